@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dzianismalei/infoBro/internal/breaker"
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/connectors"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/queue"
+	"github.com/dzianismalei/infoBro/internal/storage"
+)
+
+// app holds the config and the lazily opened connections shared by all commands
+type app struct {
+	cfg   *config.Config
+	mongo *storage.MongoDB
+	redis *queue.RedisQueue
+	fetch *httpfetch.Transport
+}
+
+// storage connects to MongoDB on first use
+func (a *app) storage() (*storage.MongoDB, error) {
+	if a.mongo != nil {
+		return a.mongo, nil
+	}
+
+	mongoStorage, err := storage.NewMongoDB(
+		a.cfg.Storage.MongoURI.Value(),
+		a.cfg.Storage.Database,
+		a.cfg.Storage.Collections.RawNews,
+		a.cfg.Storage.Collections.ProcessedNews,
+		a.cfg.Storage.Collections.ChannelStates,
+		a.cfg.Storage.Collections.Breakers,
+		a.cfg.Storage.Collections.Backfills,
+		a.cfg.Storage.ConnectTimeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mongo = mongoStorage
+	return a.mongo, nil
+}
+
+// queue connects to Redis on first use
+func (a *app) queue() (*queue.RedisQueue, error) {
+	if a.redis != nil {
+		return a.redis, nil
+	}
+
+	redisQueue, err := queue.NewRedisQueue(
+		a.cfg.Queue.Addr,
+		a.cfg.Queue.Password.Value(),
+		a.cfg.Queue.DB,
+		a.cfg.Queue.Keys.Queue,
+		a.cfg.Queue.Keys.Processing,
+		a.cfg.Queue.Keys.Failed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.redis = redisQueue
+	return a.redis, nil
+}
+
+// transport creates the HTTP transport shared by connectors on first use
+func (a *app) transport() (*httpfetch.Transport, error) {
+	if a.fetch != nil {
+		return a.fetch, nil
+	}
+
+	transport, err := newTransport(a.cfg.Fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	a.fetch = transport
+	return a.fetch, nil
+}
+
+// newTransport creates an HTTP transport from the fetch config
+func newTransport(cfg config.FetchConfig) (*httpfetch.Transport, error) {
+	transport, err := httpfetch.New(fetchOptions(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP transport: %w", err)
+	}
+	return transport, nil
+}
+
+// newPreviewTransport creates a transport like newTransport that only reaches public
+// hosts, since previewed sources are defined by API clients
+func newPreviewTransport(cfg config.FetchConfig) (*httpfetch.Transport, error) {
+	options := fetchOptions(cfg)
+	options.PublicOnly = true
+	transport, err := httpfetch.New(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP transport: %w", err)
+	}
+	return transport, nil
+}
+
+// fetchOptions converts the fetch config to transport options
+func fetchOptions(cfg config.FetchConfig) httpfetch.Options {
+	hosts := make(map[string]httpfetch.Limit, len(cfg.Hosts))
+	for host, limit := range cfg.Hosts {
+		hosts[host] = httpfetch.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+
+	return httpfetch.Options{
+		UserAgent:  cfg.UserAgent,
+		Proxy:      cfg.Proxy.Value(),
+		Limit:      httpfetch.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst},
+		Hosts:      hosts,
+		MaxRetries: cfg.Retry.MaxAttempts - 1,
+		BaseDelay:  cfg.Retry.BaseDelay,
+		MaxDelay:   cfg.Retry.MaxDelay,
+	}
+}
+
+// connectorService creates every enabled connector and a service that stores their news.
+// Connectors that cannot be created are logged and skipped.
+func (a *app) connectorService() (*connectors.ConnectorService, error) {
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return nil, err
+	}
+	redisQueue, err := a.queue()
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := a.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	connectorMap, err := connectors.NewFactory(&a.cfg.Connectors, mongoStorage, transport).CreateAllConnectors()
+	if err != nil {
+		log.Printf("Warning: some connectors could not be created: %v", err)
+	}
+
+	breakers := breaker.New(mongoStorage, a.breakerSettings())
+	return connectors.NewConnectorService(connectorMap, mongoStorage, mongoStorage, redisQueue, breakers, a.runSettings()), nil
+}
+
+// breakerSettings returns the configured source breaker settings
+func (a *app) breakerSettings() breaker.Settings {
+	return breaker.Settings{
+		Threshold:   a.cfg.Breaker.Threshold,
+		CoolDown:    a.cfg.Breaker.CoolDown,
+		MaxCoolDown: a.cfg.Breaker.MaxCoolDown,
+	}
+}
+
+// runSettings returns the configured bounds of connector runs
+func (a *app) runSettings() connectors.RunSettings {
+	return connectors.RunSettings{
+		Workers:          a.cfg.Runner.Workers,
+		ConnectorTimeout: a.cfg.Runner.ConnectorTimeout,
+		Deadline:         a.cfg.Runner.Deadline,
+	}
+}
+
+// backfiller creates a backfiller for the connectors of service, keeping its progress in MongoDB
+func (a *app) backfiller(service *connectors.ConnectorService) (*connectors.Backfiller, error) {
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return nil, err
+	}
+	return connectors.NewBackfiller(service, mongoStorage, connectors.BackfillSettings{
+		PageDelay: a.cfg.Backfill.PageDelay,
+		MaxPages:  a.cfg.Backfill.MaxPages,
+	}), nil
+}
+
+// close closes every connection that was opened
+func (a *app) close() {
+	if a.mongo != nil {
+		if err := a.mongo.Close(context.Background()); err != nil {
+			log.Printf("Failed to close MongoDB connection: %v", err)
+		}
+	}
+	if a.redis != nil {
+		if err := a.redis.Close(); err != nil {
+			log.Printf("Failed to close Redis connection: %v", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/connectors"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// backfillUsage is the usage line of the backfill command
+const backfillUsage = "backfill <connector> <source> --since <time> [--until <time>] [--status] [--json]"
+
+// runBackfill fills in the history of a source between two times. Running it again
+// with the same range resumes a stopped or failed backfill.
+func runBackfill(args []string) int {
+	fs, common := newFlagSet("backfill")
+	sinceFlag := fs.String("since", "", "Start of the range: RFC 3339 time, YYYY-MM-DD or an age such as 30d")
+	untilFlag := fs.String("until", "", "End of the range in the same formats (default now)")
+	status := fs.Bool("status", false, "Print the progress of the last backfill of the source instead of running one")
+	asJSON := fs.Bool("json", false, "Print the progress as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(positional) != 2 || (*sinceFlag == "" && !*status) {
+		return usageError(backfillUsage)
+	}
+	name, source := positional[0], positional[1]
+
+	now := time.Now()
+	var since, until time.Time
+	if *sinceFlag != "" {
+		if since, err = connectors.ParseBackfillTime(*sinceFlag, now); err != nil {
+			return fail(err)
+		}
+	}
+	if *untilFlag != "" {
+		if until, err = connectors.ParseBackfillTime(*untilFlag, now); err != nil {
+			return fail(err)
+		}
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	service, err := a.connectorService()
+	if err != nil {
+		return fail(err)
+	}
+	backfiller, err := a.backfiller(service)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	var backfill *models.Backfill
+	if *status {
+		backfill, err = backfiller.Status(ctx, name, source)
+		if err == nil && backfill == nil {
+			err = fmt.Errorf("%s/%s was never backfilled", name, source)
+		}
+	} else {
+		backfill, err = backfiller.Run(ctx, name, source, since, until)
+	}
+	if backfill != nil {
+		if printErr := printBackfill(backfill, *asJSON); printErr != nil {
+			return fail(printErr)
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// printBackfill writes the progress of a backfill as indented JSON or as text
+func printBackfill(backfill *models.Backfill, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(backfill)
+	}
+
+	fmt.Printf("%s/%s %s: %d pages, %d news stored between %s and %s\n",
+		backfill.Connector, backfill.Source, backfill.State, backfill.Pages, backfill.Stored,
+		backfill.Since.Local().Format(time.DateTime), backfill.Until.Local().Format(time.DateTime))
+	if !backfill.Oldest.IsZero() {
+		fmt.Printf("Oldest news read: %s\n", backfill.Oldest.Local().Format(time.DateTime))
+	}
+	if backfill.LastError != "" {
+		fmt.Printf("Last error: %s\n", backfill.LastError)
+	}
+	if backfill.State == models.BackfillStopped || backfill.State == models.BackfillFailed {
+		fmt.Println("Run the same command again to resume")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+)
+
+// configUsage is the usage line of the config command
+const configUsage = "config validate [connectors-file] | print"
+
+// runConfig validates or prints the effective config
+func runConfig(args []string) int {
+	fs, common := newFlagSet("config")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch {
+	case len(positional) == 2 && positional[0] == "validate":
+		return validateConnectorsConfig(positional[1])
+	case len(positional) == 1 && positional[0] == "validate":
+		return validateConfig(*common.appConfigPath, common.overrides)
+	case len(positional) == 1 && positional[0] == "print":
+		return printConfig(*common.appConfigPath, common.overrides)
+	default:
+		return usageError(configUsage)
+	}
+}
+
+// validateConfig loads and validates the application and connectors config
+func validateConfig(appConfigPath string, flags *config.Flags) int {
+	cfg, err := config.Load(appConfigPath, flags)
+	if err != nil {
+		return reportConfigError(err)
+	}
+	fmt.Printf("%s: OK\n%s: OK\n", appConfigPath, cfg.ConnectorsFile)
+	return 0
+}
+
+// validateConnectorsConfig loads and validates a single connectors config file
+func validateConnectorsConfig(path string) int {
+	if _, err := config.LoadConnectorsConfig(path); err != nil {
+		return reportConfigError(err)
+	}
+	fmt.Printf("%s: OK\n", path)
+	return 0
+}
+
+// printConfig prints the effective config with every secret redacted
+func printConfig(appConfigPath string, flags *config.Flags) int {
+	cfg, err := config.Load(appConfigPath, flags)
+	if err != nil {
+		return reportConfigError(err)
+	}
+
+	out, err := cfg.Redacted()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render config: %v\n", err)
+		return 1
+	}
+	fmt.Print(out)
+	return 0
+}
+
+// reportConfigError prints every problem found in a config and returns the exit code
+func reportConfigError(err error) int {
+	var validationErrs config.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, validationErr := range validationErrs {
+			fmt.Fprintln(os.Stderr, validationErr)
+		}
+		fmt.Fprintf(os.Stderr, "%d error(s)\n", len(validationErrs))
+		return 1
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+	return 1
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/breaker"
+	"github.com/dzianismalei/infoBro/internal/connectors"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+)
+
+// fetchUsage is the usage line of the fetch command
+const fetchUsage = "fetch <connector> [--dry-run] [--json]"
+
+// runFetch runs a single connector once through the connector service. By default the
+// news is stored and queued like a regular run and the result of the run is printed.
+// With --dry-run the news is kept in memory and printed instead.
+func runFetch(args []string) int {
+	fs, common := newFlagSet("fetch")
+	dryRun := fs.Bool("dry-run", false, "Do not store or queue news and do not advance the connector state")
+	asJSON := fs.Bool("json", false, "Print the news or the run result as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(positional) != 1 {
+		return usageError(fetchUsage)
+	}
+	name := positional[0]
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if *dryRun {
+		return dryRunFetch(ctx, a, name, *asJSON)
+	}
+
+	service, err := a.connectorService()
+	if err != nil {
+		return fail(err)
+	}
+	if !service.HasConnector(name) {
+		return fail(fmt.Errorf("connector %s is not enabled or could not be created", name))
+	}
+
+	result, err := service.RunConnector(ctx, name)
+	if printErr := printResult(os.Stdout, name, result, *asJSON); printErr != nil {
+		return fail(printErr)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// dryRunFetch runs a connector through a service that keeps news, connector state,
+// cache validators and breakers in memory, and prints the news it fetched
+func dryRunFetch(ctx context.Context, a *app, name string, asJSON bool) int {
+	stateRepo := storage.NewMemoryStateRepository()
+	transport, err := newTransport(a.cfg.Fetch)
+	if err != nil {
+		return fail(err)
+	}
+	connector, err := connectors.NewFactory(&a.cfg.Connectors, stateRepo, transport).CreateConnector(name)
+	if err != nil {
+		return fail(err)
+	}
+
+	newsStorage := storage.NewMemoryNewsStorage()
+	breakers := breaker.New(storage.NewMemoryBreakerRepository(), a.breakerSettings())
+	service := connectors.NewConnectorService(map[string]models.NewsConnector{name: connector},
+		newsStorage, stateRepo, newsStorage, breakers, a.runSettings())
+	defer service.RemoveConnector(name)
+
+	result, err := service.RunConnector(ctx, name)
+	news := newsStorage.News()
+	if printErr := printNews(os.Stdout, news, asJSON); printErr != nil {
+		return fail(printErr)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	log.Printf("Dry run: fetched %d items from %s in %d requests, nothing stored", len(news), name, result.Requests)
+	return 0
+}
+
+// printResult writes the result of a connector run as indented JSON or as text
+func printResult(w io.Writer, name string, result connectors.ConnectorResult, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	fmt.Fprintf(w, "%s %s: %d news stored and queued, %d requests, %d cache hits, %d retries, %d throttled\n",
+		name, result.Status, result.Processed, result.Requests, result.CacheHits, result.Retries, result.Throttled)
+	if len(result.Paused) > 0 {
+		fmt.Fprintf(w, "Paused sources: %s\n", strings.Join(result.Paused, ", "))
+	}
+	return nil
+}
+
+// printNews writes news as indented JSON or as a table
+func printNews(w io.Writer, news []models.RawNews, asJSON bool) error {
+	if asJSON {
+		if news == nil {
+			news = []models.RawNews{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(news)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PUBLISHED\tSOURCE\tTITLE\tURL")
+	for _, item := range news {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n",
+			item.PublishedAt.Local().Format(time.DateTime), item.SourceName, item.Title, item.URL)
+	}
+	return table.Flush()
+}
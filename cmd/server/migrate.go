@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/storage"
+)
+
+// migrateUsage is the usage line of the migrate command
+const migrateUsage = "migrate [up|status]"
+
+// runMigrate applies pending schema and index migrations or lists their status
+func runMigrate(args []string) int {
+	fs, common := newFlagSet("migrate")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	action := "up"
+	if len(positional) == 1 {
+		action = positional[0]
+	}
+	if len(positional) > 1 || (action != "up" && action != "status") {
+		return usageError(migrateUsage)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if action == "status" {
+		applied, err := mongoStorage.AppliedMigrations(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		appliedAt := make(map[int]time.Time, len(applied))
+		for _, migration := range applied {
+			appliedAt[migration.Version] = migration.AppliedAt
+		}
+		for _, migration := range storage.Migrations() {
+			status := "pending"
+			if at, ok := appliedAt[migration.Version]; ok {
+				status = "applied " + at.Local().Format(time.DateTime)
+			}
+			fmt.Printf("%3d  %-28s  %s\n", migration.Version, status, migration.Description)
+		}
+		return 0
+	}
+
+	ran, err := mongoStorage.Migrate(ctx)
+	for _, migration := range ran {
+		fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if len(ran) == 0 {
+		fmt.Println("Database is up to date")
+	}
+	return 0
+}
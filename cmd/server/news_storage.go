@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/api"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// previewLength is the number of characters shown in a news list preview
+const previewLength = 150
+
+// queryTimeout bounds a single news query
+const queryTimeout = 10 * time.Second
+
+// newsStorage serves processed news from MongoDB to the API
+type newsStorage struct {
+	mongo *storage.MongoDB
+}
+
+// newNewsStorage creates an api.NewsStorage backed by the processed news collection
+func newNewsStorage(mongo *storage.MongoDB) *newsStorage {
+	return &newsStorage{mongo: mongo}
+}
+
+// GetNewsList gets a list of news items with pagination
+func (s *newsStorage) GetNewsList(filters map[string]interface{}, page, pageSize int) (*api.NewsListResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	var filter storage.ProcessedNewsFilter
+	filter.SourceType, _ = filters["source_type"].(string)
+	filter.SourceID, _ = filters["source_id"].(string)
+	filter.Query, _ = filters["query"].(string)
+	filter.FromDate, _ = filters["from_date"].(time.Time)
+	filter.ToDate, _ = filters["to_date"].(time.Time)
+
+	list := s.mongo.ListProcessedNews
+	grouped := filters["group_by"] == "story"
+	if grouped {
+		list = s.mongo.ListStories
+	}
+	news, total, err := list(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.storyMembers(ctx, news)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]api.NewsItem, 0, len(news))
+	for _, item := range news {
+		apiItem := toNewsItem(item)
+		apiItem.ContentPreview = preview(plainText(item))
+		apiItem.Content, apiItem.ContentHTML, apiItem.ContentText = "", "", ""
+		apiItem.AlsoSeenOn = alsoSeenOn(item, members[item.StoryID])
+		if grouped {
+			apiItem.MemberCount = max(len(members[item.StoryID]), 1)
+			apiItem.Sources = storySources(item, members[item.StoryID])
+		}
+		items = append(items, apiItem)
+	}
+
+	return &api.NewsListResult{
+		Items: items,
+		Pagination: api.Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+			TotalItems: int(total),
+		},
+	}, nil
+}
+
+// GetNewsById gets a specific news item by ID
+func (s *newsStorage) GetNewsById(id string) (*api.NewsItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	news, err := s.mongo.GetProcessedNews(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.storyMembers(ctx, []models.ProcessedNews{*news})
+	if err != nil {
+		return nil, err
+	}
+
+	item := toNewsItem(*news)
+	item.AlsoSeenOn = alsoSeenOn(*news, members[news.StoryID])
+	return &item, nil
+}
+
+// storyMembers loads every item of the stories of news, whatever the list filters
+func (s *newsStorage) storyMembers(ctx context.Context, news []models.ProcessedNews) (map[string][]models.ProcessedNews, error) {
+	var storyIDs []string
+	seen := make(map[string]bool)
+	for _, item := range news {
+		if item.StoryID != "" && !seen[item.StoryID] {
+			seen[item.StoryID] = true
+			storyIDs = append(storyIDs, item.StoryID)
+		}
+	}
+	return s.mongo.StoryMembers(ctx, storyIDs)
+}
+
+// storySources counts the items of a story by source, in order of first appearance
+func storySources(news models.ProcessedNews, members []models.ProcessedNews) []api.StorySource {
+	if len(members) == 0 {
+		members = []models.ProcessedNews{news}
+	}
+	var sources []api.StorySource
+	index := make(map[[2]string]int)
+	for _, member := range members {
+		key := [2]string{member.SourceType, member.SourceName}
+		if i, ok := index[key]; ok {
+			sources[i].Count++
+			continue
+		}
+		index[key] = len(sources)
+		sources = append(sources, api.StorySource{SourceType: member.SourceType, SourceName: member.SourceName, Count: 1})
+	}
+	return sources
+}
+
+// alsoSeenOn lists the members of a story other than news
+func alsoSeenOn(news models.ProcessedNews, members []models.ProcessedNews) []api.SeenOn {
+	var seen []api.SeenOn
+	for _, member := range members {
+		if member.ID == news.ID {
+			continue
+		}
+		seen = append(seen, api.SeenOn{
+			ID:          member.ID.Hex(),
+			SourceType:  member.SourceType,
+			SourceName:  member.SourceName,
+			URL:         member.URL,
+			PublishedAt: member.PublishedAt,
+		})
+	}
+	return seen
+}
+
+// toNewsItem converts a processed news item to its API representation
+func toNewsItem(news models.ProcessedNews) api.NewsItem {
+	return api.NewsItem{
+		ID:             news.ID.Hex(),
+		Title:          news.Title,
+		Content:        news.Content,
+		ContentHTML:    news.ContentHTML,
+		ContentText:    news.ContentText,
+		LeadImage:      news.LeadImage,
+		WordCount:      news.WordCount,
+		ReadingMinutes: news.ReadingMinutes,
+		SourceType:     news.SourceType,
+		SourceID:       news.SourceID,
+		SourceName:     news.SourceName,
+		SourceURL:      news.SourceURL,
+		URL:            news.URL,
+		PublishedAt:    news.PublishedAt,
+		ProcessedAt:    news.ProcessedAt,
+		CanonicalURL:   news.CanonicalURL,
+		StoryID:        news.StoryID,
+	}
+}
+
+// plainText returns the plain text content of a news item; items processed before
+// content was normalized only have their original content
+func plainText(news models.ProcessedNews) string {
+	if news.ContentText != "" {
+		return news.ContentText
+	}
+	return news.Content
+}
+
+// preview truncates content to previewLength characters
+func preview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= previewLength {
+		return content
+	}
+	return string(runes[:previewLength]) + "..."
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+)
+
+// queueUsage is the usage line of the queue command
+const queueUsage = "queue stats | requeue [failed|processing] | purge <queue|processing|failed> --force"
+
+// runQueue inspects and manages the news queues
+func runQueue(args []string) int {
+	fs, common := newFlagSet("queue")
+	force := fs.Bool("force", false, "Confirm a destructive operation such as purge")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(positional) == 0 {
+		return usageError(queueUsage)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	redisQueue, err := a.queue()
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	switch {
+	case positional[0] == "stats" && len(positional) == 1:
+		stats, err := redisQueue.GetQueueStats(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		for _, name := range []string{"queue", "processing", "failed"} {
+			fmt.Printf("%-10s %d\n", name, stats[name])
+		}
+
+	case positional[0] == "requeue" && len(positional) <= 2:
+		from := "failed"
+		if len(positional) == 2 {
+			from = positional[1]
+		}
+		moved, err := redisQueue.Requeue(ctx, from)
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Moved %d item(s) from %s back to the queue\n", moved, from)
+
+	case positional[0] == "purge" && len(positional) == 2:
+		if !*force {
+			fmt.Printf("Refusing to purge %s without --force\n", positional[1])
+			return 2
+		}
+		removed, err := redisQueue.Purge(ctx, positional[1])
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Printf("Removed %d item(s) from %s\n", removed, positional[1])
+
+	default:
+		return usageError(queueUsage)
+	}
+
+	return 0
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/api"
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/connectors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// runServe runs the HTTP API until SIGINT or SIGTERM
+func runServe(args []string) int {
+	fs, common := newFlagSet("serve")
+	if _, err := parseArgs(fs, args); err != nil {
+		return fail(err)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+	cfg := a.cfg
+
+	mongoStorage, err := a.storage()
+	if err != nil {
+		log.Printf("Failed to connect to MongoDB: %v", err)
+		return 1
+	}
+	connectorService, err := a.connectorService()
+	if err != nil {
+		log.Printf("Failed to create connector service: %v", err)
+		return 1
+	}
+
+	// Backfills started through the API are stopped, and resumable, on shutdown
+	backfiller, err := a.backfiller(connectorService)
+	if err != nil {
+		log.Printf("Failed to create backfiller: %v", err)
+		return 1
+	}
+	defer backfiller.Close()
+
+	// Background tasks run until the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Reload connectors when the config file changes or on SIGHUP
+	transport, err := a.transport()
+	if err != nil {
+		log.Printf("Failed to create HTTP transport: %v", err)
+		return 1
+	}
+	reloader := connectors.NewReloader(connectorService, mongoStorage, transport, &cfg.Connectors)
+	reloadTrigger := make(chan string, 1)
+	go watchConnectorsConfig(backgroundCtx, cfg.ConnectorsFile, reloader, reloadTrigger)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			triggerReload(reloadTrigger, "SIGHUP")
+		}
+	}()
+
+	if cfg.Server.WatchInterval > 0 {
+		go config.WatchFile(backgroundCtx, cfg.ConnectorsFile, cfg.Server.WatchInterval, func() {
+			triggerReload(reloadTrigger, "file change")
+		})
+	}
+
+	// Each preview gets its own transport refusing internal hosts
+	previewer := connectors.NewPreviewer(connectorService, func() (http.RoundTripper, error) {
+		return newPreviewTransport(cfg.Fetch)
+	}, cfg.Runner.ConnectorTimeout)
+
+	// Create API
+	apiHandler := api.NewAPI(connectorService, backfiller, previewer, newNewsStorage(mongoStorage))
+
+	// Create router
+	r := chi.NewRouter()
+
+	// Middleware
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout))
+
+	// Register API routes
+	apiHandler.RegisterRoutes(r)
+
+	// Create HTTP server
+	server := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: r,
+	}
+
+	// Run connectors periodically if the scheduler is enabled
+	if cfg.Scheduler.Enabled {
+		go runScheduler(backgroundCtx, connectorService, cfg.Scheduler)
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("HTTP server listening on %s", cfg.Server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	// Handle graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down server...")
+
+	// Create shutdown context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Shutdown server
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+		return 1
+	}
+
+	log.Println("Server exited properly")
+	return 0
+}
+
+// triggerReload schedules a config reload unless one is already pending
+func triggerReload(trigger chan<- string, reason string) {
+	select {
+	case trigger <- reason:
+	default:
+	}
+}
+
+// watchConnectorsConfig reloads the connectors config on every trigger until ctx is cancelled.
+// An invalid config is logged and rejected while the previous one keeps running.
+func watchConnectorsConfig(ctx context.Context, path string, reloader *connectors.Reloader, trigger <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case reason := <-trigger:
+			log.Printf("Reloading connectors config %s (%s)", path, reason)
+
+			cfg, err := config.LoadConnectorsConfig(path)
+			if err != nil {
+				log.Printf("Rejected connectors config, keeping previous one: %v", err)
+				continue
+			}
+
+			result, err := reloader.Apply(cfg)
+			if err != nil {
+				log.Printf("Rejected connectors config, keeping previous one: %v", err)
+				continue
+			}
+
+			if !result.Changed() {
+				log.Printf("Connectors config reloaded, no connectors changed")
+				continue
+			}
+			log.Printf("Connectors config reloaded: created %v, updated %v, stopped %v",
+				result.Created, result.Updated, result.Stopped)
+		}
+	}
+}
+
+// runScheduler runs all connectors every interval until ctx is cancelled
+func runScheduler(ctx context.Context, service *connectors.ConnectorService, cfg config.SchedulerConfig) {
+	log.Printf("Scheduler running all connectors every %s", cfg.Interval)
+
+	run := func() {
+		results, err := service.RunAllConnectors(ctx)
+		if err != nil {
+			log.Printf("Scheduled run failed: %v", err)
+			return
+		}
+		for name, result := range results {
+			log.Printf("Scheduled run of %s: %s, %d processed, %d requests (%d cached, %d retried, %d throttled) %s",
+				name, result.Status, result.Processed, result.Requests, result.CacheHits, result.Retries, result.Throttled, result.Message)
+		}
+	}
+
+	if cfg.RunOnStart {
+		run()
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+)
+
+// exportUsage and importUsage are the usage lines of the export and import commands
+const (
+	exportUsage = "export <raw_news|processed_news|channel_states> [--out file]"
+	importUsage = "import <raw_news|processed_news|channel_states> [--in file]"
+)
+
+// runExport writes a collection as Extended JSON lines to a file or stdout
+func runExport(args []string) int {
+	fs, common := newFlagSet("export")
+	out := fs.String("out", "", "Output file (default stdout)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(positional) != 1 {
+		return usageError(exportUsage)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return fail(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fail(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	count, err := mongoStorage.Export(ctx, positional[0], w)
+	if err != nil {
+		return fail(err)
+	}
+	log.Printf("Exported %d document(s) from %s", count, positional[0])
+	return 0
+}
+
+// runImport upserts Extended JSON lines from a file or stdin into a collection
+func runImport(args []string) int {
+	fs, common := newFlagSet("import")
+	in := fs.String("in", "", "Input file (default stdin)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(positional) != 1 {
+		return usageError(importUsage)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return fail(err)
+	}
+
+	var r io.Reader = bufio.NewReader(os.Stdin)
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return fail(err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	count, err := mongoStorage.Import(ctx, positional[0], r)
+	if err != nil {
+		log.Printf("Imported %d document(s) before the error", count)
+		return fail(err)
+	}
+	log.Printf("Imported %d document(s) into %s", count, positional[0])
+	return 0
+}
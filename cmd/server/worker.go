@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/dzianismalei/infoBro/internal/processor"
+)
+
+// runWorker runs the processor until SIGINT or SIGTERM
+func runWorker(args []string) int {
+	fs, common := newFlagSet("worker")
+	if _, err := parseArgs(fs, args); err != nil {
+		return fail(err)
+	}
+
+	a, err := common.newApp()
+	if err != nil {
+		return fail(err)
+	}
+	defer a.close()
+
+	mongoStorage, err := a.storage()
+	if err != nil {
+		return fail(err)
+	}
+	redisQueue, err := a.queue()
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	var stories processor.StorySettings
+	if a.cfg.Clustering.Enabled {
+		stories = processor.StorySettings{
+			Threshold:     a.cfg.Clustering.Threshold,
+			Window:        a.cfg.Clustering.Window,
+			MaxCandidates: a.cfg.Clustering.MaxCandidates,
+		}
+	}
+
+	// Items left in the processing list were interrupted by a shutdown. Processing is
+	// idempotent, so requeueing items another worker process is still on is harmless.
+	moved, err := redisQueue.Requeue(ctx, "processing")
+	if err != nil {
+		return fail(fmt.Errorf("failed to requeue interrupted news: %w", err))
+	}
+	if moved > 0 {
+		log.Printf("Requeued %d news interrupted by the last shutdown", moved)
+	}
+
+	p := processor.New(redisQueue, mongoStorage, a.cfg.Processor.PollTimeout,
+		processor.StageFunc(processor.NormalizeStage),
+		processor.StageFunc(processor.CanonicalURLStage),
+		processor.NewStoryStage(mongoStorage, stories),
+	)
+	log.Printf("Processor running with %d worker(s)", a.cfg.Processor.Workers)
+	if err := p.Run(ctx, a.cfg.Processor.Workers); err != nil && !errors.Is(err, context.Canceled) {
+		return fail(err)
+	}
+
+	log.Println("Processor stopped")
+	return 0
+}
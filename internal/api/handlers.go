@@ -1,26 +1,41 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/dzianismalei/infoBro/internal/config"
 	"github.com/dzianismalei/infoBro/internal/connectors"
+	"github.com/dzianismalei/infoBro/internal/connectors/webhook"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// maxIngestBodySize bounds the body of a push ingestion request
+const maxIngestBodySize = 1 << 20
+
+// maxPreviewBodySize bounds the body of a connector preview request
+const maxPreviewBodySize = 64 << 10
+
 // API handles HTTP requests for the news dashboard
 type API struct {
 	connectorService *connectors.ConnectorService
+	backfiller       *connectors.Backfiller
+	previewer        *connectors.Previewer
 	newsStorage      NewsStorage
 }
 
 // NewAPI creates a new API handler
-func NewAPI(connectorService *connectors.ConnectorService, newsStorage NewsStorage) *API {
+func NewAPI(connectorService *connectors.ConnectorService, backfiller *connectors.Backfiller, previewer *connectors.Previewer, newsStorage NewsStorage) *API {
 	return &API{
 		connectorService: connectorService,
+		backfiller:       backfiller,
+		previewer:        previewer,
 		newsStorage:      newsStorage,
 	}
 }
@@ -37,6 +52,12 @@ type NewsItem struct {
 	Title         string    `json:"title"`
 	ContentPreview string    `json:"content_preview,omitempty"`
 	Content       string    `json:"content,omitempty"`
+	// ContentHTML is the sanitized content, safe to render, and ContentText its plain text
+	ContentHTML    string `json:"content_html,omitempty"`
+	ContentText    string `json:"content_text,omitempty"`
+	LeadImage      string `json:"lead_image,omitempty"`
+	WordCount      int    `json:"word_count,omitempty"`
+	ReadingMinutes int    `json:"reading_minutes,omitempty"`
 	SourceType    string    `json:"source_type"`
 	SourceID      string    `json:"source_id"`
 	SourceName    string    `json:"source_name"`
@@ -44,6 +65,29 @@ type NewsItem struct {
 	URL           string    `json:"url"`
 	PublishedAt   time.Time `json:"published_at"`
 	ProcessedAt   time.Time `json:"processed_at"`
+	CanonicalURL  string    `json:"canonical_url,omitempty"`
+	StoryID       string    `json:"story_id,omitempty"`
+	// AlsoSeenOn lists the other items of the same story
+	AlsoSeenOn []SeenOn `json:"also_seen_on,omitempty"`
+	// MemberCount and Sources describe the whole story when news is grouped by story
+	MemberCount int           `json:"member_count,omitempty"`
+	Sources     []StorySource `json:"sources,omitempty"`
+}
+
+// StorySource is a source with at least one item in a story
+type StorySource struct {
+	SourceType string `json:"source_type"`
+	SourceName string `json:"source_name"`
+	Count      int    `json:"count"`
+}
+
+// SeenOn is another item about the same story, from the same or another source
+type SeenOn struct {
+	ID          string    `json:"id"`
+	SourceType  string    `json:"source_type"`
+	SourceName  string    `json:"source_name"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
 }
 
 // NewsListResult represents paginated news results
@@ -75,12 +119,20 @@ func (a *API) RegisterRoutes(r chi.Router) {
 		r.Get("/news/{id}", a.GetNewsById)
 		
 		// Connector endpoints
+		r.Get("/connectors", a.GetConnectors)
+		r.Post("/connectors/preview", a.PreviewConnector)
+		r.Post("/connectors/{name}/breakers/reset", a.ResetBreaker)
+		r.Post("/connectors/{name}/backfill", a.StartBackfill)
+		r.Get("/connectors/{name}/backfill", a.GetBackfill)
 		r.Post("/connectors/run/{name}", a.RunConnector)
 		r.Post("/connectors/run-all", a.RunAllConnectors)
+
+		// Push ingestion endpoint
+		r.Post("/ingest/{source}", a.IngestNews)
 	})
 }
 
-// GetNewsList handles requests for filtered news lists
+// GetNewsList handles requests for filtered news lists; group_by=story lists each story once
 func (a *API) GetNewsList(w http.ResponseWriter, r *http.Request) {
 	// Parse filters from query parameters
 	filters := make(map[string]interface{})
@@ -108,6 +160,16 @@ func (a *API) GetNewsList(w http.ResponseWriter, r *http.Request) {
 			filters["to_date"] = date
 		}
 	}
+
+	// List each story once
+	switch groupBy := r.URL.Query().Get("group_by"); groupBy {
+	case "":
+	case "story":
+		filters["group_by"] = groupBy
+	default:
+		a.respondWithError(w, http.StatusBadRequest, "Invalid group_by "+strconv.Quote(groupBy)+", must be story")
+		return
+	}
 	
 	// Parse pagination parameters
 	page := 1
@@ -164,8 +226,12 @@ func (a *API) GetNewsById(w http.ResponseWriter, r *http.Request) {
 func (a *API) RunConnector(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	
-	count, err := a.connectorService.RunConnector(r.Context(), name)
+	result, err := a.connectorService.RunConnector(r.Context(), name)
 	if err != nil {
+		if result.Status == "timeout" {
+			a.respondWithError(w, http.StatusGatewayTimeout, "Connector timed out: "+err.Error())
+			return
+		}
 		a.respondWithError(w, http.StatusInternalServerError, "Failed to run connector: "+err.Error())
 		return
 	}
@@ -173,12 +239,182 @@ func (a *API) RunConnector(w http.ResponseWriter, r *http.Request) {
 	a.respondWithJSON(w, http.StatusOK, Response{
 		Success: true,
 		Data: map[string]interface{}{
-			"processed":  count,
+			"processed":  result.Processed,
 			"connector":  name,
+			"paused":     result.Paused,
+			"requests":   result.Requests,
+			"cache_hits": result.CacheHits,
+			"retries":    result.Retries,
+			"throttled":  result.Throttled,
+		},
+	})
+}
+
+// GetConnectors handles requests for the status of every connector, including the
+// circuit breakers of failing sources
+func (a *API) GetConnectors(w http.ResponseWriter, r *http.Request) {
+	statuses, err := a.connectorService.Status(r.Context())
+	if err != nil {
+		a.respondWithError(w, http.StatusInternalServerError, "Failed to get connector status: "+err.Error())
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"connectors": statuses,
+		},
+	})
+}
+
+// ResetBreaker handles requests to close the circuit breaker of a source. The source
+// is passed as a query parameter because names like GitHub repositories contain slashes.
+func (a *API) ResetBreaker(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		a.respondWithError(w, http.StatusBadRequest, "The source query parameter is required")
+		return
+	}
+
+	if err := a.connectorService.ResetBreaker(r.Context(), name, source); err != nil {
+		if errors.Is(err, connectors.ErrUnknownSource) {
+			a.respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		a.respondWithError(w, http.StatusInternalServerError, "Failed to reset breaker: "+err.Error())
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"connector": name,
+			"source":    source,
 		},
 	})
 }
 
+// StartBackfill handles requests to fill in the history of a source. The backfill runs
+// in the background; its progress is returned by GetBackfill.
+func (a *API) StartBackfill(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	query := r.URL.Query()
+	source := query.Get("source")
+	if source == "" || query.Get("since") == "" {
+		a.respondWithError(w, http.StatusBadRequest, "The source and since query parameters are required")
+		return
+	}
+
+	now := time.Now()
+	since, err := connectors.ParseBackfillTime(query.Get("since"), now)
+	if err != nil {
+		a.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var until time.Time
+	if value := query.Get("until"); value != "" {
+		if until, err = connectors.ParseBackfillTime(value, now); err != nil {
+			a.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	backfill, err := a.backfiller.Start(r.Context(), name, source, since, until)
+	if err != nil {
+		switch {
+		case errors.Is(err, connectors.ErrUnknownSource):
+			a.respondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, connectors.ErrBackfillRunning):
+			a.respondWithError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, connectors.ErrBackfillUnsupported), errors.Is(err, connectors.ErrInvalidBackfillRange):
+			a.respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			a.respondWithError(w, http.StatusInternalServerError, "Failed to start backfill: "+err.Error())
+		}
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusAccepted, Response{
+		Success: true,
+		Data:    backfill,
+	})
+}
+
+// GetBackfill handles requests for the progress of the last backfill of a source
+func (a *API) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		a.respondWithError(w, http.StatusBadRequest, "The source query parameter is required")
+		return
+	}
+
+	backfill, err := a.backfiller.Status(r.Context(), name, source)
+	if err != nil {
+		if errors.Is(err, connectors.ErrUnknownSource) || errors.Is(err, connectors.ErrBackfillUnsupported) {
+			a.respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		a.respondWithError(w, http.StatusInternalServerError, "Failed to get backfill: "+err.Error())
+		return
+	}
+	if backfill == nil {
+		a.respondWithError(w, http.StatusNotFound, "The source was never backfilled")
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    backfill,
+	})
+}
+
+// PreviewRequest is an ad-hoc source definition to preview
+type PreviewRequest struct {
+	// Type is the connector type, such as reddit or youtube
+	Type string `json:"type"`
+	// Settings has the fields of the connector section in connectors.yaml
+	Settings json.RawMessage `json:"settings"`
+	// Limit bounds the number of items returned
+	Limit int `json:"limit"`
+}
+
+// PreviewConnector handles requests to fetch an ad-hoc source definition once and
+// return what would be collected, without storing anything
+func (a *API) PreviewConnector(w http.ResponseWriter, r *http.Request) {
+	var request PreviewRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewBodySize)).Decode(&request); err != nil {
+		a.respondWithError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if request.Type == "" || len(request.Settings) == 0 {
+		a.respondWithError(w, http.StatusBadRequest, "The type and settings fields are required")
+		return
+	}
+
+	result, err := a.previewer.Preview(r.Context(), request.Type, request.Settings, request.Limit)
+	if err != nil {
+		var invalid config.ValidationErrors
+		switch {
+		case errors.As(err, &invalid), errors.Is(err, connectors.ErrPreviewUnsupported):
+			a.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			a.respondWithError(w, http.StatusGatewayTimeout, "Preview timed out: "+err.Error())
+		case errors.Is(err, connectors.ErrPreviewFailed):
+			a.respondWithError(w, http.StatusBadGateway, err.Error())
+		default:
+			a.respondWithError(w, http.StatusInternalServerError, "Failed to preview connector: "+err.Error())
+		}
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
 // RunAllConnectors handles requests to run all connectors
 func (a *API) RunAllConnectors(w http.ResponseWriter, r *http.Request) {
 	results, err := a.connectorService.RunAllConnectors(r.Context())
@@ -195,6 +431,45 @@ func (a *API) RunAllConnectors(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// IngestNews handles news pushed by a webhook source
+func (a *API) IngestNews(w http.ResponseWriter, r *http.Request) {
+	source := chi.URLParam(r, "source")
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodySize))
+	if err != nil {
+		if errors.As(err, new(*http.MaxBytesError)) {
+			a.respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+		} else {
+			a.respondWithError(w, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+		}
+		return
+	}
+
+	count, err := a.connectorService.IngestNews(r.Context(), "webhook", source, payload, r.Header)
+	if err != nil {
+		var invalid *webhook.InvalidPayloadError
+		switch {
+		case errors.As(err, &invalid):
+			a.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, webhook.ErrUnauthorized):
+			a.respondWithError(w, http.StatusUnauthorized, "Invalid signature")
+		case errors.Is(err, webhook.ErrUnknownSource), !a.connectorService.HasConnector("webhook"):
+			a.respondWithError(w, http.StatusNotFound, "Unknown source")
+		default:
+			a.respondWithError(w, http.StatusInternalServerError, "Failed to ingest news: "+err.Error())
+		}
+		return
+	}
+
+	a.respondWithJSON(w, http.StatusAccepted, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"processed": count,
+			"source":    source,
+		},
+	})
+}
+
 // respondWithJSON sends a JSON response
 func (a *API) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
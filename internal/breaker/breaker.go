@@ -0,0 +1,202 @@
+// Package breaker pauses sources that fail repeatedly. After Threshold consecutive
+// failures the breaker of a source opens and the source is skipped for a cool-down that
+// doubles with every failed probe. When the cool-down has passed the breaker half-opens
+// and lets a single probe fetch through, which closes it again on success.
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// maxErrorLength bounds the error message kept with a breaker
+const maxErrorLength = 500
+
+// Settings configures a Manager
+type Settings struct {
+	// Threshold is the number of consecutive failures that open a breaker, 0 disables breakers
+	Threshold   int
+	CoolDown    time.Duration
+	MaxCoolDown time.Duration
+}
+
+// Manager tracks the breakers of all sources. Breakers are loaded from the repository
+// on first use and every change is written through, so they survive restarts.
+type Manager struct {
+	repository models.BreakerRepository
+	settings   Settings
+	now        func() time.Time
+
+	mu       sync.Mutex
+	loaded   bool
+	breakers map[key]*models.SourceBreaker
+}
+
+type key struct {
+	connector string
+	source    string
+}
+
+// New creates a breaker manager storing its breakers in repo
+func New(repo models.BreakerRepository, settings Settings) *Manager {
+	return &Manager{
+		repository: repo,
+		settings:   settings,
+		now:        time.Now,
+		breakers:   make(map[key]*models.SourceBreaker),
+	}
+}
+
+// Allow reports whether a source may be fetched. An open breaker whose cool-down has
+// passed turns half-open and allows one probe; further fetches wait for the probe's
+// result, or for another cool-down if the probe never reports back.
+func (m *Manager) Allow(ctx context.Context, connector, source string) (bool, error) {
+	if m.settings.Threshold <= 0 {
+		return true, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(ctx); err != nil {
+		return true, err
+	}
+
+	breaker, ok := m.breakers[key{connector, source}]
+	if !ok || breaker.State == models.BreakerClosed {
+		return true, nil
+	}
+	now := m.now()
+	if now.Before(breaker.RetryAt) {
+		return false, nil
+	}
+
+	breaker.State = models.BreakerHalfOpen
+	breaker.RetryAt = now.Add(m.coolDown(breaker.Trips))
+	breaker.UpdatedAt = now
+	return true, m.save(ctx, breaker)
+}
+
+// Record reports the outcome of a fetch. A success closes the breaker of the source;
+// a failure counts towards opening it, and reopens a half-open breaker right away.
+func (m *Manager) Record(ctx context.Context, connector, source string, fetchErr error) error {
+	if m.settings.Threshold <= 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+
+	k := key{connector, source}
+	breaker, ok := m.breakers[k]
+	if fetchErr == nil {
+		if !ok {
+			return nil
+		}
+		delete(m.breakers, k)
+		if err := m.repository.DeleteBreaker(ctx, connector, source); err != nil {
+			return fmt.Errorf("failed to close breaker of %s/%s: %w", connector, source, err)
+		}
+		return nil
+	}
+
+	now := m.now()
+	if !ok {
+		breaker = &models.SourceBreaker{Connector: connector, Source: source, State: models.BreakerClosed}
+		m.breakers[k] = breaker
+	}
+	breaker.Failures++
+	breaker.LastError = truncate(fetchErr.Error(), maxErrorLength)
+	breaker.UpdatedAt = now
+	if breaker.State == models.BreakerHalfOpen || (breaker.State == models.BreakerClosed && breaker.Failures >= m.settings.Threshold) {
+		breaker.Trips++
+		breaker.State = models.BreakerOpen
+		breaker.OpenedAt = now
+		breaker.RetryAt = now.Add(m.coolDown(breaker.Trips))
+	}
+	return m.save(ctx, breaker)
+}
+
+// List returns the breakers of a connector's sources that failed recently
+func (m *Manager) List(ctx context.Context, connector string) ([]models.SourceBreaker, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(ctx); err != nil {
+		return nil, err
+	}
+
+	var breakers []models.SourceBreaker
+	for k, breaker := range m.breakers {
+		if k.connector == connector {
+			breakers = append(breakers, *breaker)
+		}
+	}
+	return breakers, nil
+}
+
+// Reset closes the breaker of a source and forgets its failures
+func (m *Manager) Reset(ctx context.Context, connector, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+
+	delete(m.breakers, key{connector, source})
+	if err := m.repository.DeleteBreaker(ctx, connector, source); err != nil {
+		return fmt.Errorf("failed to reset breaker of %s/%s: %w", connector, source, err)
+	}
+	return nil
+}
+
+// coolDown returns the pause after the given number of consecutive trips
+func (m *Manager) coolDown(trips int) time.Duration {
+	delay := m.settings.CoolDown
+	for i := 1; i < trips && delay < m.settings.MaxCoolDown; i++ {
+		delay *= 2
+	}
+	if delay > m.settings.MaxCoolDown {
+		delay = m.settings.MaxCoolDown
+	}
+	return delay
+}
+
+// load reads the stored breakers once; the caller holds mu
+func (m *Manager) load(ctx context.Context) error {
+	if m.loaded {
+		return nil
+	}
+	breakers, err := m.repository.ListBreakers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load breakers: %w", err)
+	}
+	for i := range breakers {
+		breaker := breakers[i]
+		m.breakers[key{breaker.Connector, breaker.Source}] = &breaker
+	}
+	m.loaded = true
+	return nil
+}
+
+// save writes a breaker through to the repository; the caller holds mu
+func (m *Manager) save(ctx context.Context, breaker *models.SourceBreaker) error {
+	stored := *breaker
+	if err := m.repository.SaveBreaker(ctx, &stored); err != nil {
+		return fmt.Errorf("failed to save breaker of %s/%s: %w", breaker.Connector, breaker.Source, err)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,104 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFetch = errors.New("unexpected status 503 Service Unavailable")
+
+func newTestManager(repo models.BreakerRepository, now *time.Time) *Manager {
+	manager := New(repo, Settings{Threshold: 2, CoolDown: time.Minute, MaxCoolDown: 3 * time.Minute})
+	manager.now = func() time.Time { return *now }
+	return manager
+}
+
+func allow(t *testing.T, manager *Manager) bool {
+	t.Helper()
+	allowed, err := manager.Allow(context.Background(), "reddit", "golang")
+	require.NoError(t, err)
+	return allowed
+}
+
+func record(t *testing.T, manager *Manager, err error) {
+	t.Helper()
+	require.NoError(t, manager.Record(context.Background(), "reddit", "golang", err))
+}
+
+func TestBreakerOpensAndProbes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := storage.NewMemoryBreakerRepository()
+	manager := newTestManager(repo, &now)
+
+	record(t, manager, errFetch)
+	assert.True(t, allow(t, manager), "one failure stays below the threshold")
+	record(t, manager, errFetch)
+	assert.False(t, allow(t, manager))
+
+	breakers, err := manager.List(ctx, "reddit")
+	require.NoError(t, err)
+	require.Len(t, breakers, 1)
+	assert.Equal(t, models.BreakerOpen, breakers[0].State)
+	assert.Equal(t, 2, breakers[0].Failures)
+	assert.Equal(t, now.Add(time.Minute), breakers[0].RetryAt)
+	assert.Equal(t, errFetch.Error(), breakers[0].LastError)
+
+	// After the cool-down a single probe is let through
+	now = now.Add(time.Minute)
+	assert.True(t, allow(t, manager))
+	assert.False(t, allow(t, manager), "only one probe at a time")
+
+	// A failed probe reopens the breaker with a doubled cool-down
+	record(t, manager, errFetch)
+	breakers, _ = manager.List(ctx, "reddit")
+	assert.Equal(t, models.BreakerOpen, breakers[0].State)
+	assert.Equal(t, now.Add(2*time.Minute), breakers[0].RetryAt)
+
+	now = now.Add(2 * time.Minute)
+	assert.True(t, allow(t, manager))
+	record(t, manager, errFetch)
+	breakers, _ = manager.List(ctx, "reddit")
+	assert.Equal(t, now.Add(3*time.Minute), breakers[0].RetryAt, "cool-down is capped")
+
+	// A successful probe closes the breaker
+	now = now.Add(3 * time.Minute)
+	assert.True(t, allow(t, manager))
+	record(t, manager, nil)
+	breakers, _ = manager.List(ctx, "reddit")
+	assert.Empty(t, breakers)
+	stored, _ := repo.ListBreakers(ctx)
+	assert.Empty(t, stored)
+}
+
+func TestBreakerSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := storage.NewMemoryBreakerRepository()
+
+	manager := newTestManager(repo, &now)
+	record(t, manager, errFetch)
+	record(t, manager, errFetch)
+
+	restarted := newTestManager(repo, &now)
+	assert.False(t, allow(t, restarted))
+
+	require.NoError(t, restarted.Reset(ctx, "reddit", "golang"))
+	assert.True(t, allow(t, restarted))
+	assert.True(t, allow(t, newTestManager(repo, &now)), "reset is persisted")
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	manager := New(storage.NewMemoryBreakerRepository(), Settings{})
+	for i := 0; i < 5; i++ {
+		record(t, manager, errFetch)
+	}
+	assert.True(t, allow(t, manager))
+}
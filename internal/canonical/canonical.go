@@ -0,0 +1,302 @@
+// Package canonical reduces the many URLs of a page to a single canonical form, so
+// the same article linked from different sources can be recognised. Everything is
+// done offline: shortened or wrapped links are only expanded when the target is part
+// of the link itself.
+package canonical
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxUnwrap bounds the redirect wrappers removed from a single URL
+const maxUnwrap = 3
+
+// trackingParams are query parameters that identify a campaign or a click, not a page
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true, "gclsrc": true,
+	"msclkid": true, "yclid": true, "twclid": true, "igshid": true, "mc_cid": true, "mc_eid": true,
+	"_hsenc": true, "_hsmi": true, "mkt_tok": true, "oly_anon_id": true, "oly_enc_id": true,
+	"vero_id": true, "vero_conv": true, "ref_src": true, "ref_url": true, "cmpid": true, "ncid": true,
+	"ocid": true, "spm": true,
+}
+
+// hostTrackingParams are tracking parameters only on some hosts, where they are not ambiguous
+var hostTrackingParams = map[string]map[string]bool{
+	"youtube.com": {"feature": true, "si": true, "pp": true, "t": true},
+	"twitter.com": {"s": true, "t": true},
+	"x.com":       {"s": true, "t": true},
+}
+
+// hostAliases maps mirrors, mobile and AMP versions of a site to its main host. AMP
+// hosts are listed one by one, since amp. is also the prefix of unrelated sites.
+var hostAliases = map[string]string{
+	"old.reddit.com":      "reddit.com",
+	"new.reddit.com":      "reddit.com",
+	"np.reddit.com":       "reddit.com",
+	"m.reddit.com":        "reddit.com",
+	"m.youtube.com":       "youtube.com",
+	"music.youtube.com":   "youtube.com",
+	"mobile.twitter.com":  "twitter.com",
+	"m.facebook.com":      "facebook.com",
+	"en.m.wikipedia.org":  "en.wikipedia.org",
+	"amp.theverge.com":    "theverge.com",
+	"amp.theguardian.com": "theguardian.com",
+	"amp.cnn.com":         "cnn.com",
+	"amp.dw.com":          "dw.com",
+}
+
+// ampPublishers are the sites known to serve AMP versions of their pages under an /amp
+// path or an amp query parameter. Elsewhere these are ordinary parts of the URL.
+var ampPublishers = map[string]bool{
+	"theverge.com":    true,
+	"theguardian.com": true,
+	"cnn.com":         true,
+	"dw.com":          true,
+}
+
+// redirectParams maps redirect wrappers to the query parameter holding their target
+var redirectParams = map[string]string{
+	"google.com/url":              "q",
+	"l.facebook.com/l.php":        "u",
+	"lm.facebook.com/l.php":       "u",
+	"out.reddit.com":              "url",
+	"youtube.com/redirect":        "q",
+	"l.messenger.com/l.php":       "u",
+	"t.umblr.com/redirect":        "z",
+	"linkedin.com/redir/redirect": "url",
+}
+
+// redditPermalink matches /r/<subreddit>/comments/<id>/<slug>
+var redditPermalink = regexp.MustCompile(`^/r/[^/]+/comments/([a-z0-9]+)(?:/.*)?$`)
+
+// ampCachePath matches the path of a page served by the Google AMP viewer or cache
+var ampCachePath = regexp.MustCompile(`^/(?:amp/|[cv]/)(s/)?([^/]+)(/.*)?$`)
+
+// ErrNotAbsolute is returned for URLs without a scheme and host
+var ErrNotAbsolute = errors.New("not an absolute http(s) URL")
+
+// URL returns the canonical form of an absolute http or https URL:
+//   - the scheme is https and the host is lower case, without www. or a default port
+//   - mirrors, mobile and AMP hosts are replaced by the main host, e.g. old.reddit.com by reddit.com
+//   - redirect wrappers (google.com/url, l.facebook.com, out.reddit.com...) are replaced by their target
+//   - youtu.be and redd.it links and Reddit permalinks are expanded to the page they stand for
+//   - AMP paths and query parameters of pages from the AMP cache or known AMP publishers,
+//     tracking parameters and the fragment are dropped
+//   - the remaining query parameters are sorted and a trailing slash is removed
+func URL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+	// Pages unwrapped from the AMP cache are AMP versions wherever they are hosted
+	amp := false
+	for i := 0; i < maxUnwrap; i++ {
+		if target, ok := unwrapRedirect(u); ok {
+			u = target
+			continue
+		}
+		if target, ok := unwrapAMPCache(u); ok {
+			u = target
+			amp = true
+			continue
+		}
+		break
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrNotAbsolute
+	}
+
+	u.Scheme = "https"
+	u.User = nil
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Host = normalizeHost(u)
+	amp = amp || ampPublishers[u.Host]
+
+	expand(u)
+	u.Path = cleanPath(u.Path, amp)
+	u.RawPath = ""
+	u.RawQuery = cleanQuery(u.Host, u.Query(), amp)
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// unwrapRedirect returns the target of a redirect wrapper
+func unwrapRedirect(u *url.URL) (*url.URL, bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	if param, ok := redirectParams[host+u.Path]; ok {
+		return parseTarget(u.Query().Get(param))
+	}
+	if param, ok := redirectParams[host]; ok {
+		return parseTarget(u.Query().Get(param))
+	}
+	return nil, false
+}
+
+// unwrapAMPCache returns the page served by the Google AMP viewer or cache
+func unwrapAMPCache(u *url.URL) (*url.URL, bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host == "google.com" || strings.HasSuffix(host, ".cdn.ampproject.org") {
+		if match := ampCachePath.FindStringSubmatch(u.Path); match != nil && strings.Contains(match[2], ".") {
+			scheme := "https"
+			if match[1] == "" {
+				scheme = "http"
+			}
+			target := &url.URL{Scheme: scheme, Host: match[2], Path: match[3], RawQuery: u.RawQuery}
+			return target, true
+		}
+	}
+	return nil, false
+}
+
+// parseTarget parses the target of a redirect wrapper
+func parseTarget(target string) (*url.URL, bool) {
+	if target == "" {
+		return nil, false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, false
+	}
+	return u, true
+}
+
+// normalizeHost lowercases the host and drops www., default ports, and mirrors and AMP
+// versions known to hostAliases
+func normalizeHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimSuffix(host, ".")
+	host = strings.TrimPrefix(host, "www.")
+	if alias, ok := hostAliases[host]; ok {
+		host = alias
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return host + ":" + port
+	}
+	return host
+}
+
+// expand replaces shortened links and permalinks by the page they stand for
+func expand(u *url.URL) {
+	switch u.Host {
+	case "youtu.be":
+		if id := strings.Trim(u.Path, "/"); id != "" {
+			query := u.Query()
+			query.Set("v", id)
+			u.Host, u.Path, u.RawQuery = "youtube.com", "/watch", query.Encode()
+		}
+	case "redd.it":
+		if id := strings.Trim(u.Path, "/"); id != "" {
+			u.Host, u.Path = "reddit.com", "/comments/"+id
+		}
+	case "reddit.com":
+		if match := redditPermalink.FindStringSubmatch(u.Path); match != nil {
+			u.Path = "/comments/" + match[1]
+		}
+	}
+}
+
+// cleanPath drops dot segments, the trailing slash and, for AMP pages, AMP suffixes
+func cleanPath(p string, amp bool) string {
+	if p == "" || p == "/" {
+		return ""
+	}
+	p = path.Clean(p)
+	if amp {
+		p = strings.TrimSuffix(p, "/amp")
+		p = strings.TrimSuffix(p, "/amp.html")
+		if strings.HasPrefix(p, "/amp/") {
+			p = p[len("/amp"):]
+		}
+	}
+	if p == "/" || p == "." {
+		return ""
+	}
+	return p
+}
+
+// cleanQuery drops tracking parameters and, for AMP pages, AMP parameters, and sorts
+// the others
+func cleanQuery(host string, query url.Values, amp bool) string {
+	for key := range query {
+		lower := strings.ToLower(key)
+		isAMP := amp && (lower == "amp" || lower == "outputtype" && strings.EqualFold(query.Get(key), "amp"))
+		if isAMP || strings.HasPrefix(lower, "utm_") || trackingParams[lower] || hostTrackingParams[host][lower] {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key and keeps the order of repeated values
+	return query.Encode()
+}
+
+// FromHTML returns the target of the <link rel="canonical"> of an HTML document,
+// resolved against base, or an empty string if there is none
+func FromHTML(document, base string) string {
+	if !strings.Contains(strings.ToLower(document), "canonical") {
+		return ""
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if atom.Lookup(name) == atom.Body {
+				return ""
+			}
+			if atom.Lookup(name) != atom.Link || !hasAttr {
+				continue
+			}
+			var rel, href string
+			for {
+				key, value, more := tokenizer.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = strings.ToLower(string(value))
+				case "href":
+					href = strings.TrimSpace(string(value))
+				}
+				if !more {
+					break
+				}
+			}
+			if href == "" || !containsField(rel, "canonical") {
+				continue
+			}
+			return resolve(base, href)
+		}
+	}
+}
+
+// containsField reports whether the space separated list contains field
+func containsField(list, field string) bool {
+	for _, value := range strings.Fields(list) {
+		if value == field {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve resolves href against base, returning href as is if base is not usable
+func resolve(base, href string) string {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || base == "" {
+		return ref.String()
+	}
+	return baseURL.ResolveReference(ref).String()
+}
@@ -0,0 +1,63 @@
+package canonical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"already canonical", "https://go.dev/blog/go1.26", "https://go.dev/blog/go1.26"},
+		{"scheme, host and trailing slash", "HTTP://WWW.Go.Dev:80/blog/go1.26/", "https://go.dev/blog/go1.26"},
+		{"fragment and tracking params", "https://go.dev/blog/go1.26?utm_source=hn&utm_medium=social&fbclid=x#intro", "https://go.dev/blog/go1.26"},
+		{"params kept and sorted", "https://example.com/search?q=go&page=2&gclid=1", "https://example.com/search?page=2&q=go"},
+		{"non default port kept", "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"root", "https://example.com/", "https://example.com"},
+		{"amp host", "https://amp.theverge.com/2026/1/2/go", "https://theverge.com/2026/1/2/go"},
+		{"amp site", "https://amp.dev/x", "https://amp.dev/x"},
+		{"unknown amp host", "https://amp.example.com/news/go", "https://amp.example.com/news/go"},
+		{"amp path of a publisher", "https://www.theguardian.com/amp/technology/2026/go", "https://theguardian.com/technology/2026/go"},
+		{"amp query of a publisher", "https://www.cnn.com/news/go?outputType=amp", "https://cnn.com/news/go"},
+		{"amp path kept elsewhere", "https://npmjs.com/package/amp?amp=1", "https://npmjs.com/package/amp?amp=1"},
+		{"google amp viewer", "https://www.google.com/amp/s/example.com/news/go-1-26/amp", "https://example.com/news/go-1-26"},
+		{"amp cache", "https://example-com.cdn.ampproject.org/c/s/example.com/news/go", "https://example.com/news/go"},
+		{"google redirect", "https://www.google.com/url?q=https://go.dev/blog/go1.26%3Futm_source%3Dg&sa=D", "https://go.dev/blog/go1.26"},
+		{"facebook redirect", "https://l.facebook.com/l.php?u=https%3A%2F%2Fgo.dev%2Fdl%2F&h=AT0", "https://go.dev/dl"},
+		{"reddit outbound", "https://out.reddit.com/t3_abc?url=https%3A%2F%2Fgo.dev%2F&token=x", "https://go.dev"},
+		{"youtu.be", "https://youtu.be/dQw4w9WgXcQ?si=share&t=42", "https://youtube.com/watch?v=dQw4w9WgXcQ"},
+		{"youtube tracking", "https://m.youtube.com/watch?v=dQw4w9WgXcQ&feature=share", "https://youtube.com/watch?v=dQw4w9WgXcQ"},
+		{"redd.it", "https://redd.it/1b2c3d4", "https://reddit.com/comments/1b2c3d4"},
+		{"reddit permalink", "https://old.reddit.com/r/golang/comments/1b2c3d4/go_126_released/", "https://reddit.com/comments/1b2c3d4"},
+		{"other shorteners are left alone", "https://bit.ly/3abcDEF", "https://bit.ly/3abcDEF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := URL(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	for _, input := range []string{"", "/relative/path", "mailto:gopher@example.com", "ftp://example.com/file", "http://[::1"} {
+		_, err := URL(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestFromHTML(t *testing.T) {
+	page := `<!doctype html><html><head>
+<link rel="stylesheet" href="/style.css">
+<link rel="canonical" href="/blog/go1.26">
+</head><body><a rel="canonical" href="/elsewhere">x</a></body></html>`
+	assert.Equal(t, "https://go.dev/blog/go1.26", FromHTML(page, "https://go.dev/blog/go1.26?ref=feed"))
+
+	assert.Equal(t, "https://example.com/a", FromHTML(`<LINK REL="Canonical" HREF="https://example.com/a"/>`, ""))
+	assert.Empty(t, FromHTML(`<p>A canonical example</p>`, "https://example.com"))
+	assert.Empty(t, FromHTML(`<body><link rel="canonical" href="/late"></body>`, "https://example.com"))
+}
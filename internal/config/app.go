@@ -0,0 +1,434 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default returns the application config used when nothing else is set
+func Default() *Config {
+	return &Config{
+		ConnectorsFile: "config/connectors.yaml",
+		Storage: StorageConfig{
+			MongoURI:       "mongodb://localhost:27017",
+			Database:       "infoBro",
+			ConnectTimeout: 10 * time.Second,
+			Collections: CollectionsConfig{
+				RawNews:       "raw_news",
+				ProcessedNews: "processed_news",
+				ChannelStates: "channel_states",
+				Breakers:      "source_breakers",
+				Backfills:     "backfills",
+			},
+		},
+		Queue: QueueConfig{
+			Addr: "localhost:6379",
+			Keys: QueueKeysConfig{
+				Queue:      "news:queue",
+				Processing: "news:processing",
+				Failed:     "news:failed",
+			},
+		},
+		Server: ServerConfig{
+			Addr:            ":8080",
+			RequestTimeout:  60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			WatchInterval:   5 * time.Second,
+		},
+		Processor: ProcessorConfig{
+			Workers:     1,
+			PollTimeout: 5 * time.Second,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:  false,
+			Interval: 15 * time.Minute,
+		},
+		Fetch: FetchConfig{
+			UserAgent: "infoBro/1.0",
+			RateLimit: RateLimitConfig{Rate: 2, Burst: 5},
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelay:   time.Second,
+				MaxDelay:    30 * time.Second,
+			},
+		},
+		Breaker: BreakerConfig{
+			Threshold:   3,
+			CoolDown:    5 * time.Minute,
+			MaxCoolDown: 6 * time.Hour,
+		},
+		Runner: RunnerConfig{
+			Workers:          4,
+			ConnectorTimeout: 5 * time.Minute,
+			Deadline:         10 * time.Minute,
+		},
+		Backfill: BackfillConfig{
+			PageDelay: time.Second,
+			MaxPages:  200,
+		},
+		Clustering: ClusteringConfig{
+			Enabled:       true,
+			Threshold:     0.3,
+			Window:        48 * time.Hour,
+			MaxCandidates: 200,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// override binds a config field to an environment variable and a command line flag
+type override struct {
+	env   string
+	flag  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+// overrides lists every setting that can be set from the environment or the command line
+var overrides = []override{
+	{"INFOBRO_CONNECTORS_FILE", "config", "Path to connectors config file", func(c *Config) interface{} { return &c.ConnectorsFile }},
+	{"INFOBRO_MONGO_URI", "mongo-uri", "MongoDB connection URI", func(c *Config) interface{} { return &c.Storage.MongoURI }},
+	{"INFOBRO_MONGO_DB", "mongo-db", "MongoDB database name", func(c *Config) interface{} { return &c.Storage.Database }},
+	{"INFOBRO_REDIS_ADDR", "redis-addr", "Redis server address", func(c *Config) interface{} { return &c.Queue.Addr }},
+	{"INFOBRO_REDIS_PASSWORD", "redis-password", "Redis password", func(c *Config) interface{} { return &c.Queue.Password }},
+	{"INFOBRO_REDIS_DB", "redis-db", "Redis database number", func(c *Config) interface{} { return &c.Queue.DB }},
+	{"INFOBRO_HTTP_ADDR", "http-addr", "HTTP server address", func(c *Config) interface{} { return &c.Server.Addr }},
+	{"INFOBRO_WATCH_INTERVAL", "watch-interval", "How often to check the connectors config file for changes (0 disables watching)", func(c *Config) interface{} { return &c.Server.WatchInterval }},
+	{"INFOBRO_PROCESSOR_WORKERS", "workers", "Number of concurrent processor workers", func(c *Config) interface{} { return &c.Processor.Workers }},
+	{"INFOBRO_SCHEDULER_ENABLED", "schedule", "Run all connectors periodically", func(c *Config) interface{} { return &c.Scheduler.Enabled }},
+	{"INFOBRO_SCHEDULER_INTERVAL", "schedule-interval", "Interval between scheduled connector runs", func(c *Config) interface{} { return &c.Scheduler.Interval }},
+	{"INFOBRO_FETCH_PROXY", "fetch-proxy", "Proxy URL for connector HTTP requests", func(c *Config) interface{} { return &c.Fetch.Proxy }},
+	{"INFOBRO_LOG_LEVEL", "log-level", "Log level (debug, info, warn, error)", func(c *Config) interface{} { return &c.Logging.Level }},
+	{"INFOBRO_LOG_FORMAT", "log-format", "Log format (text, json)", func(c *Config) interface{} { return &c.Logging.Format }},
+}
+
+// Flags collects the command line overrides that were explicitly set
+type Flags struct {
+	values map[string]string
+}
+
+// RegisterFlags registers a flag for every overridable setting on fs.
+// Only flags given on the command line override the file and environment.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{values: make(map[string]string)}
+	defaults := Default()
+
+	for _, o := range overrides {
+		name := o.flag
+		usage := fmt.Sprintf("%s (env %s, default %v)", o.usage, o.env, formatValue(o.field(defaults)))
+		set := func(value string) error {
+			// Validate the value now so that flag parsing reports it
+			if err := setValue(o.field(Default()), value); err != nil {
+				return err
+			}
+			flags.values[name] = value
+			return nil
+		}
+		if _, isBool := o.field(defaults).(*bool); isBool {
+			fs.BoolFunc(name, usage, set)
+		} else {
+			fs.Func(name, usage, set)
+		}
+	}
+	return flags
+}
+
+// Load builds the application config. Precedence is defaults < file < environment < flags.
+// A missing file at path is not an error, so the defaults work out of the box.
+// The connectors config is loaded from the resulting ConnectorsFile.
+func Load(path string, flags *Flags) (*Config, error) {
+	cfg, err := loadApp(path, os.LookupEnv, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	connectors, err := LoadConnectorsConfig(cfg.ConnectorsFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Connectors = *connectors
+
+	return cfg, nil
+}
+
+// loadApp builds the application config without the connectors section
+func loadApp(path string, lookupEnv func(string) (string, bool), flags *Flags) (*Config, error) {
+	cfg := Default()
+
+	var root *yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		root, err = decodeStrict(path, data, cfg)
+		if err != nil {
+			return nil, err
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		// Run on defaults, environment and flags alone
+	default:
+		return nil, err
+	}
+
+	for _, o := range overrides {
+		if value, ok := lookupEnv(o.env); ok {
+			if err := setValue(o.field(cfg), value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", o.env, err)
+			}
+		}
+	}
+
+	if flags != nil {
+		for _, o := range overrides {
+			if value, ok := flags.values[o.flag]; ok {
+				if err := setValue(o.field(cfg), value); err != nil {
+					return nil, fmt.Errorf("invalid -%s: %w", o.flag, err)
+				}
+			}
+		}
+	}
+
+	if err := cfg.validate(path, root); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// setValue parses value into the field pointer
+func setValue(field interface{}, value string) error {
+	switch ptr := field.(type) {
+	case *string:
+		*ptr = value
+	case *Secret:
+		resolved, err := ResolveSecret(value)
+		if err != nil {
+			return err
+		}
+		*ptr = Secret(resolved)
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*ptr = parsed
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*ptr = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*ptr = parsed
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+// formatValue formats the value behind a field pointer for flag usage
+func formatValue(field interface{}) string {
+	switch ptr := field.(type) {
+	case *string:
+		return strconv.Quote(*ptr)
+	case *Secret:
+		return strconv.Quote(ptr.String())
+	case *int:
+		return strconv.Itoa(*ptr)
+	case *bool:
+		return strconv.FormatBool(*ptr)
+	case *time.Duration:
+		return ptr.String()
+	default:
+		return ""
+	}
+}
+
+// validLogLevels and validLogFormats list the values accepted by the logging section
+var (
+	validLogLevels  = []string{"debug", "info", "warn", "error"}
+	validLogFormats = []string{"text", "json"}
+)
+
+// validate checks the application settings, resolving error positions against root
+func (c *Config) validate(file string, root *yaml.Node) error {
+	v := &validator{root: root}
+	if root != nil {
+		v.file = file
+	}
+
+	if c.ConnectorsFile == "" {
+		v.errorf([]string{"connectors_file"}, "connectors_file is required")
+	}
+	if c.Storage.MongoURI == "" {
+		v.errorf([]string{"storage", "mongo_uri"}, "mongo_uri is required")
+	}
+	if c.Storage.Database == "" {
+		v.errorf([]string{"storage", "database"}, "database is required")
+	}
+	if c.Storage.ConnectTimeout <= 0 {
+		v.errorf([]string{"storage", "connect_timeout"}, "connect_timeout must be positive")
+	}
+	for _, collection := range [][2]string{
+		{"raw_news", c.Storage.Collections.RawNews},
+		{"processed_news", c.Storage.Collections.ProcessedNews},
+		{"channel_states", c.Storage.Collections.ChannelStates},
+		{"breakers", c.Storage.Collections.Breakers},
+		{"backfills", c.Storage.Collections.Backfills},
+	} {
+		if collection[1] == "" {
+			v.errorf([]string{"storage", "collections", collection[0]}, "collection name is required")
+		}
+	}
+
+	if c.Queue.Addr == "" {
+		v.errorf([]string{"queue", "addr"}, "addr is required")
+	}
+	if c.Queue.DB < 0 {
+		v.errorf([]string{"queue", "db"}, "db must not be negative")
+	}
+	for _, key := range [][2]string{
+		{"queue", c.Queue.Keys.Queue},
+		{"processing", c.Queue.Keys.Processing},
+		{"failed", c.Queue.Keys.Failed},
+	} {
+		if key[1] == "" {
+			v.errorf([]string{"queue", "keys", key[0]}, "queue key is required")
+		}
+	}
+
+	if c.Server.Addr == "" {
+		v.errorf([]string{"server", "addr"}, "addr is required")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		v.errorf([]string{"server", "request_timeout"}, "request_timeout must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		v.errorf([]string{"server", "shutdown_timeout"}, "shutdown_timeout must be positive")
+	}
+	if c.Server.WatchInterval < 0 {
+		v.errorf([]string{"server", "watch_interval"}, "watch_interval must not be negative")
+	}
+
+	if c.Processor.Workers <= 0 {
+		v.errorf([]string{"processor", "workers"}, "workers must be positive")
+	}
+	if c.Processor.PollTimeout <= 0 {
+		v.errorf([]string{"processor", "poll_timeout"}, "poll_timeout must be positive")
+	}
+
+	if c.Scheduler.Enabled && c.Scheduler.Interval <= 0 {
+		v.errorf([]string{"scheduler", "interval"}, "interval must be positive")
+	}
+
+	if c.Fetch.Proxy != "" {
+		if proxy, err := url.Parse(c.Fetch.Proxy.Value()); err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			v.errorf([]string{"fetch", "proxy"}, "proxy must be an absolute URL")
+		}
+	}
+	validateRateLimit(v, []string{"fetch", "rate_limit"}, c.Fetch.RateLimit)
+	for host, limit := range c.Fetch.Hosts {
+		validateRateLimit(v, []string{"fetch", "hosts", host}, limit)
+	}
+	if c.Fetch.Retry.MaxAttempts < 1 {
+		v.errorf([]string{"fetch", "retry", "max_attempts"}, "max_attempts must be at least 1")
+	}
+	if c.Fetch.Retry.BaseDelay < 0 {
+		v.errorf([]string{"fetch", "retry", "base_delay"}, "base_delay must not be negative")
+	}
+	if c.Fetch.Retry.MaxDelay < c.Fetch.Retry.BaseDelay {
+		v.errorf([]string{"fetch", "retry", "max_delay"}, "max_delay must not be less than base_delay")
+	}
+
+	if c.Breaker.Threshold < 0 {
+		v.errorf([]string{"breaker", "threshold"}, "threshold must not be negative")
+	}
+	if c.Breaker.Threshold > 0 {
+		if c.Breaker.CoolDown <= 0 {
+			v.errorf([]string{"breaker", "cool_down"}, "cool_down must be positive")
+		}
+		if c.Breaker.MaxCoolDown < c.Breaker.CoolDown {
+			v.errorf([]string{"breaker", "max_cool_down"}, "max_cool_down must not be less than cool_down")
+		}
+	}
+
+	if c.Runner.Workers < 0 {
+		v.errorf([]string{"runner", "workers"}, "workers must not be negative")
+	}
+	if c.Runner.ConnectorTimeout < 0 {
+		v.errorf([]string{"runner", "connector_timeout"}, "connector_timeout must not be negative")
+	}
+	if c.Runner.Deadline < 0 {
+		v.errorf([]string{"runner", "deadline"}, "deadline must not be negative")
+	}
+
+	if c.Backfill.PageDelay < 0 {
+		v.errorf([]string{"backfill", "page_delay"}, "page_delay must not be negative")
+	}
+	if c.Backfill.MaxPages <= 0 {
+		v.errorf([]string{"backfill", "max_pages"}, "max_pages must be positive")
+	}
+
+	if c.Clustering.Enabled {
+		if c.Clustering.Threshold <= 0 || c.Clustering.Threshold > 1 {
+			v.errorf([]string{"clustering", "threshold"}, "threshold must be above 0 and at most 1, got %g", c.Clustering.Threshold)
+		}
+		if c.Clustering.Window <= 0 {
+			v.errorf([]string{"clustering", "window"}, "window must be positive")
+		}
+		if c.Clustering.MaxCandidates <= 0 {
+			v.errorf([]string{"clustering", "max_candidates"}, "max_candidates must be positive")
+		}
+	}
+
+	if !contains(validLogLevels, strings.ToLower(c.Logging.Level)) {
+		v.errorf([]string{"logging", "level"}, "invalid level %q, must be one of %s", c.Logging.Level, strings.Join(validLogLevels, ", "))
+	}
+	if !contains(validLogFormats, strings.ToLower(c.Logging.Format)) {
+		v.errorf([]string{"logging", "format"}, "invalid format %q, must be one of %s", c.Logging.Format, strings.Join(validLogFormats, ", "))
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validateRateLimit checks a token bucket setting
+func validateRateLimit(v *validator, path []string, limit RateLimitConfig) {
+	if limit.Rate < 0 {
+		v.errorf(append(path, "rate"), "rate must not be negative")
+	}
+	if limit.Rate > 0 && limit.Burst < 1 {
+		v.errorf(append(path, "burst"), "burst must be at least 1")
+	}
+}
+
+// Redacted renders the effective config, including the connectors, as YAML with every secret redacted
+func (c *Config) Redacted() (string, error) {
+	effective := struct {
+		Config     `yaml:",inline"`
+		Connectors ConnectorsConfig `yaml:"connectors"`
+	}{*c, c.Connectors}
+
+	data, err := yaml.Marshal(effective)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
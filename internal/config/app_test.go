@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAppPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+storage:
+  database: "from-file"
+queue:
+  addr: "file:6379"
+  db: 1
+server:
+  addr: ":7000"
+`)
+	env := map[string]string{
+		"INFOBRO_REDIS_ADDR": "env:6379",
+		"INFOBRO_HTTP_ADDR":  ":8000",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-http-addr", ":9000", "-schedule"}))
+
+	cfg, err := loadApp(path, lookupEnv, flags)
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-file", cfg.Storage.Database, "file overrides default")
+	assert.Equal(t, 1, cfg.Queue.DB, "file overrides default")
+	assert.Equal(t, "env:6379", cfg.Queue.Addr, "env overrides file")
+	assert.Equal(t, ":9000", cfg.Server.Addr, "flag overrides env")
+	assert.True(t, cfg.Scheduler.Enabled, "boolean flag without value")
+	assert.Equal(t, "raw_news", cfg.Storage.Collections.RawNews, "default kept")
+	assert.Equal(t, 60*time.Second, cfg.Server.RequestTimeout, "default kept")
+}
+
+func TestLoadAppMissingFileUsesDefaults(t *testing.T) {
+	cfg, err := loadApp("does-not-exist.yaml", func(string) (string, bool) { return "", false }, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadAppInvalid(t *testing.T) {
+	path := writeConfig(t, `
+processor:
+  workers: 0
+clustering:
+  threshold: 1.5
+logging:
+  level: "verbose"
+`)
+
+	_, err := loadApp(path, func(string) (string, bool) { return "", false }, nil)
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 3)
+	assert.Equal(t, "processor.workers", errs[0].Path)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Equal(t, "clustering.threshold", errs[1].Path)
+	assert.Equal(t, 5, errs[1].Line)
+	assert.Equal(t, "logging.level", errs[2].Path)
+	assert.Equal(t, 7, errs[2].Line)
+}
+
+func TestRedactedHidesSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Storage.MongoURI = "mongodb://admin:hunter2@db:27017"
+	cfg.Queue.Password = "hunter2"
+
+	out, err := cfg.Redacted()
+	require.NoError(t, err)
+	assert.NotContains(t, out, "hunter2")
+	assert.Contains(t, out, "addr: localhost:6379")
+}
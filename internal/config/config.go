@@ -3,20 +3,167 @@ package config
 import (
 	"os"
 	"time"
-
-	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Connectors ConnectorsConfig `yaml:"connectors"`
+	// ConnectorsFile is the path of the connectors config, kept in a separate file so it can be hot-reloaded
+	ConnectorsFile string           `yaml:"connectors_file"`
+	Connectors     ConnectorsConfig `yaml:"-"`
+	Storage        StorageConfig    `yaml:"storage"`
+	Queue          QueueConfig      `yaml:"queue"`
+	Server         ServerConfig     `yaml:"server"`
+	Processor      ProcessorConfig  `yaml:"processor"`
+	Scheduler      SchedulerConfig  `yaml:"scheduler"`
+	Fetch          FetchConfig      `yaml:"fetch"`
+	Breaker        BreakerConfig    `yaml:"breaker"`
+	Runner         RunnerConfig     `yaml:"runner"`
+	Backfill       BackfillConfig   `yaml:"backfill"`
+	Clustering     ClusteringConfig `yaml:"clustering"`
+	Logging        LoggingConfig    `yaml:"logging"`
+}
+
+// StorageConfig holds MongoDB settings
+type StorageConfig struct {
+	MongoURI       Secret            `yaml:"mongo_uri"`
+	Database       string            `yaml:"database"`
+	ConnectTimeout time.Duration     `yaml:"connect_timeout"`
+	Collections    CollectionsConfig `yaml:"collections"`
+}
+
+// CollectionsConfig holds MongoDB collection names
+type CollectionsConfig struct {
+	RawNews       string `yaml:"raw_news"`
+	ProcessedNews string `yaml:"processed_news"`
+	ChannelStates string `yaml:"channel_states"`
+	Breakers      string `yaml:"breakers"`
+	Backfills     string `yaml:"backfills"`
+}
+
+// QueueConfig holds Redis queue settings
+type QueueConfig struct {
+	Addr     string          `yaml:"addr"`
+	Password Secret          `yaml:"password"`
+	DB       int             `yaml:"db"`
+	Keys     QueueKeysConfig `yaml:"keys"`
+}
+
+// QueueKeysConfig holds the Redis keys of the news queues
+type QueueKeysConfig struct {
+	Queue      string `yaml:"queue"`
+	Processing string `yaml:"processing"`
+	Failed     string `yaml:"failed"`
+}
+
+// ServerConfig holds HTTP server settings
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	RequestTimeout  time.Duration `yaml:"request_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// WatchInterval is how often the connectors file is checked for changes, 0 disables watching
+	WatchInterval time.Duration `yaml:"watch_interval"`
+}
+
+// ProcessorConfig holds settings for the queue processor
+type ProcessorConfig struct {
+	Workers     int           `yaml:"workers"`
+	PollTimeout time.Duration `yaml:"poll_timeout"`
+}
+
+// SchedulerConfig holds settings for periodic connector runs
+type SchedulerConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Interval   time.Duration `yaml:"interval"`
+	RunOnStart bool          `yaml:"run_on_start"`
+}
+
+// FetchConfig holds settings of the HTTP client shared by connectors
+type FetchConfig struct {
+	// UserAgent is sent by connectors that do not set their own
+	UserAgent string `yaml:"user_agent"`
+	// Proxy is the proxy URL, empty uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy     Secret          `yaml:"proxy"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// Hosts overrides the rate limit for individual hosts, e.g. "api.github.com"
+	Hosts map[string]RateLimitConfig `yaml:"hosts"`
+	Retry RetryConfig                `yaml:"retry"`
+}
+
+// RateLimitConfig is a token bucket refilled at Rate requests per second, 0 disables the limit
+type RateLimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// RetryConfig holds the retry policy for throttled and failed requests
+type RetryConfig struct {
+	// MaxAttempts counts the first request too, 1 disables retries
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the backoff; a longer Retry-After fails the request instead of waiting
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+// BreakerConfig holds the circuit breaker settings that pause repeatedly failing sources
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures that open the breaker of a source, 0 disables breakers
+	Threshold int `yaml:"threshold"`
+	// CoolDown is how long a source is paused when its breaker first opens; it doubles
+	// with every failed probe up to MaxCoolDown
+	CoolDown    time.Duration `yaml:"cool_down"`
+	MaxCoolDown time.Duration `yaml:"max_cool_down"`
+}
+
+// RunnerConfig bounds how connectors are run; zero values remove the limit
+type RunnerConfig struct {
+	// Workers is the number of connectors and sources fetched at the same time, across all runs
+	Workers int `yaml:"workers"`
+	// ConnectorTimeout bounds the run of a single connector
+	ConnectorTimeout time.Duration `yaml:"connector_timeout"`
+	// Deadline bounds a run of all connectors; connectors still running are reported as timed out
+	Deadline time.Duration `yaml:"deadline"`
+}
+
+// BackfillConfig holds the settings of historical backfills
+type BackfillConfig struct {
+	// PageDelay is the pause between two pages of a backfill, on top of the fetch rate limits
+	PageDelay time.Duration `yaml:"page_delay"`
+	// MaxPages bounds the pages read by one backfill run; a stopped backfill resumes where it left off
+	MaxPages int `yaml:"max_pages"`
+}
+
+// ClusteringConfig holds the settings grouping near duplicate news into stories
+type ClusteringConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Threshold is the similarity of the words of two items, from 0 to 1, above which they are the same story
+	Threshold float64 `yaml:"threshold"`
+	// Window is how far apart in publication time items of the same story may be
+	Window time.Duration `yaml:"window"`
+	// MaxCandidates bounds the similar items compared with each new item
+	MaxCandidates int `yaml:"max_candidates"`
+}
+
+// LoggingConfig holds logging settings
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
 }
 
 // ConnectorsConfig holds configuration for all connectors
 type ConnectorsConfig struct {
-	Telegram TelegramConfig `yaml:"telegram"`
-	RSS      RSSConfig      `yaml:"rss"`
-	Reddit   RedditConfig   `yaml:"reddit"`
+	Telegram      TelegramConfig      `yaml:"telegram"`
+	RSS           RSSConfig           `yaml:"rss"`
+	Reddit        RedditConfig        `yaml:"reddit"`
+	HackerNews    HackerNewsConfig    `yaml:"hackernews"`
+	Scraper       ScraperConfig       `yaml:"scraper"`
+	GitHub        GitHubConfig        `yaml:"github"`
+	Mastodon      MastodonConfig      `yaml:"mastodon"`
+	Webhook       WebhookConfig       `yaml:"webhook"`
+	IMAP          IMAPConfig          `yaml:"imap"`
+	YouTube       YouTubeConfig       `yaml:"youtube"`
+	Arxiv         ArxivConfig         `yaml:"arxiv"`
+	StackExchange StackExchangeConfig `yaml:"stackexchange"`
+	Bluesky       BlueskyConfig       `yaml:"bluesky"`
 }
 
 // TelegramConfig holds configuration for Telegram connector
@@ -28,8 +175,8 @@ type TelegramConfig struct {
 
 // TelegramCredentials holds authentication information for Telegram
 type TelegramCredentials struct {
-	APIID   string `yaml:"api_id"`
-	APIHash string `yaml:"api_hash"`
+	APIID   Secret `yaml:"api_id"`
+	APIHash Secret `yaml:"api_hash"`
 }
 
 // ChannelConfig holds configuration for a Telegram channel
@@ -74,31 +221,43 @@ type SubredditConfig struct {
 type RedditSettings struct {
 	Timeout      time.Duration `yaml:"timeout"`
 	UserAgent    string        `yaml:"user_agent"`
-	ClientID     string        `yaml:"client_id"`
-	ClientSecret string        `yaml:"client_secret"`
-	Username     string        `yaml:"username"`
-	Password     string        `yaml:"password"`
+	ClientID     Secret        `yaml:"client_id"`
+	ClientSecret Secret        `yaml:"client_secret"`
+	Username     Secret        `yaml:"username"`
+	Password     Secret        `yaml:"password"`
 	Limit        int           `yaml:"limit"`
 	Sort         string        `yaml:"sort"`
 }
 
-// LoadConfig loads configuration from a YAML file
-func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
+// HackerNewsConfig holds configuration for the Hacker News connector
+type HackerNewsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Lists are the story lists to read: top, new, best, ask, show or job
+	Lists    []string           `yaml:"lists"`
+	Settings HackerNewsSettings `yaml:"settings"`
+}
 
-	var config Config
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, err
-	}
+// HackerNewsSettings holds settings for the Hacker News connector
+type HackerNewsSettings struct {
+	// BaseURL is the Firebase API root, e.g. https://hacker-news.firebaseio.com/v0
+	BaseURL   string        `yaml:"base_url"`
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// Limit is the number of stories read from the head of each list
+	Limit int `yaml:"limit"`
+	// Workers bounds the number of items fetched concurrently
+	Workers int `yaml:"workers"`
+	// BatchSize is the number of items stored and checkpointed together, 50 if unset
+	BatchSize int `yaml:"batch_size"`
+}
 
-	return &config, nil
+// HasCredentials reports whether all credentials for an authenticated client are set
+func (s RedditSettings) HasCredentials() bool {
+	return s.ClientID != "" && s.ClientSecret != "" && s.Username != "" && s.Password != ""
 }
 
-// LoadConnectorsConfig loads just the connectors configuration
+// LoadConnectorsConfig loads just the connectors configuration.
+// Unknown keys and invalid values are reported as ValidationErrors.
 func LoadConnectorsConfig(path string) (*ConnectorsConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -106,10 +265,300 @@ func LoadConnectorsConfig(path string) (*ConnectorsConfig, error) {
 	}
 
 	var config ConnectorsConfig
-	err = yaml.Unmarshal(data, &config)
+	root, err := decodeStrict(path, data, &config)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := config.validate(path, root, nil); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
-}
\ No newline at end of file
+}
+
+// ScraperConfig holds configuration for the web scraping connector
+type ScraperConfig struct {
+	Enabled  bool            `yaml:"enabled"`
+	Sites    []SiteConfig    `yaml:"sites"`
+	Settings ScraperSettings `yaml:"settings"`
+}
+
+// SiteConfig holds the extraction rules of a scraped site
+type SiteConfig struct {
+	Name string `yaml:"name"`
+	// URL is the list page that links to the articles
+	URL       string           `yaml:"url"`
+	Selectors ScraperSelectors `yaml:"selectors"`
+	// DateFormat is a Go time layout used to parse the date, e.g. "January 2, 2006".
+	// When empty, RFC 3339 and a few common layouts are tried.
+	DateFormat string `yaml:"date_format"`
+	// MaxPages is the number of list pages followed through the next selector
+	MaxPages int `yaml:"max_pages"`
+}
+
+// ScraperSelectors holds the CSS selectors of a scraped site. Link, title and date
+// are matched inside each item; title and date fall back to the article page.
+type ScraperSelectors struct {
+	Item  string `yaml:"item"`
+	Link  string `yaml:"link"`
+	Title string `yaml:"title"`
+	Date  string `yaml:"date"`
+	// Body is matched on the article page
+	Body string `yaml:"body"`
+	// Next is the link to the following list page; pagination is off when empty
+	Next string `yaml:"next"`
+}
+
+// ScraperSettings holds settings for the web scraping connector
+type ScraperSettings struct {
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// CrawlDelay is the minimum time between requests to the same host; a longer
+	// Crawl-delay in robots.txt takes precedence
+	CrawlDelay time.Duration `yaml:"crawl_delay"`
+	// MaxItems bounds the number of new articles fetched per site and run
+	MaxItems int `yaml:"max_items"`
+}
+
+// GitHubConfig holds configuration for the GitHub releases connector
+type GitHubConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Repositories are watched as owner/repo
+	Repositories []string       `yaml:"repositories"`
+	Settings     GitHubSettings `yaml:"settings"`
+}
+
+// GitHubSettings holds settings for the GitHub releases connector
+type GitHubSettings struct {
+	// Mode is "api" for the REST releases API or "atom" for the releases.atom feed,
+	// which needs no token and also lists plain tags
+	Mode string `yaml:"mode"`
+	// BaseURL is the REST API root, e.g. https://api.github.com
+	BaseURL string `yaml:"base_url"`
+	// FeedURL is the web root serving <owner>/<repo>/releases.atom, e.g. https://github.com
+	FeedURL   string        `yaml:"feed_url"`
+	Token     Secret        `yaml:"token"`
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// PerPage is the number of most recent releases read per repository
+	PerPage         int  `yaml:"per_page"`
+	SkipPrereleases bool `yaml:"skip_prereleases"`
+}
+
+// MastodonConfig holds configuration for the Mastodon connector
+type MastodonConfig struct {
+	Enabled  bool                   `yaml:"enabled"`
+	Sources  []MastodonSourceConfig `yaml:"sources"`
+	Settings MastodonSettings       `yaml:"settings"`
+}
+
+// MastodonSourceConfig is an account or a hashtag timeline on an instance; exactly
+// one of Account and Hashtag is set
+type MastodonSourceConfig struct {
+	// Instance is the server root, e.g. https://fosstodon.org
+	Instance string `yaml:"instance"`
+	// Account is a user name on the instance, or user@domain for a remote account
+	Account string `yaml:"account"`
+	// Hashtag is a tag without the leading #
+	Hashtag string `yaml:"hashtag"`
+}
+
+// MastodonSettings holds settings for the Mastodon connector
+type MastodonSettings struct {
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// Limit is the page size, at most 40
+	Limit int `yaml:"limit"`
+	// MaxPages bounds the pages read per source and run when catching up
+	MaxPages       int  `yaml:"max_pages"`
+	ExcludeReplies bool `yaml:"exclude_replies"`
+	ExcludeBoosts  bool `yaml:"exclude_boosts"`
+}
+
+// WebhookConfig holds configuration for the push ingestion endpoint
+type WebhookConfig struct {
+	Enabled  bool                  `yaml:"enabled"`
+	Sources  []WebhookSourceConfig `yaml:"sources"`
+	Settings WebhookSettings       `yaml:"settings"`
+}
+
+// WebhookSourceConfig is a client allowed to push to /api/ingest/{name}
+type WebhookSourceConfig struct {
+	Name string `yaml:"name"`
+	// Secret is the HMAC-SHA256 key the source signs its requests with
+	Secret Secret `yaml:"secret"`
+}
+
+// WebhookSettings holds settings for the push ingestion endpoint
+type WebhookSettings struct {
+	// MaxBatch is the maximum number of items in one request
+	MaxBatch int `yaml:"max_batch"`
+	// MaxSkew is how far the signed timestamp may be from the server clock
+	MaxSkew time.Duration `yaml:"max_skew"`
+}
+
+// IMAPConfig holds configuration for the IMAP newsletter connector
+type IMAPConfig struct {
+	Enabled  bool               `yaml:"enabled"`
+	Server   IMAPServerConfig   `yaml:"server"`
+	Sources  []IMAPSourceConfig `yaml:"sources"`
+	Settings IMAPSettings       `yaml:"settings"`
+}
+
+// IMAPServerConfig holds the mailbox address and login
+type IMAPServerConfig struct {
+	// Address is host:port, e.g. imap.gmail.com:993
+	Address string `yaml:"address"`
+	// TLS connects with implicit TLS; turn it off only for local servers
+	TLS      bool   `yaml:"tls"`
+	Username Secret `yaml:"username"`
+	Password Secret `yaml:"password"`
+}
+
+// IMAPSourceConfig selects the messages of a newsletter
+type IMAPSourceConfig struct {
+	Name   string `yaml:"name"`
+	Folder string `yaml:"folder"`
+	// From restricts the source to messages whose From header contains this value
+	From  string          `yaml:"from"`
+	Split IMAPSplitConfig `yaml:"split"`
+}
+
+// IMAPSplitConfig holds the rules that split a newsletter into one item per link
+type IMAPSplitConfig struct {
+	// Enabled emits one item per matching link instead of one item per message
+	Enabled bool `yaml:"enabled"`
+	// MinTitleLength skips links with shorter text, such as "here" or icons
+	MinTitleLength int `yaml:"min_title_length"`
+	// IncludeURLs are regular expressions; when set, a link must match one of them
+	IncludeURLs []string `yaml:"include_urls"`
+	// ExcludeURLs are regular expressions for links to drop, e.g. unsubscribe links
+	ExcludeURLs []string `yaml:"exclude_urls"`
+}
+
+// IMAPSettings holds settings for the IMAP newsletter connector
+type IMAPSettings struct {
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxMessages bounds the messages read per source and run
+	MaxMessages int `yaml:"max_messages"`
+}
+
+// YouTubeConfig holds configuration for the YouTube connector
+type YouTubeConfig struct {
+	Enabled  bool                  `yaml:"enabled"`
+	Sources  []YouTubeSourceConfig `yaml:"sources"`
+	Settings YouTubeSettings       `yaml:"settings"`
+}
+
+// YouTubeSourceConfig is a channel or a playlist; exactly one of ChannelID and PlaylistID is set
+type YouTubeSourceConfig struct {
+	Name       string `yaml:"name"`
+	ChannelID  string `yaml:"channel_id"`
+	PlaylistID string `yaml:"playlist_id"`
+}
+
+// YouTubeSettings holds settings for the YouTube connector
+type YouTubeSettings struct {
+	// BaseURL is the Atom feed endpoint, e.g. https://www.youtube.com/feeds/videos.xml
+	BaseURL   string        `yaml:"base_url"`
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+}
+
+// ArxivConfig holds configuration for the arXiv connector
+type ArxivConfig struct {
+	Enabled  bool               `yaml:"enabled"`
+	Queries  []ArxivQueryConfig `yaml:"queries"`
+	Settings ArxivSettings      `yaml:"settings"`
+}
+
+// ArxivQueryConfig is a named arXiv query; Categories and Search are combined with AND
+type ArxivQueryConfig struct {
+	Name string `yaml:"name"`
+	// Categories are arXiv categories such as cs.DC, any of which may match
+	Categories []string `yaml:"categories"`
+	// Search is an arXiv search_query expression, e.g. abs:raft AND ti:consensus
+	Search string `yaml:"search"`
+}
+
+// ArxivSettings holds settings for the arXiv connector
+type ArxivSettings struct {
+	// BaseURL is the query endpoint, e.g. https://export.arxiv.org/api/query
+	BaseURL   string        `yaml:"base_url"`
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// PageSize is the max_results of each request
+	PageSize int `yaml:"page_size"`
+	// MaxPages bounds the pages read per query and run when catching up
+	MaxPages int `yaml:"max_pages"`
+	// RequestDelay is the pause between API requests; arXiv asks for at least 3s
+	RequestDelay time.Duration `yaml:"request_delay"`
+}
+
+// StackExchangeConfig holds configuration for the Stack Exchange connector
+type StackExchangeConfig struct {
+	Enabled  bool                        `yaml:"enabled"`
+	Sources  []StackExchangeSourceConfig `yaml:"sources"`
+	Settings StackExchangeSettings       `yaml:"settings"`
+}
+
+// StackExchangeSourceConfig is a set of tags followed on a Stack Exchange site
+type StackExchangeSourceConfig struct {
+	Name string `yaml:"name"`
+	// Site is the API site parameter, e.g. stackoverflow or serverfault
+	Site string `yaml:"site"`
+	// Tags must all be present on a question, at most 5
+	Tags []string `yaml:"tags"`
+	// MinScore skips questions scored lower
+	MinScore int `yaml:"min_score"`
+	// AnsweredOnly skips questions without an upvoted or accepted answer
+	AnsweredOnly bool `yaml:"answered_only"`
+}
+
+// StackExchangeSettings holds settings for the Stack Exchange connector
+type StackExchangeSettings struct {
+	// BaseURL is the API root, e.g. https://api.stackexchange.com/2.3
+	BaseURL string `yaml:"base_url"`
+	// Key is an optional app key, which raises the daily quota from 300 to 10000 requests
+	Key       Secret        `yaml:"key"`
+	Timeout   time.Duration `yaml:"timeout"`
+	UserAgent string        `yaml:"user_agent"`
+	// PageSize is the pagesize of each request, at most 100
+	PageSize int `yaml:"page_size"`
+	// MaxPages bounds the pages read per source and run when catching up
+	MaxPages int `yaml:"max_pages"`
+	// RecheckWindow is how long a question that does not pass min_score or
+	// answered_only yet keeps being fetched again
+	RecheckWindow time.Duration `yaml:"recheck_window"`
+}
+
+// BlueskyConfig holds configuration for the Bluesky connector
+type BlueskyConfig struct {
+	Enabled  bool                  `yaml:"enabled"`
+	Sources  []BlueskySourceConfig `yaml:"sources"`
+	Settings BlueskySettings       `yaml:"settings"`
+}
+
+// BlueskySourceConfig is an author feed or a custom feed; exactly one of Actor and Feed is set
+type BlueskySourceConfig struct {
+	Name string `yaml:"name"`
+	// Actor is a handle such as gopher.bsky.social, or a DID
+	Actor string `yaml:"actor"`
+	// Feed is the at:// URI of a feed generator; the authority may be a handle or a DID
+	Feed string `yaml:"feed"`
+}
+
+// BlueskySettings holds settings for the Bluesky connector
+type BlueskySettings struct {
+	// ServiceURL is the AppView serving the XRPC endpoints, e.g. https://public.api.bsky.app
+	ServiceURL string        `yaml:"service_url"`
+	Timeout    time.Duration `yaml:"timeout"`
+	UserAgent  string        `yaml:"user_agent"`
+	// Limit is the page size, at most 100
+	Limit int `yaml:"limit"`
+	// MaxPages bounds the pages read per source and run when catching up
+	MaxPages       int  `yaml:"max_pages"`
+	ExcludeReplies bool `yaml:"exclude_replies"`
+	ExcludeReposts bool `yaml:"exclude_reposts"`
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values wherever they are printed or serialized
+const redacted = "[REDACTED]"
+
+// secretFilePrefix marks a secret that is read from a file, e.g. file:/run/secrets/reddit_password
+const secretFilePrefix = "file:"
+
+// secretEnvPattern matches ${VAR} and ${VAR:-default} references
+var secretEnvPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-(.*))?\}$`)
+
+// Secret holds a credential. Its String, MarshalJSON and MarshalYAML methods redact
+// the value, so a Secret can be logged or printed without leaking it; use Value to
+// get the credential itself.
+//
+// In YAML a secret may be a literal, an environment variable reference such as
+// ${REDDIT_PASSWORD} or ${REDDIT_PASSWORD:-default}, or a file reference such as
+// file:/run/secrets/reddit_password. References are resolved at load time.
+type Secret string
+
+// Value returns the plaintext credential
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns a redacted placeholder, or an empty string if the secret is not set
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString redacts the secret in %#v output
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.String())
+}
+
+// MarshalJSON serializes the secret redacted
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML serializes the secret redacted
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// UnmarshalYAML decodes the secret and resolves environment and file references
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	var raw string
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	value, err := ResolveSecret(raw)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	*s = Secret(value)
+	return nil
+}
+
+// ResolveSecret resolves a ${VAR}, ${VAR:-default} or file:/path reference.
+// Any other value is returned unchanged. Errors never include the secret value.
+func ResolveSecret(raw string) (string, error) {
+	if match := secretEnvPattern.FindStringSubmatch(raw); match != nil {
+		name := match[1]
+		if value, ok := os.LookupEnv(name); ok {
+			return value, nil
+		}
+		if strings.Contains(raw, ":-") {
+			return match[2], nil
+		}
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	if strings.HasPrefix(raw, secretFilePrefix) {
+		path := strings.TrimPrefix(raw, secretFilePrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("INFOBRO_TEST_SECRET", "from-env")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"literal", "literal"},
+		{"${INFOBRO_TEST_SECRET}", "from-env"},
+		{"${INFOBRO_TEST_SECRET:-fallback}", "from-env"},
+		{"${INFOBRO_TEST_UNSET:-fallback}", "fallback"},
+		{"${INFOBRO_TEST_UNSET:-}", ""},
+		{"file:" + secretFile, "from-file"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveSecret(tt.raw)
+		require.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.want, got, tt.raw)
+	}
+
+	_, err := ResolveSecret("${INFOBRO_TEST_UNSET}")
+	assert.EqualError(t, err, "environment variable INFOBRO_TEST_UNSET is not set")
+}
+
+func TestSecretRedaction(t *testing.T) {
+	settings := RedditSettings{ClientSecret: "hunter2", Password: "correct-horse"}
+
+	for _, out := range []string{
+		fmt.Sprintf("%v", settings),
+		fmt.Sprintf("%+v", settings),
+		fmt.Sprintf("%#v", settings),
+		fmt.Sprint(settings.Password),
+	} {
+		assert.NotContains(t, out, "hunter2")
+		assert.NotContains(t, out, "correct-horse")
+	}
+
+	data, err := json.Marshal(settings)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.Contains(t, string(data), redacted)
+
+	assert.Equal(t, "hunter2", settings.ClientSecret.Value())
+	assert.Equal(t, "", Secret("").String())
+}
+
+func TestLoadConnectorsConfigUnsetSecret(t *testing.T) {
+	path := writeConfig(t, `
+reddit:
+  enabled: false
+  settings:
+    password: "${INFOBRO_TEST_UNSET}"
+`)
+
+	_, err := LoadConnectorsConfig(path)
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, 5, errs[0].Line)
+	assert.Equal(t, "environment variable INFOBRO_TEST_UNSET is not set", errs[0].Message)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseConnectorSection decodes and validates the config of a single connector given
+// as YAML or JSON, with the same fields as its section in connectors.yaml. The section
+// is enabled whatever its enabled field says. It is meant for definitions from API
+// clients, so environment and file references are rejected instead of resolved, and
+// errors carry the path of the value but no line numbers.
+func ParseConnectorSection(section string, data []byte) (*ConnectorsConfig, error) {
+	var value yaml.Node
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, ValidationErrors{withoutPosition(yamlError("", err.Error()))}
+	}
+	if len(value.Content) == 0 || value.Content[0].Kind != yaml.MappingNode {
+		return nil, ValidationErrors{{Path: section, Message: "settings must be an object"}}
+	}
+	settings := value.Content[0]
+
+	var errs ValidationErrors
+	checkNoReferences(settings, []string{section}, &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	setEnabled(settings)
+
+	document := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: section},
+		settings,
+	}}
+	text, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, ValidationErrors{{Path: section, Message: err.Error()}}
+	}
+
+	var config ConnectorsConfig
+	root, err := decodeStrict("", text, &config)
+	if err == nil {
+		err = config.validate("", root, nil)
+	}
+	if err != nil {
+		if decodeErrs, ok := err.(ValidationErrors); ok {
+			// Positions refer to the re-encoded document, not to what the client sent
+			for _, decodeErr := range decodeErrs {
+				withoutPosition(decodeErr)
+			}
+		}
+		return nil, err
+	}
+	return &config, nil
+}
+
+// checkNoReferences records an error for every value that would be resolved as a secret reference
+func checkNoReferences(node *yaml.Node, path []string, errs *ValidationErrors) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if secretEnvPattern.MatchString(node.Value) || strings.HasPrefix(node.Value, secretFilePrefix) {
+			*errs = append(*errs, &ValidationError{
+				Path:    strings.Join(path, "."),
+				Message: "environment and file references are not allowed here",
+			})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNoReferences(node.Content[i+1], append(path, node.Content[i].Value), errs)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			checkNoReferences(item, append(path, strconv.Itoa(i)), errs)
+		}
+	}
+}
+
+// setEnabled sets the enabled field of a section mapping to true
+func setEnabled(settings *yaml.Node) {
+	for i := 0; i+1 < len(settings.Content); i += 2 {
+		if settings.Content[i].Value == "enabled" {
+			settings.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+			return
+		}
+	}
+	settings.Content = append(settings.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "enabled"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+	)
+}
+
+// withoutPosition clears the file and position of an error
+func withoutPosition(err *ValidationError) *ValidationError {
+	err.File, err.Line, err.Column = "", 0, 0
+	return err
+}
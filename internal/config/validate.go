@@ -0,0 +1,686 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError describes a single problem found in a config file
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+// Error formats the error as file:line:column: path: message
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "%d:", e.Column)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationErrors collects every problem found in a config file
+type ValidationErrors []*ValidationError
+
+// Error joins all validation errors, one per line
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// validRedditSorts lists the values accepted by reddit.settings.sort
+var validRedditSorts = []string{"hot", "new", "top", "rising"}
+
+// validHackerNewsLists lists the values accepted by hackernews.lists
+var validHackerNewsLists = []string{"top", "new", "best", "ask", "show", "job"}
+
+// validGitHubModes lists the values accepted by github.settings.mode
+var validGitHubModes = []string{"api", "atom"}
+
+// gitHubRepoPattern matches owner/repo
+var gitHubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+$`)
+
+// webhookNamePattern matches webhook source names, which appear in the ingest URL
+var webhookNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// arxivCategoryPattern matches arXiv categories such as cs.DC, math.PR or hep-th
+var arxivCategoryPattern = regexp.MustCompile(`^[a-z-]+(\.[A-Za-z-]+)?$`)
+
+// blueskyFeedPattern matches the at:// URI of a feed generator
+var blueskyFeedPattern = regexp.MustCompile(`^at://[^/]+/app\.bsky\.feed\.generator/[^/]+$`)
+
+// yamlLinePattern extracts the line number from yaml.v3 error messages
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decodeStrict decodes YAML into out, rejecting unknown keys. Decoding errors are
+// returned as ValidationErrors carrying the file and line they refer to.
+func decodeStrict(file string, data []byte, out interface{}) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, ValidationErrors{yamlError(file, err.Error())}
+	}
+	if len(root.Content) == 0 {
+		// Empty file: nothing to decode, every section keeps its zero value
+		return &root, nil
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			errs := make(ValidationErrors, 0, len(typeErr.Errors))
+			for _, message := range typeErr.Errors {
+				errs = append(errs, yamlError(file, message))
+			}
+			return nil, errs
+		}
+		return nil, ValidationErrors{yamlError(file, err.Error())}
+	}
+
+	return &root, nil
+}
+
+// yamlError converts a yaml.v3 error message into a ValidationError
+func yamlError(file, message string) *ValidationError {
+	if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return &ValidationError{File: file, Line: line, Message: match[2]}
+	}
+	return &ValidationError{File: file, Message: strings.TrimPrefix(message, "yaml: ")}
+}
+
+// validator accumulates errors and resolves config paths to YAML positions
+type validator struct {
+	file   string
+	root   *yaml.Node
+	prefix []string
+	errs   ValidationErrors
+}
+
+// errorf records an error for the value at path
+func (v *validator) errorf(path []string, format string, args ...interface{}) {
+	full := append(append([]string{}, v.prefix...), path...)
+	node := lookupNode(v.root, full)
+
+	err := &ValidationError{
+		File:    v.file,
+		Path:    strings.Join(full, "."),
+		Message: fmt.Sprintf(format, args...),
+	}
+	if node != nil {
+		err.Line = node.Line
+		err.Column = node.Column
+	}
+	v.errs = append(v.errs, err)
+}
+
+// lookupNode returns the node at path, or the deepest existing ancestor if the
+// path is not present in the document
+func lookupNode(root *yaml.Node, path []string) *yaml.Node {
+	if root == nil {
+		return nil
+	}
+	node := root
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+
+	for _, segment := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+// isPlaceholder reports whether a value is a sample placeholder such as "your_client_id"
+func isPlaceholder(value string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "your_")
+}
+
+// checkURL records an error unless value is an absolute http(s) URL
+func (v *validator) checkURL(path []string, value string) {
+	if value == "" {
+		v.errorf(path, "url is required")
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.errorf(path, "%q is not an absolute http(s) URL", value)
+	}
+}
+
+// checkSources validates a list of named sources and flags duplicate names
+func (v *validator) checkSources(section, list string, names, urls []string) {
+	seen := make(map[string]int)
+	for i, name := range names {
+		index := strconv.Itoa(i)
+		if strings.TrimSpace(name) == "" {
+			v.errorf([]string{section, list, index, "name"}, "name is required")
+		} else if first, exists := seen[name]; exists {
+			v.errorf([]string{section, list, index, "name"}, "duplicate source name %q (first defined at %s.%s.%d)", name, section, list, first)
+		} else {
+			seen[name] = i
+		}
+		v.checkURL([]string{section, list, index, "url"}, urls[i])
+	}
+}
+
+// Validate checks the connectors config for semantic errors. Sections of disabled
+// connectors are not checked. The returned error, if any, is ValidationErrors.
+func (c *ConnectorsConfig) Validate() error {
+	return c.validate("", nil, nil)
+}
+
+// validate checks the config, resolving error positions against root. The prefix
+// is the path of the connectors section inside the document.
+func (c *ConnectorsConfig) validate(file string, root *yaml.Node, prefix []string) error {
+	v := &validator{file: file, root: root, prefix: prefix}
+
+	if c.Telegram.Enabled {
+		names := make([]string, len(c.Telegram.Channels))
+		urls := make([]string, len(c.Telegram.Channels))
+		for i, channel := range c.Telegram.Channels {
+			names[i], urls[i] = channel.Name, channel.URL
+		}
+		if len(names) == 0 {
+			v.errorf([]string{"telegram", "channels"}, "at least one channel is required")
+		}
+		v.checkSources("telegram", "channels", names, urls)
+
+		if isPlaceholder(c.Telegram.Credentials.APIID.Value()) {
+			v.errorf([]string{"telegram", "credentials", "api_id"}, "placeholder value must be replaced")
+		}
+		if isPlaceholder(c.Telegram.Credentials.APIHash.Value()) {
+			v.errorf([]string{"telegram", "credentials", "api_hash"}, "placeholder value must be replaced")
+		}
+	}
+
+	if c.RSS.Enabled {
+		names := make([]string, len(c.RSS.Feeds))
+		urls := make([]string, len(c.RSS.Feeds))
+		for i, feed := range c.RSS.Feeds {
+			names[i], urls[i] = feed.Name, feed.URL
+		}
+		if len(names) == 0 {
+			v.errorf([]string{"rss", "feeds"}, "at least one feed is required")
+		}
+		v.checkSources("rss", "feeds", names, urls)
+
+		if c.RSS.Settings.Timeout <= 0 {
+			v.errorf([]string{"rss", "settings", "timeout"}, "timeout must be positive")
+		}
+	}
+
+	if c.Reddit.Enabled {
+		names := make([]string, len(c.Reddit.Subreddits))
+		urls := make([]string, len(c.Reddit.Subreddits))
+		for i, subreddit := range c.Reddit.Subreddits {
+			names[i], urls[i] = subreddit.Name, subreddit.URL
+		}
+		if len(names) == 0 {
+			v.errorf([]string{"reddit", "subreddits"}, "at least one subreddit is required")
+		}
+		v.checkSources("reddit", "subreddits", names, urls)
+
+		settings := c.Reddit.Settings
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"reddit", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.Limit <= 0 || settings.Limit > 100 {
+			v.errorf([]string{"reddit", "settings", "limit"}, "limit must be between 1 and 100, got %d", settings.Limit)
+		}
+		if !contains(validRedditSorts, settings.Sort) {
+			v.errorf([]string{"reddit", "settings", "sort"}, "invalid sort %q, must be one of %s", settings.Sort, strings.Join(validRedditSorts, ", "))
+		}
+
+		credentials := map[string]string{
+			"client_id":     settings.ClientID.Value(),
+			"client_secret": settings.ClientSecret.Value(),
+			"username":      settings.Username.Value(),
+			"password":      settings.Password.Value(),
+		}
+		set := 0
+		for _, key := range []string{"client_id", "client_secret", "username", "password"} {
+			value := credentials[key]
+			if value != "" {
+				set++
+			}
+			if isPlaceholder(value) {
+				v.errorf([]string{"reddit", "settings", key}, "placeholder value must be replaced, or left empty for read-only access")
+			}
+		}
+		if set > 0 && set < len(credentials) {
+			v.errorf([]string{"reddit", "settings"}, "client_id, client_secret, username and password must be set together")
+		}
+	}
+
+	if c.HackerNews.Enabled {
+		if len(c.HackerNews.Lists) == 0 {
+			v.errorf([]string{"hackernews", "lists"}, "at least one list is required")
+		}
+		seen := make(map[string]bool)
+		for i, list := range c.HackerNews.Lists {
+			path := []string{"hackernews", "lists", strconv.Itoa(i)}
+			if !contains(validHackerNewsLists, list) {
+				v.errorf(path, "invalid list %q, must be one of %s", list, strings.Join(validHackerNewsLists, ", "))
+			} else if seen[list] {
+				v.errorf(path, "duplicate list %q", list)
+			}
+			seen[list] = true
+		}
+
+		settings := c.HackerNews.Settings
+		v.checkURL([]string{"hackernews", "settings", "base_url"}, settings.BaseURL)
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"hackernews", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.Limit <= 0 || settings.Limit > 500 {
+			v.errorf([]string{"hackernews", "settings", "limit"}, "limit must be between 1 and 500, got %d", settings.Limit)
+		}
+		if settings.Workers <= 0 {
+			v.errorf([]string{"hackernews", "settings", "workers"}, "workers must be positive")
+		}
+		if settings.BatchSize < 0 {
+			v.errorf([]string{"hackernews", "settings", "batch_size"}, "batch_size must not be negative")
+		}
+	}
+
+	if c.Scraper.Enabled {
+		names := make([]string, len(c.Scraper.Sites))
+		urls := make([]string, len(c.Scraper.Sites))
+		for i, site := range c.Scraper.Sites {
+			names[i], urls[i] = site.Name, site.URL
+		}
+		if len(names) == 0 {
+			v.errorf([]string{"scraper", "sites"}, "at least one site is required")
+		}
+		v.checkSources("scraper", "sites", names, urls)
+
+		for i, site := range c.Scraper.Sites {
+			path := []string{"scraper", "sites", strconv.Itoa(i)}
+			selectors := map[string]string{
+				"item": site.Selectors.Item,
+				"link": site.Selectors.Link,
+				"body": site.Selectors.Body,
+			}
+			for _, key := range []string{"item", "link", "body"} {
+				if strings.TrimSpace(selectors[key]) == "" {
+					v.errorf(append(path, "selectors", key), "%s selector is required", key)
+				}
+			}
+			if site.MaxPages < 0 {
+				v.errorf(append(path, "max_pages"), "max_pages must not be negative")
+			}
+		}
+
+		settings := c.Scraper.Settings
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"scraper", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.CrawlDelay < 0 {
+			v.errorf([]string{"scraper", "settings", "crawl_delay"}, "crawl_delay must not be negative")
+		}
+		if settings.MaxItems <= 0 {
+			v.errorf([]string{"scraper", "settings", "max_items"}, "max_items must be positive")
+		}
+	}
+
+	if c.GitHub.Enabled {
+		if len(c.GitHub.Repositories) == 0 {
+			v.errorf([]string{"github", "repositories"}, "at least one repository is required")
+		}
+		seen := make(map[string]bool)
+		for i, repo := range c.GitHub.Repositories {
+			path := []string{"github", "repositories", strconv.Itoa(i)}
+			if !gitHubRepoPattern.MatchString(repo) {
+				v.errorf(path, "invalid repository %q, must be owner/repo", repo)
+			} else if seen[strings.ToLower(repo)] {
+				v.errorf(path, "duplicate repository %q", repo)
+			}
+			seen[strings.ToLower(repo)] = true
+		}
+
+		settings := c.GitHub.Settings
+		if !contains(validGitHubModes, settings.Mode) {
+			v.errorf([]string{"github", "settings", "mode"}, "invalid mode %q, must be one of %s", settings.Mode, strings.Join(validGitHubModes, ", "))
+		} else if settings.Mode == "api" {
+			v.checkURL([]string{"github", "settings", "base_url"}, settings.BaseURL)
+		} else {
+			v.checkURL([]string{"github", "settings", "feed_url"}, settings.FeedURL)
+		}
+		if isPlaceholder(settings.Token.Value()) {
+			v.errorf([]string{"github", "settings", "token"}, "placeholder value must be replaced, or left empty for anonymous access")
+		}
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"github", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.PerPage <= 0 || settings.PerPage > 100 {
+			v.errorf([]string{"github", "settings", "per_page"}, "per_page must be between 1 and 100, got %d", settings.PerPage)
+		}
+	}
+
+	if c.Mastodon.Enabled {
+		if len(c.Mastodon.Sources) == 0 {
+			v.errorf([]string{"mastodon", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.Mastodon.Sources {
+			path := []string{"mastodon", "sources", strconv.Itoa(i)}
+			v.checkURL(append(path, "instance"), source.Instance)
+			if (source.Account == "") == (source.Hashtag == "") {
+				v.errorf(path, "exactly one of account and hashtag is required")
+				continue
+			}
+			key := strings.ToLower(strings.TrimRight(source.Instance, "/") + " @" + source.Account + " #" + source.Hashtag)
+			if first, exists := seen[key]; exists {
+				v.errorf(path, "duplicate source (first defined at mastodon.sources.%d)", first)
+			} else {
+				seen[key] = i
+			}
+		}
+
+		settings := c.Mastodon.Settings
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"mastodon", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.Limit <= 0 || settings.Limit > 40 {
+			v.errorf([]string{"mastodon", "settings", "limit"}, "limit must be between 1 and 40, got %d", settings.Limit)
+		}
+		if settings.MaxPages <= 0 {
+			v.errorf([]string{"mastodon", "settings", "max_pages"}, "max_pages must be positive")
+		}
+	}
+
+	if c.Webhook.Enabled {
+		if len(c.Webhook.Sources) == 0 {
+			v.errorf([]string{"webhook", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.Webhook.Sources {
+			path := []string{"webhook", "sources", strconv.Itoa(i)}
+			if !webhookNamePattern.MatchString(source.Name) {
+				v.errorf(append(path, "name"), "invalid name %q, must be letters, digits, '-' or '_'", source.Name)
+			} else if first, exists := seen[source.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate source name %q (first defined at webhook.sources.%d)", source.Name, first)
+			} else {
+				seen[source.Name] = i
+			}
+			if source.Secret.Value() == "" {
+				v.errorf(append(path, "secret"), "secret is required")
+			} else if isPlaceholder(source.Secret.Value()) {
+				v.errorf(append(path, "secret"), "placeholder value must be replaced")
+			}
+		}
+
+		settings := c.Webhook.Settings
+		if settings.MaxBatch <= 0 {
+			v.errorf([]string{"webhook", "settings", "max_batch"}, "max_batch must be positive")
+		}
+		if settings.MaxSkew <= 0 {
+			v.errorf([]string{"webhook", "settings", "max_skew"}, "max_skew must be positive")
+		}
+	}
+
+	if c.IMAP.Enabled {
+		server := c.IMAP.Server
+		if _, port, err := net.SplitHostPort(server.Address); err != nil || port == "" {
+			v.errorf([]string{"imap", "server", "address"}, "%q is not a host:port address", server.Address)
+		}
+		credentials := map[string]string{"username": server.Username.Value(), "password": server.Password.Value()}
+		for _, key := range []string{"username", "password"} {
+			if credentials[key] == "" {
+				v.errorf([]string{"imap", "server", key}, "%s is required", key)
+			} else if isPlaceholder(credentials[key]) {
+				v.errorf([]string{"imap", "server", key}, "placeholder value must be replaced")
+			}
+		}
+
+		if len(c.IMAP.Sources) == 0 {
+			v.errorf([]string{"imap", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.IMAP.Sources {
+			path := []string{"imap", "sources", strconv.Itoa(i)}
+			if strings.TrimSpace(source.Name) == "" {
+				v.errorf(append(path, "name"), "name is required")
+			} else if first, exists := seen[source.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate source name %q (first defined at imap.sources.%d)", source.Name, first)
+			} else {
+				seen[source.Name] = i
+			}
+			if strings.TrimSpace(source.Folder) == "" {
+				v.errorf(append(path, "folder"), "folder is required")
+			}
+			for _, list := range []string{"include_urls", "exclude_urls"} {
+				patterns := source.Split.IncludeURLs
+				if list == "exclude_urls" {
+					patterns = source.Split.ExcludeURLs
+				}
+				for j, pattern := range patterns {
+					if _, err := regexp.Compile(pattern); err != nil {
+						v.errorf(append(path, "split", list, strconv.Itoa(j)), "invalid regular expression: %v", err)
+					}
+				}
+			}
+			if source.Split.MinTitleLength < 0 {
+				v.errorf(append(path, "split", "min_title_length"), "min_title_length must not be negative")
+			}
+		}
+
+		settings := c.IMAP.Settings
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"imap", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.MaxMessages <= 0 {
+			v.errorf([]string{"imap", "settings", "max_messages"}, "max_messages must be positive")
+		}
+	}
+
+	if c.YouTube.Enabled {
+		if len(c.YouTube.Sources) == 0 {
+			v.errorf([]string{"youtube", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.YouTube.Sources {
+			path := []string{"youtube", "sources", strconv.Itoa(i)}
+			if strings.TrimSpace(source.Name) == "" {
+				v.errorf(append(path, "name"), "name is required")
+			} else if first, exists := seen[source.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate source name %q (first defined at youtube.sources.%d)", source.Name, first)
+			} else {
+				seen[source.Name] = i
+			}
+			if (source.ChannelID == "") == (source.PlaylistID == "") {
+				v.errorf(path, "exactly one of channel_id and playlist_id is required")
+			}
+		}
+
+		settings := c.YouTube.Settings
+		v.checkURL([]string{"youtube", "settings", "base_url"}, settings.BaseURL)
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"youtube", "settings", "timeout"}, "timeout must be positive")
+		}
+	}
+
+	if c.Arxiv.Enabled {
+		if len(c.Arxiv.Queries) == 0 {
+			v.errorf([]string{"arxiv", "queries"}, "at least one query is required")
+		}
+		seen := make(map[string]int)
+		for i, query := range c.Arxiv.Queries {
+			path := []string{"arxiv", "queries", strconv.Itoa(i)}
+			if strings.TrimSpace(query.Name) == "" {
+				v.errorf(append(path, "name"), "name is required")
+			} else if first, exists := seen[query.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate query name %q (first defined at arxiv.queries.%d)", query.Name, first)
+			} else {
+				seen[query.Name] = i
+			}
+			if len(query.Categories) == 0 && strings.TrimSpace(query.Search) == "" {
+				v.errorf(path, "at least one of categories and search is required")
+			}
+			for j, category := range query.Categories {
+				if !arxivCategoryPattern.MatchString(category) {
+					v.errorf(append(path, "categories", strconv.Itoa(j)), "invalid category %q, expected e.g. cs.DC or hep-th", category)
+				}
+			}
+		}
+
+		settings := c.Arxiv.Settings
+		v.checkURL([]string{"arxiv", "settings", "base_url"}, settings.BaseURL)
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"arxiv", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.PageSize <= 0 || settings.PageSize > 2000 {
+			v.errorf([]string{"arxiv", "settings", "page_size"}, "page_size must be between 1 and 2000, got %d", settings.PageSize)
+		}
+		if settings.MaxPages <= 0 {
+			v.errorf([]string{"arxiv", "settings", "max_pages"}, "max_pages must be positive")
+		}
+		if settings.RequestDelay < 3*time.Second {
+			v.errorf([]string{"arxiv", "settings", "request_delay"}, "request_delay must be at least 3s as required by the arXiv API terms, got %s", settings.RequestDelay)
+		}
+	}
+
+	if c.StackExchange.Enabled {
+		if len(c.StackExchange.Sources) == 0 {
+			v.errorf([]string{"stackexchange", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.StackExchange.Sources {
+			path := []string{"stackexchange", "sources", strconv.Itoa(i)}
+			if strings.TrimSpace(source.Name) == "" {
+				v.errorf(append(path, "name"), "name is required")
+			} else if first, exists := seen[source.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate source name %q (first defined at stackexchange.sources.%d)", source.Name, first)
+			} else {
+				seen[source.Name] = i
+			}
+			if strings.TrimSpace(source.Site) == "" {
+				v.errorf(append(path, "site"), "site is required")
+			}
+			if len(source.Tags) == 0 || len(source.Tags) > 5 {
+				v.errorf(append(path, "tags"), "between 1 and 5 tags are required, got %d", len(source.Tags))
+			}
+			for j, tag := range source.Tags {
+				if strings.TrimSpace(tag) == "" || strings.ContainsAny(tag, "; ") {
+					v.errorf(append(path, "tags", strconv.Itoa(j)), "invalid tag %q", tag)
+				}
+			}
+		}
+
+		settings := c.StackExchange.Settings
+		v.checkURL([]string{"stackexchange", "settings", "base_url"}, settings.BaseURL)
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"stackexchange", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.PageSize <= 0 || settings.PageSize > 100 {
+			v.errorf([]string{"stackexchange", "settings", "page_size"}, "page_size must be between 1 and 100, got %d", settings.PageSize)
+		}
+		if settings.MaxPages <= 0 {
+			v.errorf([]string{"stackexchange", "settings", "max_pages"}, "max_pages must be positive")
+		}
+		if settings.RecheckWindow < 0 {
+			v.errorf([]string{"stackexchange", "settings", "recheck_window"}, "recheck_window must not be negative")
+		}
+	}
+
+	if c.Bluesky.Enabled {
+		if len(c.Bluesky.Sources) == 0 {
+			v.errorf([]string{"bluesky", "sources"}, "at least one source is required")
+		}
+		seen := make(map[string]int)
+		for i, source := range c.Bluesky.Sources {
+			path := []string{"bluesky", "sources", strconv.Itoa(i)}
+			if strings.TrimSpace(source.Name) == "" {
+				v.errorf(append(path, "name"), "name is required")
+			} else if first, exists := seen[source.Name]; exists {
+				v.errorf(append(path, "name"), "duplicate source name %q (first defined at bluesky.sources.%d)", source.Name, first)
+			} else {
+				seen[source.Name] = i
+			}
+			if (source.Actor == "") == (source.Feed == "") {
+				v.errorf(path, "exactly one of actor and feed is required")
+			} else if source.Feed != "" && !blueskyFeedPattern.MatchString(source.Feed) {
+				v.errorf(append(path, "feed"), "invalid feed %q, expected at://<handle or did>/app.bsky.feed.generator/<name>", source.Feed)
+			}
+		}
+
+		settings := c.Bluesky.Settings
+		v.checkURL([]string{"bluesky", "settings", "service_url"}, settings.ServiceURL)
+		if settings.Timeout <= 0 {
+			v.errorf([]string{"bluesky", "settings", "timeout"}, "timeout must be positive")
+		}
+		if settings.Limit <= 0 || settings.Limit > 100 {
+			v.errorf([]string{"bluesky", "settings", "limit"}, "limit must be between 1 and 100, got %d", settings.Limit)
+		}
+		if settings.MaxPages <= 0 {
+			v.errorf([]string{"bluesky", "settings", "max_pages"}, "max_pages must be positive")
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes content to a temporary config file and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "connectors.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConnectorsConfigValid(t *testing.T) {
+	path := writeConfig(t, `
+reddit:
+  enabled: true
+  subreddits:
+    - name: "golang"
+      url: "https://www.reddit.com/r/golang"
+  settings:
+    timeout: 30s
+    limit: 25
+    sort: "hot"
+`)
+
+	cfg, err := LoadConnectorsConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 25, cfg.Reddit.Settings.Limit)
+	assert.False(t, cfg.Reddit.Settings.HasCredentials())
+}
+
+func TestLoadConnectorsConfigUnknownKey(t *testing.T) {
+	path := writeConfig(t, `
+reddit:
+  enabled: true
+  settings:
+    sortt: "hot"
+`)
+
+	_, err := LoadConnectorsConfig(path)
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, path, errs[0].File)
+	assert.Equal(t, 5, errs[0].Line)
+	assert.Contains(t, errs[0].Message, "field sortt not found")
+}
+
+func TestLoadConnectorsConfigInvalidValues(t *testing.T) {
+	path := writeConfig(t, `
+rss:
+  enabled: true
+  feeds:
+    - name: "Go Blog"
+      url: "https://go.dev/blog/feed.atom"
+    - name: "Go Blog"
+      url: "not a url"
+  settings:
+    timeout: 30s
+reddit:
+  enabled: true
+  subreddits:
+    - name: "golang"
+      url: "https://www.reddit.com/r/golang"
+  settings:
+    timeout: 30s
+    limit: 25
+    sort: "best"
+    client_id: "your_client_id"
+    client_secret: "secret"
+    username: "user"
+    password: "pass"
+`)
+
+	_, err := LoadConnectorsConfig(path)
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+
+	positions := make(map[string]int)
+	for _, e := range errs {
+		positions[e.Path] = e.Line
+	}
+	assert.Equal(t, map[string]int{
+		"rss.feeds.1.name":          7,
+		"rss.feeds.1.url":           8,
+		"reddit.settings.sort":      19,
+		"reddit.settings.client_id": 20,
+	}, positions)
+}
+
+func TestValidateSkipsDisabledConnectors(t *testing.T) {
+	cfg := &ConnectorsConfig{
+		Reddit: RedditConfig{Enabled: false, Settings: RedditSettings{Sort: "invalid"}},
+	}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateScraperSites(t *testing.T) {
+	cfg := &ConnectorsConfig{
+		Scraper: ScraperConfig{
+			Enabled: true,
+			Sites: []SiteConfig{
+				{Name: "Go Blog", URL: "https://go.dev/blog/", Selectors: ScraperSelectors{Item: ".blogtitle", Link: "a", Body: "#content"}},
+				{Name: "Broken", URL: "https://example.com/news", Selectors: ScraperSelectors{Item: "article"}, MaxPages: -1},
+			},
+			Settings: ScraperSettings{Timeout: 30 * time.Second, MaxItems: 20},
+		},
+	}
+
+	err := cfg.Validate()
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{
+		"scraper.sites.1.selectors.link",
+		"scraper.sites.1.selectors.body",
+		"scraper.sites.1.max_pages",
+	}, paths)
+}
+
+func TestValidateArxivQueries(t *testing.T) {
+	cfg := &ConnectorsConfig{
+		Arxiv: ArxivConfig{
+			Enabled: true,
+			Queries: []ArxivQueryConfig{
+				{Name: "Distributed systems", Categories: []string{"cs.DC", "hep-th"}},
+				{Name: "Empty"},
+				{Name: "Typo", Categories: []string{"cs DC"}},
+			},
+			Settings: ArxivSettings{
+				BaseURL:      "https://export.arxiv.org/api/query",
+				Timeout:      time.Minute,
+				PageSize:     50,
+				MaxPages:     5,
+				RequestDelay: time.Second,
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{
+		"arxiv.queries.1",
+		"arxiv.queries.2.categories.0",
+		"arxiv.settings.request_delay",
+	}, paths)
+}
+
+func TestParseConnectorSection(t *testing.T) {
+	cfg, err := ParseConnectorSection("hackernews", []byte(`{
+		"enabled": false,
+		"lists": ["new"],
+		"settings": {"base_url": "https://hn.example.com/v0", "timeout": "10s", "limit": 5, "workers": 2}
+	}`))
+	require.NoError(t, err)
+	assert.True(t, cfg.HackerNews.Enabled, "previewed sections are always enabled")
+	assert.Equal(t, []string{"new"}, cfg.HackerNews.Lists)
+	assert.Equal(t, 10*time.Second, cfg.HackerNews.Settings.Timeout)
+	assert.False(t, cfg.Reddit.Enabled)
+}
+
+func TestParseConnectorSectionInvalid(t *testing.T) {
+	_, err := ParseConnectorSection("hackernews", []byte(`{"lists": ["old"], "settings": {"limit": 5, "workerz": 2}}`))
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, 0, errs[0].Line, "lines of the re-encoded document are not reported")
+	assert.Contains(t, errs[0].Message, "field workerz not found")
+
+	_, err = ParseConnectorSection("hackernews", []byte(`{"lists": ["old"], "settings": {"limit": 5}}`))
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, "hackernews.lists.0", errs[0].Path)
+
+	_, err = ParseConnectorSection("hackernews", []byte(`["top"]`))
+	assert.Error(t, err)
+}
+
+func TestParseConnectorSectionRejectsReferences(t *testing.T) {
+	t.Setenv("INFOBRO_TEST_SECRET", "hunter2")
+
+	_, err := ParseConnectorSection("reddit", []byte(`{
+		"subreddits": [{"name": "golang", "url": "https://www.reddit.com/r/golang"}],
+		"settings": {"client_id": "${INFOBRO_TEST_SECRET}", "client_secret": "file:/etc/passwd"}
+	}`))
+	var errs ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	assert.Equal(t, "reddit.settings.client_id", errs[0].Path)
+	assert.Equal(t, "reddit.settings.client_secret", errs[1].Path)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
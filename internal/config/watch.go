@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"time"
+)
+
+// WatchFile polls the file at path and calls onChange whenever its content changes.
+// Polling is used instead of inotify so that editors which replace the file and
+// bind-mounted config directories (Docker, Kubernetes ConfigMaps) are handled alike.
+// WatchFile blocks until ctx is cancelled.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	lastSum, err := fileChecksum(path)
+	if err != nil {
+		log.Printf("Config watcher: failed to read %s: %v", path, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sum, err := fileChecksum(path)
+			if err != nil {
+				// The file may be mid-replacement; try again on the next tick
+				continue
+			}
+			if bytes.Equal(sum, lastSum) {
+				continue
+			}
+			lastSum = sum
+			onChange()
+		}
+	}
+}
+
+// fileChecksum returns the SHA-256 of the file content
+func fileChecksum(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
@@ -0,0 +1,479 @@
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// seenLimit is how many paper IDs are remembered per query
+const seenLimit = 2000
+
+// Feed is a page of results returned by the arXiv query API
+type Feed struct {
+	TotalResults int     `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	StartIndex   int     `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	Entries      []Entry `xml:"entry"`
+}
+
+// Entry is a paper in the results. Fields outside the Atom namespace use the
+// arxiv namespace.
+type Entry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Summary   string    `xml:"summary"`
+	Published time.Time `xml:"published"`
+	Updated   time.Time `xml:"updated"`
+	Authors   []struct {
+		Name        string `xml:"name"`
+		Affiliation string `xml:"http://arxiv.org/schemas/atom affiliation"`
+	} `xml:"author"`
+	Links []struct {
+		Href  string `xml:"href,attr"`
+		Rel   string `xml:"rel,attr"`
+		Title string `xml:"title,attr"`
+		Type  string `xml:"type,attr"`
+	} `xml:"link"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+	PrimaryCategory struct {
+		Term string `xml:"term,attr"`
+	} `xml:"http://arxiv.org/schemas/atom primary_category"`
+	DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+	Comment    string `xml:"http://arxiv.org/schemas/atom comment"`
+	JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+}
+
+// Connector implements NewsConnector for arXiv queries
+type Connector struct {
+	client          *http.Client
+	baseURL         string
+	userAgent       string
+	pageSize        int
+	maxPages        int
+	requestDelay    time.Duration
+	queries         []config.ArxivQueryConfig
+	stateRepository models.ChannelStateRepository
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// New creates a new arXiv connector
+func New(cfg config.ArxivConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("arxiv connector is disabled in config")
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		baseURL:         cfg.Settings.BaseURL,
+		userAgent:       cfg.Settings.UserAgent,
+		pageSize:        cfg.Settings.PageSize,
+		maxPages:        cfg.Settings.MaxPages,
+		requestDelay:    cfg.Settings.RequestDelay,
+		queries:         cfg.Queries,
+		stateRepository: stateRepo,
+	}, nil
+}
+
+// GetNews returns the papers submitted for every query since the previous run.
+// A failing query is logged and skipped; an error is returned only if every query failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, query := range c.queries {
+		news, err := c.GetSourceNews(ctx, query.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to query arXiv %s: %v", query.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to query arXiv %s: %w", query.Name, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.queries) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured queries
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.queries))
+	for _, query := range c.queries {
+		names = append(names, query.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named query only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named query with the state that
+// moves its cursor past them, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, query := range c.queries {
+		if query.Name == name {
+			return c.queryNews(ctx, query)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// queryNews pages through the results of a query, newest submissions first, until it
+// reaches the cursor kept in LastMessageID. Without a cursor only the first page is
+// read; older papers are read by a backfill. A run that reads maxPages pages without
+// reaching the cursor leaves it where it was and records where the next run resumes.
+func (c *Connector) queryNews(ctx context.Context, query config.ArxivQueryConfig) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "arxiv:"+query.Name)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	cursor, err := parseCursor(state.LastMessageID)
+	if err != nil {
+		log.Printf("Ignoring malformed arXiv cursor %q for %s", state.LastMessageID, query.Name)
+	}
+	maxPages := c.maxPages
+	if cursor.time.IsZero() {
+		maxPages = 1
+	}
+
+	searchQuery := buildSearchQuery(query)
+	var entries []Entry
+	caughtUp := false
+	for page := 0; page < maxPages; page++ {
+		feed, err := c.fetchPage(ctx, searchQuery, cursor.offset+page*c.pageSize)
+		if err != nil {
+			return models.NewsBatch{}, err
+		}
+		entries = append(entries, feed.Entries...)
+
+		if len(feed.Entries) < c.pageSize || feed.StartIndex+len(feed.Entries) >= feed.TotalResults {
+			caughtUp = true
+			break
+		}
+		if oldest := feed.Entries[len(feed.Entries)-1]; !oldest.Published.After(cursor.time) {
+			caughtUp = true
+			break
+		}
+	}
+
+	fetchedAt := time.Now()
+	seen := state.SeenSet()
+	newest := cursor.newest
+	if newest.Before(cursor.time) {
+		newest = cursor.time
+	}
+	var news []models.RawNews
+	var ids []string
+	for _, entry := range entries {
+		id := paperID(entry.ID)
+		// Papers submitted in the same second as the cursor are told apart by the seen set
+		if id == "" || seen[id] || entry.Published.Before(cursor.time) {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		news = append(news, toRawNews(query, entry, id, fetchedAt))
+		if entry.Published.After(newest) {
+			newest = entry.Published
+		}
+	}
+
+	if caughtUp || cursor.time.IsZero() {
+		cursor = queryCursor{time: newest}
+	} else {
+		cursor.newest = newest
+		cursor.offset += maxPages * c.pageSize
+	}
+
+	state.MarkSeen(seenLimit, ids...)
+	if !cursor.time.IsZero() {
+		state.LastMessageID = cursor.String()
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// queryCursor is the position of a query, kept in LastMessageID as the RFC 3339 time
+// of the newest paper read. While a run that ran out of pages is being resumed, the
+// time of the newest paper read since and the offset to resume from are appended:
+// "<time> <newest> <offset>". New submissions only push papers to higher offsets, so
+// resuming rereads a few papers, which the seen set filters out, but skips none.
+type queryCursor struct {
+	time   time.Time
+	newest time.Time
+	offset int
+}
+
+// parseCursor parses a cursor written by queryCursor.String; "" is the zero cursor
+func parseCursor(value string) (queryCursor, error) {
+	var cursor queryCursor
+	if value == "" {
+		return cursor, nil
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) != 1 && len(fields) != 3 {
+		return queryCursor{}, fmt.Errorf("expected 1 or 3 fields, got %d", len(fields))
+	}
+	var err error
+	if cursor.time, err = time.Parse(time.RFC3339, fields[0]); err != nil {
+		return queryCursor{}, err
+	}
+	if len(fields) == 3 {
+		if cursor.newest, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+			return queryCursor{}, err
+		}
+		if cursor.offset, err = strconv.Atoi(fields[2]); err != nil || cursor.offset < 0 {
+			return queryCursor{}, fmt.Errorf("invalid offset %q", fields[2])
+		}
+	}
+	return cursor, nil
+}
+
+// String formats the cursor for LastMessageID
+func (c queryCursor) String() string {
+	if c.offset == 0 {
+		return c.time.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s %s %d", c.time.UTC().Format(time.RFC3339), c.newest.UTC().Format(time.RFC3339), c.offset)
+}
+
+// BackfillPage returns a page of the results of the named query, newest submissions
+// first. The cursor is the offset of the page in the results.
+func (c *Connector) BackfillPage(ctx context.Context, name, cursor string) (models.BackfillPage, error) {
+	for _, query := range c.queries {
+		if query.Name != name {
+			continue
+		}
+
+		start := 0
+		if cursor != "" {
+			var err error
+			if start, err = strconv.Atoi(cursor); err != nil || start < 0 {
+				return models.BackfillPage{}, fmt.Errorf("invalid arXiv cursor %q", cursor)
+			}
+		}
+		feed, err := c.fetchPage(ctx, buildSearchQuery(query), start)
+		if err != nil {
+			return models.BackfillPage{}, err
+		}
+
+		fetchedAt := time.Now()
+		var page models.BackfillPage
+		for _, entry := range feed.Entries {
+			if id := paperID(entry.ID); id != "" {
+				page.News = append(page.News, toRawNews(query, entry, id, fetchedAt))
+			}
+		}
+		if next := start + len(feed.Entries); len(feed.Entries) > 0 && next < feed.TotalResults {
+			page.Next = strconv.Itoa(next)
+		}
+		return page, nil
+	}
+	return models.BackfillPage{}, fmt.Errorf("unknown source %q", name)
+}
+
+// fetchPage requests one page of results sorted by submission date, newest first
+func (c *Connector) fetchPage(ctx context.Context, searchQuery string, start int) (*Feed, error) {
+	params := url.Values{
+		"search_query": {searchQuery},
+		"start":        {fmt.Sprint(start)},
+		"max_results":  {fmt.Sprint(c.pageSize)},
+		"sortBy":       {"submittedDate"},
+		"sortOrder":    {"descending"},
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var feed Feed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	// Errors come back as a feed with a single entry whose id points at the error docs
+	if len(feed.Entries) == 1 && strings.Contains(feed.Entries[0].ID, "/api/errors") {
+		return nil, fmt.Errorf("arXiv API error: %s", collapse(feed.Entries[0].Summary))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return &feed, nil
+}
+
+// wait blocks until requestDelay has passed since the previous request. Concurrent
+// callers reserve consecutive slots, so requests are never closer than the delay.
+func (c *Connector) wait(ctx context.Context) error {
+	c.mu.Lock()
+	next := c.lastRequest.Add(c.requestDelay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.lastRequest = next
+	c.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildSearchQuery combines the categories of a query with OR and the result with
+// its search expression using AND
+func buildSearchQuery(query config.ArxivQueryConfig) string {
+	var parts []string
+	if len(query.Categories) > 0 {
+		categories := make([]string, len(query.Categories))
+		for i, category := range query.Categories {
+			categories[i] = "cat:" + category
+		}
+		parts = append(parts, "("+strings.Join(categories, " OR ")+")")
+	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		parts = append(parts, "("+search+")")
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// toRawNews converts a result entry to the standard news format
+func toRawNews(query config.ArxivQueryConfig, entry Entry, id string, fetchedAt time.Time) models.RawNews {
+	var absURL, pdfURL string
+	for _, link := range entry.Links {
+		switch {
+		case link.Title == "pdf":
+			pdfURL = link.Href
+		case link.Rel == "alternate":
+			absURL = link.Href
+		}
+	}
+	if absURL == "" {
+		absURL = entry.ID
+	}
+
+	authors := make([]string, 0, len(entry.Authors))
+	for _, author := range entry.Authors {
+		authors = append(authors, collapse(author.Name))
+	}
+	categories := make([]string, 0, len(entry.Categories))
+	for _, category := range entry.Categories {
+		categories = append(categories, category.Term)
+	}
+
+	return models.RawNews{
+		SourceType:  "arxiv",
+		SourceID:    id,
+		SourceName:  query.Name,
+		SourceURL:   "https://arxiv.org/list/" + entry.PrimaryCategory.Term + "/new",
+		Title:       collapse(entry.Title),
+		Content:     collapse(entry.Summary),
+		URL:         absURL,
+		PublishedAt: entry.Published,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"arxivID":         id,
+			"version":         strings.TrimPrefix(strings.TrimPrefix(paperVersion(entry.ID), id), "v"),
+			"authors":         authors,
+			"primaryCategory": entry.PrimaryCategory.Term,
+			"categories":      categories,
+			"pdfURL":          pdfURL,
+			"doi":             strings.TrimSpace(entry.DOI),
+			"journalRef":      collapse(entry.JournalRef),
+			"comment":         collapse(entry.Comment),
+			"updatedAt":       entry.Updated,
+		},
+	}
+}
+
+// paperVersion returns the versioned ID at the end of an abs URL, e.g. 2403.01234v2
+func paperVersion(entryID string) string {
+	index := strings.Index(entryID, "/abs/")
+	if index < 0 {
+		return ""
+	}
+	return entryID[index+len("/abs/"):]
+}
+
+// paperID returns the ID of a paper without its version, so revisions are not
+// reported as new papers
+func paperID(entryID string) string {
+	versioned := paperVersion(entryID)
+	if index := strings.LastIndex(versioned, "v"); index > 0 && isDigits(versioned[index+1:]) {
+		return versioned[:index]
+	}
+	return versioned
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// collapse joins the whitespace-separated words of text with single spaces; titles
+// and abstracts are hard-wrapped in the feed
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,286 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paper is an entry of the stub API, listed newest first
+type paper struct {
+	id        string
+	published string
+}
+
+func feedXML(total, start int, papers []paper) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <opensearch:totalResults>%d</opensearch:totalResults>
+  <opensearch:startIndex>%d</opensearch:startIndex>
+`, total, start)
+	for _, p := range papers {
+		fmt.Fprintf(&b, `  <entry>
+    <id>http://arxiv.org/abs/%[1]sv2</id>
+    <updated>%[2]s</updated>
+    <published>%[2]s</published>
+    <title>Raft under
+      partial network partitions</title>
+    <summary>  We study how Raft behaves
+  when links fail asymmetrically.
+</summary>
+    <author><name>Ada Lovelace</name><arxiv:affiliation>Analytical Engines</arxiv:affiliation></author>
+    <author><name>Alan Turing</name></author>
+    <arxiv:doi>10.1145/0000000.%[1]s</arxiv:doi>
+    <arxiv:comment>12 pages, 4 figures</arxiv:comment>
+    <link href="http://arxiv.org/abs/%[1]sv2" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/%[1]sv2" rel="related" type="application/pdf"/>
+    <arxiv:primary_category term="cs.DC" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.DC" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.NI" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+`, p.id, p.published)
+	}
+	b.WriteString("</feed>")
+	return b.String()
+}
+
+// stubAPI serves papers in pages and records the requests it received
+type stubAPI struct {
+	mu       sync.Mutex
+	papers   []paper
+	queries  []string
+	requests []time.Time
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, r.URL.Query().Get("search_query"))
+	s.requests = append(s.requests, time.Now())
+
+	var start, size int
+	fmt.Sscan(r.URL.Query().Get("start"), &start)
+	fmt.Sscan(r.URL.Query().Get("max_results"), &size)
+	end := start + size
+	if end > len(s.papers) {
+		end = len(s.papers)
+	}
+	if start > end {
+		start = end
+	}
+	w.Write([]byte(feedXML(len(s.papers), start, s.papers[start:end])))
+}
+
+func newTestConnector(t *testing.T, baseURL string, delay time.Duration) *Connector {
+	t.Helper()
+	connector, err := New(config.ArxivConfig{
+		Enabled: true,
+		Queries: []config.ArxivQueryConfig{{Name: "consensus", Categories: []string{"cs.DC", "cs.NI"}, Search: "abs:raft"}},
+		Settings: config.ArxivSettings{
+			BaseURL:      baseURL,
+			Timeout:      5 * time.Second,
+			PageSize:     2,
+			MaxPages:     5,
+			RequestDelay: delay,
+		},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNews(t *testing.T) {
+	api := &stubAPI{papers: []paper{
+		{"2403.00003", "2026-03-03T10:00:00Z"},
+		{"2403.00002", "2026-03-02T10:00:00Z"},
+		{"2403.00001", "2026-03-01T10:00:00Z"},
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, 50*time.Millisecond)
+
+	// Without a cursor only the first page is read
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+	assert.Equal(t, "(cat:cs.DC OR cat:cs.NI) AND (abs:raft)", api.queries[0])
+
+	first := news[0]
+	assert.Equal(t, "arxiv", first.SourceType)
+	assert.Equal(t, "2403.00003", first.SourceID)
+	assert.Equal(t, "consensus", first.SourceName)
+	assert.Equal(t, "Raft under partial network partitions", first.Title)
+	assert.Equal(t, "We study how Raft behaves when links fail asymmetrically.", first.Content)
+	assert.Equal(t, "http://arxiv.org/abs/2403.00003v2", first.URL)
+	assert.Equal(t, time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC), first.PublishedAt)
+	assert.Equal(t, []string{"Ada Lovelace", "Alan Turing"}, first.Metadata["authors"])
+	assert.Equal(t, "cs.DC", first.Metadata["primaryCategory"])
+	assert.Equal(t, []string{"cs.DC", "cs.NI"}, first.Metadata["categories"])
+	assert.Equal(t, "http://arxiv.org/pdf/2403.00003v2", first.Metadata["pdfURL"])
+	assert.Equal(t, "10.1145/0000000.2403.00003", first.Metadata["doi"])
+	assert.Equal(t, "2", first.Metadata["version"])
+
+	// New submissions are read page by page back to the cursor
+	api.papers = append([]paper{
+		{"2403.00006", "2026-03-06T10:00:00Z"},
+		{"2403.00005", "2026-03-05T10:00:00Z"},
+		{"2403.00004", "2026-03-04T10:00:00Z"},
+	}, api.papers...)
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	var ids []string
+	for _, item := range news {
+		ids = append(ids, item.SourceID)
+	}
+	assert.Equal(t, []string{"2403.00006", "2403.00005", "2403.00004"}, ids)
+	assert.Len(t, api.queries, 3)
+
+	// Requests are spaced by the politeness delay
+	for i := 1; i < len(api.requests); i++ {
+		assert.GreaterOrEqual(t, int64(api.requests[i].Sub(api.requests[i-1])), int64(45*time.Millisecond))
+	}
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+}
+
+func TestGetNewsResumesUnreadPages(t *testing.T) {
+	api := &stubAPI{papers: []paper{{"2403.00001", "2026-03-01T10:00:00Z"}}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, 0)
+	ctx := context.Background()
+
+	news, err := connector.GetNews(ctx)
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+
+	// 12 new submissions are more than the 5 pages of 2 a run reads
+	var submitted []paper
+	for i := 13; i > 1; i-- {
+		submitted = append(submitted, paper{fmt.Sprintf("2403.%05d", i), fmt.Sprintf("2026-03-%02dT10:00:00Z", i)})
+	}
+	api.papers = append(submitted, api.papers...)
+
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	assert.Len(t, news, 10)
+	state, err := connector.stateRepository.GetChannelState(ctx, "arxiv:consensus")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-01T10:00:00Z 2026-03-13T10:00:00Z 10", state.LastMessageID, "the cursor stays until the gap is read")
+
+	// A submission arriving meanwhile shifts the offsets by one; the reread paper is skipped
+	api.papers = append([]paper{{"2403.00014", "2026-03-14T10:00:00Z"}}, api.papers...)
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, item := range news {
+		ids = append(ids, item.SourceID)
+	}
+	assert.Equal(t, []string{"2403.00003", "2403.00002"}, ids)
+	state, err = connector.stateRepository.GetChannelState(ctx, "arxiv:consensus")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-13T10:00:00Z", state.LastMessageID)
+
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "2403.00014", news[0].SourceID)
+}
+
+func TestParseCursor(t *testing.T) {
+	cursor, err := parseCursor("2026-03-01T10:00:00Z 2026-03-13T10:00:00Z 10")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), cursor.time)
+	assert.Equal(t, time.Date(2026, 3, 13, 10, 0, 0, 0, time.UTC), cursor.newest)
+	assert.Equal(t, 10, cursor.offset)
+	assert.Equal(t, "2026-03-01T10:00:00Z 2026-03-13T10:00:00Z 10", cursor.String())
+
+	cursor, err = parseCursor("2026-03-01T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-01T10:00:00Z", cursor.String())
+
+	for _, malformed := range []string{"yesterday", "2026-03-01T10:00:00Z 10", "2026-03-01T10:00:00Z 2026-03-13T10:00:00Z -1"} {
+		_, err := parseCursor(malformed)
+		assert.Error(t, err, malformed)
+	}
+}
+
+func TestBackfillPage(t *testing.T) {
+	api := &stubAPI{papers: []paper{
+		{"2403.00003", "2026-03-03T10:00:00Z"},
+		{"2403.00002", "2026-03-02T10:00:00Z"},
+		{"2403.00001", "2026-03-01T10:00:00Z"},
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, 0)
+
+	page, err := connector.BackfillPage(context.Background(), "consensus", "")
+	require.NoError(t, err)
+	require.Len(t, page.News, 2)
+	assert.Equal(t, "2403.00003", page.News[0].SourceID)
+	assert.Equal(t, "2", page.Next)
+
+	page, err = connector.BackfillPage(context.Background(), "consensus", page.Next)
+	require.NoError(t, err)
+	require.Len(t, page.News, 1)
+	assert.Equal(t, "2403.00001", page.News[0].SourceID)
+	assert.Empty(t, page.Next, "the last page has no cursor")
+
+	// A backfill does not move the cursor of live runs
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, news, 2)
+
+	_, err = connector.BackfillPage(context.Background(), "consensus", "oops")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid arXiv cursor")
+}
+
+func TestGetNewsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>http://arxiv.org/api/errors#incorrect_id_format_for_1234</id>
+    <title>Error</title>
+    <summary>incorrect id format for 1234</summary>
+  </entry>
+</feed>`))
+	}))
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, 0)
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "arXiv API error: incorrect id format for 1234")
+}
+
+func TestWaitHonoursContext(t *testing.T) {
+	connector := newTestConnector(t, "http://127.0.0.1", time.Hour)
+	require.NoError(t, connector.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, connector.wait(ctx))
+}
+
+func TestPaperID(t *testing.T) {
+	assert.Equal(t, "2403.01234", paperID("http://arxiv.org/abs/2403.01234v3"))
+	assert.Equal(t, "hep-th/9901001", paperID("http://arxiv.org/abs/hep-th/9901001v1"))
+	assert.Equal(t, "2403.01234", paperID("http://arxiv.org/abs/2403.01234"))
+	assert.Equal(t, "", paperID("http://arxiv.org/api/errors#x"))
+}
@@ -0,0 +1,311 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+var (
+	// ErrBackfillUnsupported is returned for connectors that cannot page through their history
+	ErrBackfillUnsupported = errors.New("connector does not support backfill")
+	// ErrBackfillRunning is returned when the source is already being backfilled
+	ErrBackfillRunning = errors.New("backfill is already running")
+	// ErrInvalidBackfillRange is returned for a range without a start or with its end before its start
+	ErrInvalidBackfillRange = errors.New("invalid backfill range")
+)
+
+// BackfillSettings bounds backfill runs
+type BackfillSettings struct {
+	// PageDelay is the pause between two pages, on top of the rate limits of the transport
+	PageDelay time.Duration
+	// MaxPages bounds the pages read by one run; the backfill is then stopped and can be resumed
+	MaxPages int
+}
+
+// Backfiller fills in the history of a source between two times, storing the news
+// like regular runs. Progress is saved after every page in a BackfillRepository,
+// apart from the ChannelState of the source, so live runs are not disturbed and an
+// interrupted backfill resumes from its last page.
+type Backfiller struct {
+	service  *ConnectorService
+	repo     models.BackfillRepository
+	settings BackfillSettings
+
+	mu      sync.Mutex
+	running map[[2]string]bool
+
+	// ctx is the parent of backfills started in the background; Close cancels it
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBackfiller creates a backfiller for the connectors of service
+func NewBackfiller(service *ConnectorService, repo models.BackfillRepository, settings BackfillSettings) *Backfiller {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backfiller{
+		service:  service,
+		repo:     repo,
+		settings: settings,
+		running:  make(map[[2]string]bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Run backfills a source of the named connector between since and until, a zero until
+// meaning now, and returns its progress. A stopped or failed backfill of the same range
+// resumes from its last page; a finished one is returned as is.
+func (b *Backfiller) Run(ctx context.Context, name, source string, since, until time.Time) (*models.Backfill, error) {
+	connector, backfill, err := b.begin(ctx, name, source, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer b.finish(name, source)
+
+	if backfill.State == models.BackfillDone {
+		return backfill, nil
+	}
+	err = b.run(ctx, name, connector, backfill)
+	return backfill, err
+}
+
+// Start begins a backfill like Run in the background and returns its progress at the start
+func (b *Backfiller) Start(ctx context.Context, name, source string, since, until time.Time) (*models.Backfill, error) {
+	connector, backfill, err := b.begin(ctx, name, source, since, until)
+	if err != nil {
+		return nil, err
+	}
+	started := *backfill
+	if backfill.State == models.BackfillDone {
+		b.finish(name, source)
+		return &started, nil
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer b.finish(name, source)
+
+		if err := b.run(b.ctx, name, connector, backfill); err != nil {
+			log.Printf("Backfill of %s/%s stopped: %v", name, source, err)
+			return
+		}
+		log.Printf("Backfill of %s/%s %s: %d pages, %d news stored", name, source, backfill.State, backfill.Pages, backfill.Stored)
+	}()
+	return &started, nil
+}
+
+// Status returns the progress of the last backfill of a source, or nil if it was never backfilled
+func (b *Backfiller) Status(ctx context.Context, name, source string) (*models.Backfill, error) {
+	if _, err := b.connector(name, source); err != nil {
+		return nil, err
+	}
+	return b.repo.GetBackfill(ctx, name, source)
+}
+
+// Close cancels the backfills running in the background and waits until they saved their progress
+func (b *Backfiller) Close() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+// begin checks the request, marks the source as running and returns the backfill to
+// continue: the stored one if it covers the same range, a new one otherwise
+func (b *Backfiller) begin(ctx context.Context, name, source string, since, until time.Time) (models.BackfillConnector, *models.Backfill, error) {
+	connector, err := b.connector(name, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if since.IsZero() {
+		return nil, nil, fmt.Errorf("%w: the start is required", ErrInvalidBackfillRange)
+	}
+	if !until.IsZero() && !since.Before(until) {
+		return nil, nil, fmt.Errorf("%w: %s is not before %s", ErrInvalidBackfillRange, since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	key := [2]string{name, source}
+	b.mu.Lock()
+	if b.running[key] {
+		b.mu.Unlock()
+		return nil, nil, fmt.Errorf("%w for %s/%s", ErrBackfillRunning, name, source)
+	}
+	b.running[key] = true
+	b.mu.Unlock()
+
+	backfill, err := b.repo.GetBackfill(ctx, name, source)
+	if err != nil {
+		b.finish(name, source)
+		return nil, nil, fmt.Errorf("failed to load backfill of %s/%s: %w", name, source, err)
+	}
+	if backfill != nil && backfill.Since.Equal(since) && (until.IsZero() || backfill.Until.Equal(until)) {
+		return connector, backfill, nil
+	}
+
+	now := time.Now()
+	if until.IsZero() {
+		until = now
+	}
+	return connector, &models.Backfill{
+		Connector: name,
+		Source:    source,
+		Since:     since.UTC(),
+		Until:     until.UTC(),
+		StartedAt: now,
+	}, nil
+}
+
+// finish marks the source as no longer running
+func (b *Backfiller) finish(name, source string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.running, [2]string{name, source})
+}
+
+// connector returns the named connector if it can backfill source
+func (b *Backfiller) connector(name, source string) (models.BackfillConnector, error) {
+	connector, exists := b.service.Connector(name)
+	if !exists {
+		return nil, fmt.Errorf("%w: connector %s not found", ErrUnknownSource, name)
+	}
+	backfiller, ok := connector.(models.BackfillConnector)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBackfillUnsupported, name)
+	}
+	for _, known := range backfiller.Sources() {
+		if known == source {
+			return backfiller, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: connector %s has no source %s", ErrUnknownSource, name, source)
+}
+
+// run pages backwards through the source until the news is older than the start of
+// the range or the history is exhausted, storing the news inside the range and saving
+// the progress after every page. Pages share the worker pool with regular runs.
+func (b *Backfiller) run(ctx context.Context, name string, connector models.BackfillConnector, backfill *models.Backfill) error {
+	backfill.State = models.BackfillRunning
+	backfill.LastError = ""
+	if err := b.save(ctx, backfill); err != nil {
+		return err
+	}
+
+	for pages := 0; ; pages++ {
+		if pages == b.settings.MaxPages {
+			backfill.State = models.BackfillStopped
+			return b.save(ctx, backfill)
+		}
+		if pages > 0 && b.settings.PageDelay > 0 {
+			if err := sleep(ctx, b.settings.PageDelay); err != nil {
+				return b.fail(ctx, backfill, err)
+			}
+		}
+
+		if err := b.service.acquire(ctx); err != nil {
+			return b.fail(ctx, backfill, err)
+		}
+		page, err := connector.BackfillPage(ctx, backfill.Source, backfill.Cursor)
+		b.service.release()
+		if err != nil {
+			return b.fail(ctx, backfill, err)
+		}
+
+		var news []models.RawNews
+		covered := false
+		for _, item := range page.News {
+			if backfill.Oldest.IsZero() || item.PublishedAt.Before(backfill.Oldest) {
+				backfill.Oldest = item.PublishedAt
+			}
+			switch {
+			case item.PublishedAt.Before(backfill.Since):
+				covered = true
+			case !item.PublishedAt.After(backfill.Until):
+				news = append(news, item)
+			}
+		}
+
+		count, err := b.service.StoreNews(ctx, name, news)
+		if err != nil {
+			return b.fail(ctx, backfill, err)
+		}
+		backfill.Pages++
+		backfill.Stored += count
+		backfill.Cursor = page.Next
+		if covered || page.Next == "" {
+			backfill.State = models.BackfillDone
+			backfill.Cursor = ""
+		}
+		if err := b.save(ctx, backfill); err != nil {
+			return err
+		}
+		if backfill.State == models.BackfillDone {
+			return nil
+		}
+	}
+}
+
+// fail records why a backfill ended early. An interrupted backfill is stopped rather
+// than failed, since it resumes cleanly.
+func (b *Backfiller) fail(ctx context.Context, backfill *models.Backfill, err error) error {
+	backfill.State = models.BackfillFailed
+	if ctx.Err() != nil {
+		backfill.State = models.BackfillStopped
+	}
+	backfill.LastError = err.Error()
+	if saveErr := b.save(ctx, backfill); saveErr != nil {
+		log.Printf("Failed to save backfill of %s/%s: %v", backfill.Connector, backfill.Source, saveErr)
+	}
+	return err
+}
+
+// save stores the progress of a backfill, also when ctx is already cancelled
+func (b *Backfiller) save(ctx context.Context, backfill *models.Backfill) error {
+	backfill.UpdatedAt = time.Now()
+	if err := b.repo.SaveBackfill(context.WithoutCancel(ctx), backfill); err != nil {
+		return fmt.Errorf("failed to save backfill of %s/%s: %w", backfill.Connector, backfill.Source, err)
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ParseBackfillTime parses a bound of a backfill range: an RFC 3339 time, a date such
+// as 2026-01-31 (midnight UTC), or an age before now such as 72h or 30d. Ages are
+// rounded down to the hour, so repeating the same command within the hour resumes
+// the same backfill.
+func ParseBackfillTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n).Truncate(time.Hour), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return now.Add(-age).Truncate(time.Hour), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339, YYYY-MM-DD or an age such as 72h or 30d", value)
+}
@@ -0,0 +1,198 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubBackfillConnector serves one news item per day, newest first, two per page.
+// The page at failAt fails once.
+type stubBackfillConnector struct {
+	newest  time.Time
+	days    int
+	failAt  int
+	cursors []string
+}
+
+func (c *stubBackfillConnector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	return nil, nil
+}
+
+func (c *stubBackfillConnector) Sources() []string {
+	return []string{"golang"}
+}
+
+func (c *stubBackfillConnector) GetSourceNews(ctx context.Context, source string) ([]models.RawNews, error) {
+	return nil, nil
+}
+
+func (c *stubBackfillConnector) BackfillPage(ctx context.Context, source, cursor string) (models.BackfillPage, error) {
+	c.cursors = append(c.cursors, cursor)
+	start, _ := strconv.Atoi(cursor)
+	if c.failAt > 0 && start/2 == c.failAt {
+		c.failAt = 0
+		return models.BackfillPage{}, errors.New("unexpected status 503")
+	}
+
+	var page models.BackfillPage
+	for day := start; day < start+2 && day < c.days; day++ {
+		page.News = append(page.News, models.RawNews{
+			SourceType:  "stub",
+			SourceID:    strconv.Itoa(day),
+			PublishedAt: c.newest.AddDate(0, 0, -day),
+		})
+	}
+	if start+2 < c.days {
+		page.Next = strconv.Itoa(start + 2)
+	}
+	return page, nil
+}
+
+func newTestBackfiller(connector models.NewsConnector, news *memoryNews, maxPages int) (*Backfiller, *storage.MemoryBackfillRepository) {
+	service := NewConnectorService(map[string]models.NewsConnector{"stub": connector}, news, nil, news, nil, RunSettings{Workers: 1})
+	repo := storage.NewMemoryBackfillRepository()
+	return NewBackfiller(service, repo, BackfillSettings{MaxPages: maxPages}), repo
+}
+
+func TestBackfillStopsAtStartOfRange(t *testing.T) {
+	newest := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	connector := &stubBackfillConnector{newest: newest, days: 30}
+	news := &memoryNews{}
+	backfiller, _ := newTestBackfiller(connector, news, 100)
+
+	// Items of days 1 to 5 are inside the range; the page with day 6 covers its start
+	since := newest.AddDate(0, 0, -5)
+	until := newest.AddDate(0, 0, -1)
+	backfill, err := backfiller.Run(context.Background(), "stub", "golang", since, until)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.BackfillDone, backfill.State)
+	assert.Equal(t, 4, backfill.Pages)
+	assert.Equal(t, 5, backfill.Stored)
+	assert.Len(t, news.saved, 5)
+	assert.Equal(t, "1", news.saved[0].SourceID)
+	assert.Equal(t, "5", news.saved[4].SourceID)
+	assert.Equal(t, newest.AddDate(0, 0, -7), backfill.Oldest)
+	assert.Empty(t, backfill.Cursor)
+
+	// Running the finished backfill again does not fetch anything
+	backfill, err = backfiller.Run(context.Background(), "stub", "golang", since, until)
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillDone, backfill.State)
+	assert.Len(t, connector.cursors, 4)
+}
+
+func TestBackfillResumesFromLastPage(t *testing.T) {
+	newest := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	connector := &stubBackfillConnector{newest: newest, days: 10, failAt: 2}
+	news := &memoryNews{}
+	backfiller, repo := newTestBackfiller(connector, news, 100)
+	since := newest.AddDate(0, 0, -30)
+
+	backfill, err := backfiller.Run(context.Background(), "stub", "golang", since, time.Time{})
+	require.Error(t, err)
+	assert.Equal(t, models.BackfillFailed, backfill.State)
+	assert.Equal(t, "unexpected status 503", backfill.LastError)
+	assert.Equal(t, "4", backfill.Cursor)
+
+	stored, err := repo.GetBackfill(context.Background(), "stub", "golang")
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Pages, "progress is saved after every page")
+
+	// The same range without an end resumes where the failed run stopped
+	backfill, err = backfiller.Run(context.Background(), "stub", "golang", since, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillDone, backfill.State)
+	assert.Equal(t, 5, backfill.Pages)
+	assert.Equal(t, 10, backfill.Stored)
+	assert.Len(t, news.saved, 10)
+	assert.Equal(t, []string{"", "2", "4", "4", "6", "8"}, connector.cursors)
+}
+
+func TestBackfillSkipsStoredNews(t *testing.T) {
+	newest := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	connector := &stubBackfillConnector{newest: newest, days: 6}
+	news := &memoryNews{}
+	backfiller, _ := newTestBackfiller(connector, news, 100)
+
+	// A live run already stored the three newest items
+	live, err := connector.BackfillPage(context.Background(), "golang", "")
+	require.NoError(t, err)
+	_, err = backfiller.service.StoreNews(context.Background(), "stub", append(live.News, models.RawNews{
+		SourceType: "stub", SourceID: "2", PublishedAt: newest.AddDate(0, 0, -2),
+	}))
+	require.NoError(t, err)
+	news.queued = 0
+
+	backfill, err := backfiller.Run(context.Background(), "stub", "golang", newest.AddDate(0, 0, -30), newest)
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillDone, backfill.State)
+	assert.Equal(t, 3, backfill.Pages)
+	assert.Equal(t, 3, backfill.Stored, "news stored before is not counted")
+	assert.Equal(t, 3, news.queued, "news stored before is not queued again")
+
+	var ids []string
+	for _, item := range news.saved {
+		ids = append(ids, item.SourceID)
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, ids)
+}
+
+func TestBackfillStopsAfterMaxPages(t *testing.T) {
+	newest := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	connector := &stubBackfillConnector{newest: newest, days: 10}
+	backfiller, _ := newTestBackfiller(connector, &memoryNews{}, 2)
+
+	backfill, err := backfiller.Run(context.Background(), "stub", "golang", newest.AddDate(0, 0, -30), newest)
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillStopped, backfill.State)
+	assert.Equal(t, 2, backfill.Pages)
+	assert.Equal(t, "4", backfill.Cursor)
+}
+
+func TestBackfillRejectsUnsupportedSources(t *testing.T) {
+	backfiller, _ := newTestBackfiller(&stubSourceConnector{sources: []string{"golang"}}, &memoryNews{}, 1)
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := backfiller.Run(context.Background(), "stub", "golang", since, time.Time{})
+	assert.True(t, errors.Is(err, ErrBackfillUnsupported))
+
+	_, err = backfiller.Run(context.Background(), "missing", "golang", since, time.Time{})
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+
+	backfiller, _ = newTestBackfiller(&stubBackfillConnector{}, &memoryNews{}, 1)
+	_, err = backfiller.Run(context.Background(), "stub", "rust", since, time.Time{})
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+
+	_, err = backfiller.Run(context.Background(), "stub", "golang", since, since)
+	assert.True(t, errors.Is(err, ErrInvalidBackfillRange))
+}
+
+func TestParseBackfillTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-02-01T08:00:00Z", time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)},
+		{"2026-02-01", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"30d", time.Date(2026, 2, 8, 12, 0, 0, 0, time.UTC)},
+		{"90m", time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseBackfillTime(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+
+	_, err := ParseBackfillTime("last week", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,539 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// titleLength is the maximum length of a title cut from the post text
+const titleLength = 100
+
+// seenLimit is how many post URIs are remembered per source
+const seenLimit = 2000
+
+// Facet feature types
+const (
+	linkFeature    = "app.bsky.richtext.facet#link"
+	mentionFeature = "app.bsky.richtext.facet#mention"
+	tagFeature     = "app.bsky.richtext.facet#tag"
+)
+
+// Profile is the author of a post or of a repost
+type Profile struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+}
+
+// Facet annotates a UTF-8 byte range of the post text with links, mentions or tags
+type Facet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []struct {
+		Type string `json:"$type"`
+		URI  string `json:"uri"`
+		DID  string `json:"did"`
+		Tag  string `json:"tag"`
+	} `json:"features"`
+}
+
+// Record is the app.bsky.feed.post record of a post
+type Record struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+	Langs     []string  `json:"langs"`
+	Facets    []Facet   `json:"facets"`
+	// Reply is set when the post replies to another post
+	Reply *struct {
+		Parent struct {
+			URI string `json:"uri"`
+		} `json:"parent"`
+	} `json:"reply"`
+}
+
+// Embed is the view of media embedded in a post; only link cards are used
+type Embed struct {
+	Type     string `json:"$type"`
+	External *struct {
+		URI         string `json:"uri"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Thumb       string `json:"thumb"`
+	} `json:"external"`
+}
+
+// Post is a post view returned by the feed endpoints
+type Post struct {
+	URI         string    `json:"uri"`
+	CID         string    `json:"cid"`
+	Author      Profile   `json:"author"`
+	Record      Record    `json:"record"`
+	Embed       *Embed    `json:"embed"`
+	ReplyCount  int       `json:"replyCount"`
+	RepostCount int       `json:"repostCount"`
+	LikeCount   int       `json:"likeCount"`
+	QuoteCount  int       `json:"quoteCount"`
+	IndexedAt   time.Time `json:"indexedAt"`
+}
+
+// FeedItem is an entry of a feed; Reason is set when the post was reposted into it
+type FeedItem struct {
+	Post   Post `json:"post"`
+	Reason *struct {
+		Type string  `json:"$type"`
+		By   Profile `json:"by"`
+	} `json:"reason"`
+}
+
+// FeedResponse is a page of getAuthorFeed or getFeed
+type FeedResponse struct {
+	Cursor string     `json:"cursor"`
+	Feed   []FeedItem `json:"feed"`
+}
+
+// Connector implements NewsConnector for Bluesky author feeds and custom feeds
+type Connector struct {
+	client          *http.Client
+	serviceURL      string
+	userAgent       string
+	limit           int
+	maxPages        int
+	excludeReplies  bool
+	excludeReposts  bool
+	sources         []config.BlueskySourceConfig
+	stateRepository models.ChannelStateRepository
+
+	mu   sync.Mutex
+	dids map[string]string
+}
+
+// New creates a new Bluesky connector
+func New(cfg config.BlueskyConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("bluesky connector is disabled in config")
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		serviceURL:      strings.TrimRight(cfg.Settings.ServiceURL, "/"),
+		userAgent:       cfg.Settings.UserAgent,
+		limit:           cfg.Settings.Limit,
+		maxPages:        cfg.Settings.MaxPages,
+		excludeReplies:  cfg.Settings.ExcludeReplies,
+		excludeReposts:  cfg.Settings.ExcludeReposts,
+		sources:         cfg.Sources,
+		stateRepository: stateRepo,
+		dids:            make(map[string]string),
+	}, nil
+}
+
+// GetNews returns the posts added to every source since the previous run.
+// A failing source is logged and skipped; an error is returned only if every source failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, source := range c.sources {
+		news, err := c.GetSourceNews(ctx, source.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch Bluesky feed %s: %v", source.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to fetch Bluesky feed %s: %w", source.Name, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.sources) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured feeds
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named feed only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named feed with the state that
+// marks its posts as seen, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, source := range c.sources {
+		if source.Name == name {
+			return c.sourceNews(ctx, source)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// sourceNews follows the feed cursor from the newest posts back to the first page
+// containing a post seen before. Without state only the newest page is read.
+func (c *Connector) sourceNews(ctx context.Context, source config.BlueskySourceConfig) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "bluesky:"+source.Name)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	endpoint, query, err := c.feedRequest(ctx, source)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	maxPages := c.maxPages
+	if state.LastMessageID == "" {
+		maxPages = 1
+	}
+
+	seen := state.SeenSet()
+	var items []FeedItem
+	for page := 0; page < maxPages; page++ {
+		var resp FeedResponse
+		if err := c.getJSON(ctx, endpoint+"?"+query.Encode(), &resp); err != nil {
+			return models.NewsBatch{}, err
+		}
+		items = append(items, resp.Feed...)
+
+		caughtUp := false
+		for _, item := range resp.Feed {
+			if seen[itemID(item)] {
+				caughtUp = true
+				break
+			}
+		}
+		if caughtUp || resp.Cursor == "" || len(resp.Feed) == 0 {
+			break
+		}
+		query.Set("cursor", resp.Cursor)
+	}
+
+	fetchedAt := time.Now()
+	var news []models.RawNews
+	var ids []string
+	for _, item := range items {
+		id := itemID(item)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if c.excludeReplies && item.Post.Record.Reply != nil {
+			continue
+		}
+		if c.excludeReposts && item.Reason != nil {
+			continue
+		}
+		news = append(news, toRawNews(source, item, id, fetchedAt))
+	}
+
+	state.MarkSeen(seenLimit, ids...)
+	if len(items) > 0 {
+		state.LastMessageID = itemID(items[0])
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// feedRequest returns the XRPC endpoint and query of a source, with handles resolved to DIDs
+func (c *Connector) feedRequest(ctx context.Context, source config.BlueskySourceConfig) (string, url.Values, error) {
+	query := url.Values{"limit": {strconv.Itoa(c.limit)}}
+
+	if source.Feed != "" {
+		authority, rest := splitATURI(source.Feed)
+		did, err := c.resolveDID(ctx, authority)
+		if err != nil {
+			return "", nil, err
+		}
+		query.Set("feed", "at://"+did+rest)
+		return c.serviceURL + "/xrpc/app.bsky.feed.getFeed", query, nil
+	}
+
+	did, err := c.resolveDID(ctx, strings.TrimPrefix(source.Actor, "@"))
+	if err != nil {
+		return "", nil, err
+	}
+	query.Set("actor", did)
+	if c.excludeReplies {
+		query.Set("filter", "posts_no_replies")
+	}
+	return c.serviceURL + "/xrpc/app.bsky.feed.getAuthorFeed", query, nil
+}
+
+// resolveDID resolves a handle to its DID, caching the result. DIDs are returned as is.
+func (c *Connector) resolveDID(ctx context.Context, handle string) (string, error) {
+	if strings.HasPrefix(handle, "did:") {
+		return handle, nil
+	}
+
+	c.mu.Lock()
+	did, ok := c.dids[handle]
+	c.mu.Unlock()
+	if ok {
+		return did, nil
+	}
+
+	var resp struct {
+		DID string `json:"did"`
+	}
+	endpoint := c.serviceURL + "/xrpc/com.atproto.identity.resolveHandle?handle=" + url.QueryEscape(handle)
+	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+		return "", fmt.Errorf("failed to resolve handle %s: %w", handle, err)
+	}
+
+	c.mu.Lock()
+	c.dids[handle] = resp.DID
+	c.mu.Unlock()
+	return resp.DID, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into out. XRPC
+// errors carry a JSON body with error and message fields.
+func (c *Connector) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var xrpcErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&xrpcErr) == nil && xrpcErr.Error != "" {
+			return fmt.Errorf("unexpected status %s: %s: %s", resp.Status, xrpcErr.Error, xrpcErr.Message)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// toRawNews converts a feed item to the standard news format. Link facets are
+// expanded in the content because Bluesky shortens the displayed link text.
+func toRawNews(source config.BlueskySourceConfig, item FeedItem, id string, fetchedAt time.Time) models.RawNews {
+	post := item.Post
+	record := post.Record
+	links, mentions, tags := expandFacets(record.Text, record.Facets)
+	text := expandLinks(record.Text, record.Facets)
+
+	title := titleFromText(text)
+	embedURL := ""
+	if post.Embed != nil && post.Embed.External != nil {
+		embedURL = post.Embed.External.URI
+		if title == "" {
+			title = post.Embed.External.Title
+		}
+	}
+	if title == "" {
+		// Image-only posts have no text
+		title = "Post by @" + post.Author.Handle
+	}
+
+	repostedBy := ""
+	if item.Reason != nil {
+		repostedBy = item.Reason.By.Handle
+	}
+	publishedAt := record.CreatedAt
+	if publishedAt.IsZero() {
+		publishedAt = post.IndexedAt
+	}
+
+	return models.RawNews{
+		SourceType:  "bluesky",
+		SourceID:    id,
+		SourceName:  source.Name,
+		SourceURL:   sourceURL(source),
+		Title:       title,
+		Content:     text,
+		URL:         postURL(post),
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"uri":        post.URI,
+			"cid":        post.CID,
+			"author":     post.Author.Handle,
+			"authorDID":  post.Author.DID,
+			"authorName": post.Author.DisplayName,
+			"likes":      post.LikeCount,
+			"reposts":    post.RepostCount,
+			"replies":    post.ReplyCount,
+			"quotes":     post.QuoteCount,
+			"isReply":    record.Reply != nil,
+			"repostedBy": repostedBy,
+			"langs":      record.Langs,
+			"links":      links,
+			"mentions":   mentions,
+			"tags":       tags,
+			"embedURL":   embedURL,
+		},
+	}
+}
+
+// expandFacets collects the link URIs, mentioned accounts and hashtags of a post
+func expandFacets(text string, facets []Facet) ([]string, []map[string]interface{}, []string) {
+	links := make([]string, 0)
+	mentions := make([]map[string]interface{}, 0)
+	tags := make([]string, 0)
+	for _, facet := range facets {
+		for _, feature := range facet.Features {
+			switch feature.Type {
+			case linkFeature:
+				links = append(links, feature.URI)
+			case mentionFeature:
+				mentions = append(mentions, map[string]interface{}{
+					"did":    feature.DID,
+					"handle": strings.TrimPrefix(facetText(text, facet), "@"),
+				})
+			case tagFeature:
+				tags = append(tags, feature.Tag)
+			}
+		}
+	}
+	return links, mentions, tags
+}
+
+// expandLinks replaces the text of every link facet with its full URI
+func expandLinks(text string, facets []Facet) string {
+	type link struct {
+		start, end int
+		uri        string
+	}
+	var links []link
+	for _, facet := range facets {
+		for _, feature := range facet.Features {
+			if feature.Type == linkFeature && facetText(text, facet) != "" {
+				links = append(links, link{facet.Index.ByteStart, facet.Index.ByteEnd, feature.URI})
+				break
+			}
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].start < links[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, l := range links {
+		if l.start < last {
+			// Overlapping facets are invalid; keep the first
+			continue
+		}
+		b.WriteString(text[last:l.start])
+		b.WriteString(l.uri)
+		last = l.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// facetText returns the text a facet annotates, or "" if its byte range is invalid
+func facetText(text string, facet Facet) string {
+	start, end := facet.Index.ByteStart, facet.Index.ByteEnd
+	if start < 0 || end > len(text) || start >= end {
+		return ""
+	}
+	segment := text[start:end]
+	if !utf8.ValidString(segment) {
+		return ""
+	}
+	return segment
+}
+
+// itemID identifies a feed item; a repost is distinct from the post itself
+func itemID(item FeedItem) string {
+	if item.Reason != nil && item.Reason.By.DID != "" {
+		return item.Post.URI + "#repost:" + item.Reason.By.DID
+	}
+	return item.Post.URI
+}
+
+// splitATURI splits an at:// URI into its authority and the path after it
+func splitATURI(uri string) (string, string) {
+	rest := strings.TrimPrefix(uri, "at://")
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return rest[:i], rest[i:]
+	}
+	return rest, ""
+}
+
+// postURL is the bsky.app page of a post
+func postURL(post Post) string {
+	_, path := splitATURI(post.URI)
+	rkey := path[strings.LastIndexByte(path, '/')+1:]
+	profile := post.Author.Handle
+	if profile == "" {
+		profile = post.Author.DID
+	}
+	return "https://bsky.app/profile/" + profile + "/post/" + rkey
+}
+
+// sourceURL is the bsky.app page of a source
+func sourceURL(source config.BlueskySourceConfig) string {
+	if source.Feed != "" {
+		authority, path := splitATURI(source.Feed)
+		return "https://bsky.app/profile/" + authority + "/feed/" + path[strings.LastIndexByte(path, '/')+1:]
+	}
+	return "https://bsky.app/profile/" + strings.TrimPrefix(source.Actor, "@")
+}
+
+// titleFromText cuts the first line of text to at most titleLength characters at a word boundary
+func titleFromText(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= titleLength {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:titleLength])
+	if i := strings.LastIndexByte(cut, ' '); i > titleLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,164 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const postText = "Go 1.26 is out 🚀 go.dev/blog/go1.26... thanks @alice.bsky.social #golang"
+
+// facet builds a facet over the first occurrence of segment in postText
+func facet(segment, featureJSON string) string {
+	start := strings.Index(postText, segment)
+	return `{"index": {"byteStart": ` + strconv.Itoa(start) + `, "byteEnd": ` + strconv.Itoa(start+len(segment)) + `}, "features": [` + featureJSON + `]}`
+}
+
+func post(rkey, text, facets, extra string) string {
+	return `{"post": {
+		"uri": "at://did:plc:gopher/app.bsky.feed.post/` + rkey + `",
+		"cid": "cid-` + rkey + `",
+		"author": {"did": "did:plc:gopher", "handle": "gopher.bsky.social", "displayName": "Gopher"},
+		"record": {"$type": "app.bsky.feed.post", "text": ` + quote(text) + `, "createdAt": "2026-03-01T10:00:00Z", "langs": ["en"], "facets": [` + facets + `]` + extra + `},
+		"replyCount": 2, "repostCount": 5, "likeCount": 40, "quoteCount": 1,
+		"indexedAt": "2026-03-01T10:00:01Z"
+	}}`
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// stubAppView serves an author feed in pages of one post
+type stubAppView struct {
+	pages    map[string]string
+	requests []string
+}
+
+func (s *stubAppView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r.URL.Path+"?"+r.URL.RawQuery)
+	switch r.URL.Path {
+	case "/xrpc/com.atproto.identity.resolveHandle":
+		if r.URL.Query().Get("handle") != "gopher.bsky.social" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "InvalidRequest", "message": "Unable to resolve handle"}`))
+			return
+		}
+		w.Write([]byte(`{"did": "did:plc:gopher"}`))
+	case "/xrpc/app.bsky.feed.getAuthorFeed", "/xrpc/app.bsky.feed.getFeed":
+		w.Write([]byte(s.pages[r.URL.Query().Get("cursor")]))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestConnector(t *testing.T, serviceURL string, sources ...config.BlueskySourceConfig) *Connector {
+	t.Helper()
+	connector, err := New(config.BlueskyConfig{
+		Enabled:  true,
+		Sources:  sources,
+		Settings: config.BlueskySettings{ServiceURL: serviceURL, Timeout: 5 * time.Second, Limit: 1, MaxPages: 5, ExcludeReplies: true},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNewsAuthorFeed(t *testing.T) {
+	facets := facet("go.dev/blog/go1.26...", `{"$type": "app.bsky.richtext.facet#link", "uri": "https://go.dev/blog/go1.26"}`) + "," +
+		facet("@alice.bsky.social", `{"$type": "app.bsky.richtext.facet#mention", "did": "did:plc:alice"}`) + "," +
+		facet("#golang", `{"$type": "app.bsky.richtext.facet#tag", "tag": "golang"}`)
+	api := &stubAppView{pages: map[string]string{
+		"": `{"cursor": "c1", "feed": [` + post("3k1", postText, facets, "") + `]}`,
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, config.BlueskySourceConfig{Name: "Gopher", Actor: "@gopher.bsky.social"})
+
+	// Without state only the newest page is read
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "/xrpc/com.atproto.identity.resolveHandle?handle=gopher.bsky.social", api.requests[0])
+	assert.Equal(t, "/xrpc/app.bsky.feed.getAuthorFeed?actor=did%3Aplc%3Agopher&filter=posts_no_replies&limit=1", api.requests[1])
+
+	item := news[0]
+	assert.Equal(t, "bluesky", item.SourceType)
+	assert.Equal(t, "at://did:plc:gopher/app.bsky.feed.post/3k1", item.SourceID)
+	assert.Equal(t, "Gopher", item.SourceName)
+	assert.Equal(t, "https://bsky.app/profile/gopher.bsky.social", item.SourceURL)
+	assert.Equal(t, "https://bsky.app/profile/gopher.bsky.social/post/3k1", item.URL)
+	assert.Equal(t, "Go 1.26 is out 🚀 https://go.dev/blog/go1.26 thanks @alice.bsky.social #golang", item.Content)
+	assert.Equal(t, item.Content, item.Title)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), item.PublishedAt)
+	assert.Equal(t, []string{"https://go.dev/blog/go1.26"}, item.Metadata["links"])
+	assert.Equal(t, []map[string]interface{}{{"did": "did:plc:alice", "handle": "alice.bsky.social"}}, item.Metadata["mentions"])
+	assert.Equal(t, []string{"golang"}, item.Metadata["tags"])
+	assert.Equal(t, 40, item.Metadata["likes"])
+
+	// The cursor is followed back to the page holding the post seen before
+	reply := `, "reply": {"parent": {"uri": "at://did:plc:alice/app.bsky.feed.post/1"}, "root": {"uri": "at://did:plc:alice/app.bsky.feed.post/1"}}`
+	api.pages = map[string]string{
+		"":   `{"cursor": "c1", "feed": [` + post("3k3", "Third", "", "") + `]}`,
+		"c1": `{"cursor": "c2", "feed": [` + post("3k2", "A reply", "", reply) + `]}`,
+		"c2": `{"cursor": "c3", "feed": [` + post("3k1", postText, facets, "") + `]}`,
+		"c3": `{"feed": [` + post("3k0", "Older", "", "") + `]}`,
+	}
+	api.requests = nil
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "Third", news[0].Title)
+	assert.Len(t, api.requests, 3, "handle resolution is cached and paging stops at the seen post")
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+}
+
+func TestGetNewsCustomFeed(t *testing.T) {
+	api := &stubAppView{pages: map[string]string{
+		"": `{"feed": [{"post": {
+			"uri": "at://did:plc:bob/app.bsky.feed.post/9",
+			"author": {"did": "did:plc:bob", "handle": "bob.dev"},
+			"record": {"text": "", "createdAt": "2026-03-02T08:00:00Z"},
+			"embed": {"$type": "app.bsky.embed.external#view", "external": {"uri": "https://example.com/post", "title": "Link card title"}}
+		}, "reason": {"$type": "app.bsky.feed.defs#reasonRepost", "by": {"did": "did:plc:gopher", "handle": "gopher.bsky.social"}}}]}`,
+	}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, config.BlueskySourceConfig{
+		Name: "Go feed",
+		Feed: "at://gopher.bsky.social/app.bsky.feed.generator/golang",
+	})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "/xrpc/app.bsky.feed.getFeed?feed=at%3A%2F%2Fdid%3Aplc%3Agopher%2Fapp.bsky.feed.generator%2Fgolang&limit=1", api.requests[1])
+	assert.Equal(t, "at://did:plc:bob/app.bsky.feed.post/9#repost:did:plc:gopher", news[0].SourceID)
+	assert.Equal(t, "https://bsky.app/profile/gopher.bsky.social/feed/golang", news[0].SourceURL)
+	assert.Equal(t, "Link card title", news[0].Title)
+	assert.Equal(t, "gopher.bsky.social", news[0].Metadata["repostedBy"])
+	assert.Equal(t, "https://example.com/post", news[0].Metadata["embedURL"])
+}
+
+func TestGetNewsUnresolvableHandle(t *testing.T) {
+	server := httptest.NewServer(&stubAppView{})
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, config.BlueskySourceConfig{Name: "Nobody", Actor: "nobody.example"})
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InvalidRequest: Unable to resolve handle")
+}
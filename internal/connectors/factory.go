@@ -2,9 +2,20 @@ package connectors
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/connectors/arxiv"
+	"github.com/dzianismalei/infoBro/internal/connectors/bluesky"
+	"github.com/dzianismalei/infoBro/internal/connectors/github"
+	"github.com/dzianismalei/infoBro/internal/connectors/hackernews"
+	"github.com/dzianismalei/infoBro/internal/connectors/imap"
+	"github.com/dzianismalei/infoBro/internal/connectors/mastodon"
 	"github.com/dzianismalei/infoBro/internal/connectors/reddit"
+	"github.com/dzianismalei/infoBro/internal/connectors/scraper"
+	"github.com/dzianismalei/infoBro/internal/connectors/stackexchange"
+	"github.com/dzianismalei/infoBro/internal/connectors/webhook"
+	"github.com/dzianismalei/infoBro/internal/connectors/youtube"
 	"github.com/dzianismalei/infoBro/internal/models"
 )
 
@@ -12,13 +23,85 @@ import (
 type Factory struct {
 	config         *config.ConnectorsConfig
 	stateRepository models.ChannelStateRepository
+	// transport is shared by the HTTP based connectors, nil uses http.DefaultTransport
+	transport http.RoundTripper
 }
 
 // NewFactory creates a new connector factory
-func NewFactory(cfg *config.ConnectorsConfig, stateRepo models.ChannelStateRepository) *Factory {
+func NewFactory(cfg *config.ConnectorsConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) *Factory {
 	return &Factory{
 		config:         cfg,
 		stateRepository: stateRepo,
+		transport:      transport,
+	}
+}
+
+// EnabledSections returns the config section of every enabled connector keyed by connector name
+func EnabledSections(cfg *config.ConnectorsConfig) map[string]interface{} {
+	sections := make(map[string]interface{})
+	if cfg.Reddit.Enabled {
+		sections["reddit"] = cfg.Reddit
+	}
+	if cfg.HackerNews.Enabled {
+		sections["hackernews"] = cfg.HackerNews
+	}
+	if cfg.Scraper.Enabled {
+		sections["scraper"] = cfg.Scraper
+	}
+	if cfg.GitHub.Enabled {
+		sections["github"] = cfg.GitHub
+	}
+	if cfg.Mastodon.Enabled {
+		sections["mastodon"] = cfg.Mastodon
+	}
+	if cfg.Webhook.Enabled {
+		sections["webhook"] = cfg.Webhook
+	}
+	if cfg.IMAP.Enabled {
+		sections["imap"] = cfg.IMAP
+	}
+	if cfg.YouTube.Enabled {
+		sections["youtube"] = cfg.YouTube
+	}
+	if cfg.Arxiv.Enabled {
+		sections["arxiv"] = cfg.Arxiv
+	}
+	if cfg.StackExchange.Enabled {
+		sections["stackexchange"] = cfg.StackExchange
+	}
+	if cfg.Bluesky.Enabled {
+		sections["bluesky"] = cfg.Bluesky
+	}
+	return sections
+}
+
+// CreateConnector creates the named connector from its config section
+func (f *Factory) CreateConnector(name string) (models.NewsConnector, error) {
+	switch name {
+	case "reddit":
+		return f.CreateRedditConnector()
+	case "hackernews":
+		return f.CreateHackerNewsConnector()
+	case "scraper":
+		return f.CreateScraperConnector()
+	case "github":
+		return f.CreateGitHubConnector()
+	case "mastodon":
+		return f.CreateMastodonConnector()
+	case "webhook":
+		return f.CreateWebhookConnector()
+	case "imap":
+		return f.CreateIMAPConnector()
+	case "youtube":
+		return f.CreateYouTubeConnector()
+	case "arxiv":
+		return f.CreateArxivConnector()
+	case "stackexchange":
+		return f.CreateStackExchangeConnector()
+	case "bluesky":
+		return f.CreateBlueskyConnector()
+	default:
+		return nil, fmt.Errorf("unknown connector type %q", name)
 	}
 }
 
@@ -28,23 +111,70 @@ func (f *Factory) CreateRedditConnector() (models.NewsConnector, error) {
 		return nil, fmt.Errorf("reddit connector is disabled in config")
 	}
 	
-	return reddit.New(f.config.Reddit, f.stateRepository)
+	return reddit.New(f.config.Reddit, f.stateRepository, f.transport)
+}
+
+// CreateHackerNewsConnector creates a Hacker News connector
+func (f *Factory) CreateHackerNewsConnector() (models.NewsConnector, error) {
+	return hackernews.New(f.config.HackerNews, f.stateRepository, f.transport)
+}
+
+// CreateScraperConnector creates a web scraping connector
+func (f *Factory) CreateScraperConnector() (models.NewsConnector, error) {
+	return scraper.New(f.config.Scraper, f.stateRepository, f.transport)
+}
+
+// CreateGitHubConnector creates a GitHub releases connector
+func (f *Factory) CreateGitHubConnector() (models.NewsConnector, error) {
+	return github.New(f.config.GitHub, f.stateRepository, f.transport)
+}
+
+// CreateMastodonConnector creates a Mastodon connector
+func (f *Factory) CreateMastodonConnector() (models.NewsConnector, error) {
+	return mastodon.New(f.config.Mastodon, f.stateRepository, f.transport)
+}
+
+// CreateWebhookConnector creates the push ingestion connector
+func (f *Factory) CreateWebhookConnector() (models.NewsConnector, error) {
+	return webhook.New(f.config.Webhook, f.stateRepository)
+}
+
+// CreateIMAPConnector creates an IMAP newsletter connector
+func (f *Factory) CreateIMAPConnector() (models.NewsConnector, error) {
+	return imap.New(f.config.IMAP, f.stateRepository)
+}
+
+// CreateYouTubeConnector creates a YouTube channel and playlist connector
+func (f *Factory) CreateYouTubeConnector() (models.NewsConnector, error) {
+	return youtube.New(f.config.YouTube, f.stateRepository, f.transport)
+}
+
+// CreateArxivConnector creates an arXiv query connector
+func (f *Factory) CreateArxivConnector() (models.NewsConnector, error) {
+	return arxiv.New(f.config.Arxiv, f.stateRepository, f.transport)
+}
+
+// CreateStackExchangeConnector creates a Stack Exchange tagged questions connector
+func (f *Factory) CreateStackExchangeConnector() (models.NewsConnector, error) {
+	return stackexchange.New(f.config.StackExchange, f.stateRepository, f.transport)
+}
+
+// CreateBlueskyConnector creates a Bluesky author feed and custom feed connector
+func (f *Factory) CreateBlueskyConnector() (models.NewsConnector, error) {
+	return bluesky.New(f.config.Bluesky, f.stateRepository, f.transport)
 }
 
 // CreateAllConnectors creates all enabled connectors
 func (f *Factory) CreateAllConnectors() (map[string]models.NewsConnector, error) {
 	connectors := make(map[string]models.NewsConnector)
 	
-	// Create Reddit connector if enabled
-	if f.config.Reddit.Enabled {
-		connector, err := f.CreateRedditConnector()
+	for name := range EnabledSections(f.config) {
+		connector, err := f.CreateConnector(name)
 		if err != nil {
-			return connectors, fmt.Errorf("failed to create Reddit connector: %w", err)
+			return connectors, fmt.Errorf("failed to create %s connector: %w", name, err)
 		}
-		connectors["reddit"] = connector
+		connectors[name] = connector
 	}
 	
-	// Here you would add creation of other connector types (Telegram, RSS, etc.)
-	
 	return connectors, nil
-}
\ No newline at end of file
+}
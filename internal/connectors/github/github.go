@@ -0,0 +1,376 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// defaultUserAgent is sent when the config does not set one; GitHub rejects requests without it
+const defaultUserAgent = "NewsAggregator/1.0"
+
+// minSeenReleases is the lower bound of remembered release IDs per repository
+const minSeenReleases = 200
+
+// Release is a release returned by the REST releases API
+type Release struct {
+	ID          int64     `json:"id"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	BodyHTML    string    `json:"body_html"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	CreatedAt   time.Time `json:"created_at"`
+	PublishedAt time.Time `json:"published_at"`
+	Author      struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name          string `json:"name"`
+	ContentType   string `json:"content_type"`
+	Size          int64  `json:"size"`
+	DownloadCount int    `json:"download_count"`
+	DownloadURL   string `json:"browser_download_url"`
+}
+
+// atomFeed is the releases.atom feed of a repository
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomEntry is a release or tag in releases.atom
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Updated string `xml:"updated"`
+	Title   string `xml:"title"`
+	Link    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Content string `xml:"content"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// Connector implements NewsConnector for GitHub releases
+type Connector struct {
+	client          *http.Client
+	mode            string
+	baseURL         string
+	feedURL         string
+	token           string
+	userAgent       string
+	perPage         int
+	skipPrereleases bool
+	repositories    []string
+	stateRepository models.ChannelStateRepository
+}
+
+// New creates a new GitHub releases connector
+func New(cfg config.GitHubConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("github connector is disabled in config")
+	}
+
+	userAgent := cfg.Settings.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		mode:            cfg.Settings.Mode,
+		baseURL:         strings.TrimRight(cfg.Settings.BaseURL, "/"),
+		feedURL:         strings.TrimRight(cfg.Settings.FeedURL, "/"),
+		token:           cfg.Settings.Token.Value(),
+		userAgent:       userAgent,
+		perPage:         cfg.Settings.PerPage,
+		skipPrereleases: cfg.Settings.SkipPrereleases,
+		repositories:    cfg.Repositories,
+		stateRepository: stateRepo,
+	}, nil
+}
+
+// GetNews returns the releases of every watched repository that have not been seen before.
+// A failing repository is logged and skipped; an error is returned only if every repository failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, repo := range c.repositories {
+		news, err := c.GetSourceNews(ctx, repo)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch releases of %s: %v", repo, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to fetch releases of %s: %w", repo, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.repositories) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured repositories
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.repositories))
+	for _, repo := range c.repositories {
+		names = append(names, repo)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named repository only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named repository with the state that marks
+// it as seen, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, repo := range c.repositories {
+		if repo == name {
+			return c.repositoryNews(ctx, repo)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// repositoryNews fetches the recent releases of repo and keeps the unseen ones
+func (c *Connector) repositoryNews(ctx context.Context, repo string) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "github:"+repo)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Unchanged listings are answered with 304 Not Modified, which GitHub does not
+	// count against the API rate limit
+	ctx = httpfetch.WithState(ctx, state)
+	fetchedAt := time.Now()
+	var news []models.RawNews
+	if c.mode == "atom" {
+		news, err = c.fetchFeed(ctx, repo, fetchedAt)
+	} else {
+		news, err = c.fetchReleases(ctx, repo, fetchedAt)
+	}
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	seen := state.SeenSet()
+	var fresh []models.RawNews
+	var ids []string
+	for _, item := range news {
+		if seen[item.SourceID] {
+			continue
+		}
+		ids = append(ids, item.SourceID)
+		if c.skipPrereleases && item.Metadata["prerelease"] == true {
+			continue
+		}
+		fresh = append(fresh, item)
+	}
+
+	state.MarkSeen(c.seenLimit(), ids...)
+	if len(news) > 0 {
+		state.LastMessageID = news[0].SourceID
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(fresh)
+	return models.NewsBatch{News: fresh, State: state}, nil
+}
+
+// fetchReleases reads the most recent releases through the REST API, newest first
+func (c *Connector) fetchReleases(ctx context.Context, repo string, fetchedAt time.Time) ([]models.RawNews, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/releases?per_page=%d", c.baseURL, repo, c.perPage)
+	resp, err := c.get(ctx, endpoint, "application/vnd.github.html+json")
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+
+	var news []models.RawNews
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		news = append(news, c.releaseToRawNews(repo, release, fetchedAt))
+	}
+	return news, nil
+}
+
+// fetchFeed reads releases.atom, which also lists tags without a release, newest first
+func (c *Connector) fetchFeed(ctx context.Context, repo string, fetchedAt time.Time) ([]models.RawNews, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("%s/%s/releases.atom", c.feedURL, repo), "application/atom+xml")
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var feed atomFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to decode releases feed: %w", err)
+	}
+
+	news := make([]models.RawNews, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		news = append(news, c.entryToRawNews(repo, entry, fetchedAt))
+	}
+	if len(news) > c.perPage {
+		news = news[:c.perPage]
+	}
+	return news, nil
+}
+
+// get performs a GET request and checks for a 200 response. It returns a nil
+// response if the resource is not modified since the last request.
+func (c *Connector) get(ctx context.Context, endpoint, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" && c.mode == "api" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return nil, fmt.Errorf("rate limit exceeded, resets at %s", resp.Header.Get("X-RateLimit-Reset"))
+		}
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// seenLimit is how many release IDs are remembered per repository
+func (c *Connector) seenLimit() int {
+	limit := 4 * c.perPage
+	if limit < minSeenReleases {
+		limit = minSeenReleases
+	}
+	return limit
+}
+
+// releaseToRawNews converts an API release to the standard news format
+func (c *Connector) releaseToRawNews(repo string, release Release, fetchedAt time.Time) models.RawNews {
+	title := release.Name
+	if title == "" {
+		title = release.TagName
+	}
+	publishedAt := release.PublishedAt
+	if publishedAt.IsZero() {
+		publishedAt = release.CreatedAt
+	}
+
+	assets := make([]map[string]interface{}, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		assets = append(assets, map[string]interface{}{
+			"name":          asset.Name,
+			"contentType":   asset.ContentType,
+			"size":          asset.Size,
+			"downloadCount": asset.DownloadCount,
+			"url":           asset.DownloadURL,
+		})
+	}
+
+	return models.RawNews{
+		SourceType:  "github",
+		SourceID:    strconv.FormatInt(release.ID, 10),
+		SourceName:  repo,
+		SourceURL:   "https://github.com/" + repo,
+		Title:       fmt.Sprintf("%s %s", repo, title),
+		Content:     release.BodyHTML,
+		URL:         release.HTMLURL,
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"repository": repo,
+			"tag":        release.TagName,
+			"prerelease": release.Prerelease,
+			"author":     release.Author.Login,
+			"assets":     assets,
+		},
+	}
+}
+
+// entryToRawNews converts a releases.atom entry to the standard news format. The feed
+// does not say whether a release is a prerelease, and has no assets.
+func (c *Connector) entryToRawNews(repo string, entry atomEntry, fetchedAt time.Time) models.RawNews {
+	// Links look like https://github.com/<owner>/<repo>/releases/tag/<tag>
+	tag := path.Base(entry.Link.Href)
+	if unescaped, err := url.PathUnescape(tag); err == nil {
+		tag = unescaped
+	}
+	publishedAt, err := time.Parse(time.RFC3339, entry.Updated)
+	if err != nil {
+		publishedAt = fetchedAt
+	}
+
+	return models.RawNews{
+		SourceType:  "github",
+		SourceID:    "tag:" + tag,
+		SourceName:  repo,
+		SourceURL:   "https://github.com/" + repo,
+		Title:       fmt.Sprintf("%s %s", repo, strings.TrimSpace(entry.Title)),
+		Content:     strings.TrimSpace(entry.Content),
+		URL:         entry.Link.Href,
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"repository": repo,
+			"tag":        tag,
+			"prerelease": false,
+			"author":     entry.Author.Name,
+			"assets":     []map[string]interface{}{},
+		},
+	}
+}
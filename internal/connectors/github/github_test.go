@@ -0,0 +1,180 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const releasesJSON = `[
+  {"id": 3, "tag_name": "v1.3.0-rc.1", "name": "", "html_url": "https://github.com/acme/tool/releases/tag/v1.3.0-rc.1",
+   "body_html": "<p>Release candidate</p>", "prerelease": true, "published_at": "2026-03-03T10:00:00Z", "author": {"login": "alice"}, "assets": []},
+  {"id": 2, "tag_name": "v1.2.0", "name": "Tool 1.2", "html_url": "https://github.com/acme/tool/releases/tag/v1.2.0",
+   "body_html": "<ul><li>Faster</li></ul>", "prerelease": false, "published_at": "2026-03-01T10:00:00Z", "author": {"login": "bob"},
+   "assets": [{"name": "tool_linux_amd64.tar.gz", "content_type": "application/gzip", "size": 1024, "download_count": 7,
+               "browser_download_url": "https://github.com/acme/tool/releases/download/v1.2.0/tool_linux_amd64.tar.gz"}]},
+  {"id": 1, "tag_name": "v1.1.0", "draft": true}
+]`
+
+const releasesAtom = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>tag:github.com,2008:Repository/1/v1.2.0</id>
+    <updated>2026-03-01T10:00:00Z</updated>
+    <link rel="alternate" type="text/html" href="https://github.com/acme/tool/releases/tag/v1.2.0"/>
+    <title>Tool 1.2</title>
+    <content type="html">&lt;ul&gt;&lt;li&gt;Faster&lt;/li&gt;&lt;/ul&gt;</content>
+    <author><name>bob</name></author>
+  </entry>
+</feed>`
+
+func newTestConnector(t *testing.T, settings config.GitHubSettings) *Connector {
+	t.Helper()
+	settings.Timeout = 5 * time.Second
+	settings.PerPage = 10
+	connector, err := New(config.GitHubConfig{
+		Enabled:      true,
+		Repositories: []string{"acme/tool"},
+		Settings:     settings,
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNewsAPI(t *testing.T) {
+	var auth, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/tool/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		auth, accept = r.Header.Get("Authorization"), r.Header.Get("Accept")
+		w.Write([]byte(releasesJSON))
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.GitHubSettings{
+		Mode:    "api",
+		BaseURL: server.URL,
+		Token:   config.Secret("token123"),
+	})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2, "drafts are skipped")
+	assert.Equal(t, "Bearer token123", auth)
+	assert.Equal(t, "application/vnd.github.html+json", accept)
+
+	assert.Equal(t, "acme/tool v1.3.0-rc.1", news[0].Title)
+	assert.Equal(t, true, news[0].Metadata["prerelease"])
+
+	release := news[1]
+	assert.Equal(t, "github", release.SourceType)
+	assert.Equal(t, "2", release.SourceID)
+	assert.Equal(t, "acme/tool Tool 1.2", release.Title)
+	assert.Equal(t, "<ul><li>Faster</li></ul>", release.Content)
+	assert.Equal(t, "https://github.com/acme/tool/releases/tag/v1.2.0", release.URL)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), release.PublishedAt)
+	assert.Equal(t, "v1.2.0", release.Metadata["tag"])
+	assert.Equal(t, false, release.Metadata["prerelease"])
+	assets := release.Metadata["assets"].([]map[string]interface{})
+	require.Len(t, assets, 1)
+	assert.Equal(t, "tool_linux_amd64.tar.gz", assets[0]["name"])
+	assert.Equal(t, int64(1024), assets[0]["size"])
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+}
+
+func TestGetSourceBatchLeavesStateUnsaved(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(releasesJSON))
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.GitHubSettings{Mode: "api", BaseURL: server.URL})
+	ctx := context.Background()
+
+	batch, err := connector.GetSourceBatch(ctx, "acme/tool")
+	require.NoError(t, err)
+	require.Len(t, batch.News, 2)
+	assert.Equal(t, "github:acme/tool", batch.State.ChannelID)
+
+	batch, err = connector.GetSourceBatch(ctx, "acme/tool")
+	require.NoError(t, err)
+	assert.Len(t, batch.News, 2, "news stays unseen until the state is saved")
+
+	require.NoError(t, connector.stateRepository.UpdateChannelState(ctx, batch.State))
+	batch, err = connector.GetSourceBatch(ctx, "acme/tool")
+	require.NoError(t, err)
+	assert.Empty(t, batch.News)
+
+	_, err = connector.GetSourceBatch(ctx, "acme/other")
+	assert.Error(t, err)
+}
+
+func TestGetNewsSkipPrereleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(releasesJSON))
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.GitHubSettings{Mode: "api", BaseURL: server.URL, SkipPrereleases: true})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "2", news[0].SourceID)
+}
+
+func TestGetNewsAtom(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/acme/tool/releases.atom" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(releasesAtom))
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.GitHubSettings{
+		Mode:    "atom",
+		FeedURL: server.URL,
+		Token:   config.Secret("token123"),
+	})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Empty(t, auth, "the token is only sent to the API")
+	assert.Equal(t, "tag:v1.2.0", news[0].SourceID)
+	assert.Equal(t, "acme/tool Tool 1.2", news[0].Title)
+	assert.Equal(t, "<ul><li>Faster</li></ul>", news[0].Content)
+	assert.Equal(t, "v1.2.0", news[0].Metadata["tag"])
+	assert.Equal(t, "bob", news[0].Metadata["author"])
+}
+
+func TestGetNewsRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1772539200")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.GitHubSettings{Mode: "api", BaseURL: server.URL})
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit exceeded")
+}
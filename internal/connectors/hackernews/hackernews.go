@@ -0,0 +1,309 @@
+package hackernews
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// channelID is the ChannelState key shared by all lists, so a story that
+// appears in several lists is emitted only once
+const channelID = "hackernews"
+
+// minSeenIDs is the lower bound of remembered story IDs
+const minSeenIDs = 2000
+
+// defaultBatchSize is the number of items per streamed batch when the config does not set one
+const defaultBatchSize = 50
+
+// itemURL is the discussion page of an item
+const itemURL = "https://news.ycombinator.com/item?id=%d"
+
+// Item is a story, job or poll returned by the Firebase item API
+type Item struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Text        string `json:"text"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Deleted     bool   `json:"deleted"`
+	Dead        bool   `json:"dead"`
+}
+
+// Connector implements NewsConnector for Hacker News
+type Connector struct {
+	client          *http.Client
+	baseURL         string
+	userAgent       string
+	lists           []string
+	limit           int
+	workers         int
+	batchSize       int
+	stateRepository models.ChannelStateRepository
+}
+
+// New creates a new Hacker News connector
+func New(cfg config.HackerNewsConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("hackernews connector is disabled in config")
+	}
+
+	batchSize := cfg.Settings.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		baseURL:         strings.TrimRight(cfg.Settings.BaseURL, "/"),
+		userAgent:       cfg.Settings.UserAgent,
+		lists:           cfg.Lists,
+		limit:           cfg.Settings.Limit,
+		workers:         cfg.Settings.Workers,
+		batchSize:       batchSize,
+		stateRepository: stateRepo,
+	}, nil
+}
+
+// GetNews retrieves the stories of every configured list that have not been seen before
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	err := c.StreamNews(ctx, func(ctx context.Context, batch models.NewsBatch) error {
+		if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+			return fmt.Errorf("failed to update hackernews state: %w", err)
+		}
+		allNews = append(allNews, batch.News...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allNews, nil
+}
+
+// StreamNews fetches the unseen stories of every configured list in batches of
+// c.batchSize items. Each batch carries the state that marks its items as seen.
+func (c *Connector) StreamNews(ctx context.Context, emit func(context.Context, models.NewsBatch) error) error {
+	state, err := c.stateRepository.GetChannelState(ctx, channelID)
+	if err != nil {
+		return fmt.Errorf("failed to load hackernews state: %w", err)
+	}
+	seen := state.SeenSet()
+
+	// Collect unseen IDs across all lists, remembering the first list each came from
+	var ids []int
+	listOf := make(map[int]string)
+	for _, list := range c.lists {
+		listIDs, err := c.fetchList(ctx, list)
+		if err != nil {
+			return err
+		}
+		if len(listIDs) > c.limit {
+			listIDs = listIDs[:c.limit]
+		}
+		for _, id := range listIDs {
+			if _, queued := listOf[id]; queued || seen[strconv.Itoa(id)] {
+				continue
+			}
+			listOf[id] = list
+			ids = append(ids, id)
+		}
+	}
+
+	lastID, _ := strconv.Atoi(state.LastMessageID)
+	// A run without unseen stories still emits one empty batch to record its time
+	for start := 0; ; start += c.batchSize {
+		end := min(start+c.batchSize, len(ids))
+		items := c.fetchItems(ctx, ids[start:end])
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fetchedAt := time.Now()
+		var news []models.RawNews
+		var emitted []string
+		for _, id := range ids[start:end] {
+			item, ok := items[id]
+			if !ok {
+				// Fetch failed; leave it unseen so the next run retries it
+				continue
+			}
+			emitted = append(emitted, strconv.Itoa(id))
+			if id > lastID {
+				lastID = id
+			}
+			if item == nil || item.Deleted || item.Dead || item.Title == "" {
+				continue
+			}
+			news = append(news, c.toRawNews(item, listOf[id], fetchedAt))
+		}
+
+		state.MarkSeen(c.seenLimit(), emitted...)
+		state.LastMessageID = strconv.Itoa(lastID)
+		state.LastUpdateTime = fetchedAt
+		state.ProcessedMessages += len(news)
+		if err := emit(ctx, models.NewsBatch{News: news, State: state}); err != nil {
+			return err
+		}
+
+		if end == len(ids) {
+			return nil
+		}
+	}
+}
+
+// seenLimit is how many story IDs are remembered; enough to cover several full reads of every list
+func (c *Connector) seenLimit() int {
+	limit := 4 * c.limit * len(c.lists)
+	if limit < minSeenIDs {
+		limit = minSeenIDs
+	}
+	return limit
+}
+
+// fetchList returns the item IDs of a story list such as "top" or "ask"
+func (c *Connector) fetchList(ctx context.Context, list string) ([]int, error) {
+	var ids []int
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/%sstories.json", c.baseURL, list), &ids); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s stories: %w", list, err)
+	}
+	return ids, nil
+}
+
+// fetchItems fetches items concurrently with at most c.workers requests in flight.
+// The first worker uses the worker pool slot of the run; the others take a slot of
+// the pool of ctx, if any, for every item. Items that fail to load are logged and left
+// out of the result; items that do not exist map to nil.
+func (c *Connector) fetchItems(ctx context.Context, ids []int) map[int]*Item {
+	jobs := make(chan int)
+	items := make(map[int]*Item, len(ids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	fetch := func(id int) {
+		var item Item
+		if err := c.getJSON(ctx, fmt.Sprintf("%s/item/%d.json", c.baseURL, id), &item); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to fetch Hacker News item %d: %v", id, err)
+			}
+			return
+		}
+		mu.Lock()
+		if item.ID == 0 {
+			// The API returns null for items that do not exist
+			items[id] = nil
+		} else {
+			items[id] = &item
+		}
+		mu.Unlock()
+	}
+
+	// Extra workers wait for a slot only while there are items left to hand out
+	pool := models.WorkerPoolFrom(ctx)
+	acquireCtx, stopAcquiring := context.WithCancel(ctx)
+	defer stopAcquiring()
+
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func(pooled bool) {
+			defer wg.Done()
+			for {
+				if pooled {
+					if err := pool.Acquire(acquireCtx); err != nil {
+						return
+					}
+				}
+				id, ok := <-jobs
+				if ok {
+					fetch(id)
+				}
+				if pooled {
+					pool.Release()
+				}
+				if !ok {
+					return
+				}
+			}
+		}(i > 0 && pool != nil)
+	}
+
+	for _, id := range ids {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	stopAcquiring()
+	wg.Wait()
+
+	return items
+}
+
+// getJSON performs a GET request and decodes the JSON response into out
+func (c *Connector) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// toRawNews converts an item to the standard news format
+func (c *Connector) toRawNews(item *Item, list string, fetchedAt time.Time) models.RawNews {
+	discussionURL := fmt.Sprintf(itemURL, item.ID)
+	url := item.URL
+	if url == "" {
+		// Ask HN, jobs and other text posts have no outbound link
+		url = discussionURL
+	}
+
+	return models.RawNews{
+		SourceType:  "hackernews",
+		SourceID:    strconv.Itoa(item.ID),
+		SourceName:  "Hacker News",
+		SourceURL:   "https://news.ycombinator.com",
+		Title:       item.Title,
+		Content:     item.Text,
+		URL:         url,
+		PublishedAt: time.Unix(item.Time, 0),
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"score":       item.Score,
+			"descendants": item.Descendants,
+			"by":          item.By,
+			"type":        item.Type,
+			"list":        list,
+			"commentsURL": discussionURL,
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package hackernews
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeAPI serves story lists and items the way the Firebase API does
+func newFakeAPI(t *testing.T, lists map[string][]int, items map[int]Item) (*httptest.Server, *int32) {
+	t.Helper()
+	var itemRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v0/")
+		switch {
+		case strings.HasSuffix(path, "stories.json"):
+			ids, ok := lists[strings.TrimSuffix(path, "stories.json")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(ids)
+		case strings.HasPrefix(path, "item/"):
+			atomic.AddInt32(&itemRequests, 1)
+			var id int
+			fmt.Sscanf(path, "item/%d.json", &id)
+			item, ok := items[id]
+			if !ok {
+				w.Write([]byte("null"))
+				return
+			}
+			json.NewEncoder(w).Encode(item)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &itemRequests
+}
+
+func newTestConnector(t *testing.T, baseURL string, lists ...string) *Connector {
+	t.Helper()
+	connector, err := New(config.HackerNewsConfig{
+		Enabled: true,
+		Lists:   lists,
+		Settings: config.HackerNewsSettings{
+			BaseURL: baseURL + "/v0",
+			Timeout: 5 * time.Second,
+			Limit:   10,
+			Workers: 3,
+		},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNews(t *testing.T) {
+	published := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server, itemRequests := newFakeAPI(t,
+		map[string][]int{
+			"top": {101, 102, 103, 104},
+			"ask": {105, 102},
+		},
+		map[int]Item{
+			101: {ID: 101, Type: "story", By: "alice", Time: published.Unix(), Title: "Go 1.26 released", URL: "https://go.dev/blog/go1.26", Score: 512, Descendants: 128},
+			102: {ID: 102, Type: "story", By: "bob", Time: published.Unix(), Title: "Show HN: a Go linter", URL: "https://example.com/linter", Score: 40},
+			103: {ID: 103, Type: "story", Deleted: true},
+			105: {ID: 105, Type: "story", By: "carol", Time: published.Unix(), Title: "Ask HN: Favourite Go books?", Text: "<p>Looking for recommendations</p>", Score: 12, Descendants: 30},
+			// 104 does not exist and is returned as null
+		},
+	)
+	connector := newTestConnector(t, server.URL, "top", "ask")
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 3)
+
+	assert.Equal(t, "hackernews", news[0].SourceType)
+	assert.Equal(t, "101", news[0].SourceID)
+	assert.Equal(t, "Go 1.26 released", news[0].Title)
+	assert.Equal(t, "https://go.dev/blog/go1.26", news[0].URL)
+	assert.Equal(t, published, news[0].PublishedAt.UTC())
+	assert.Equal(t, 512, news[0].Metadata["score"])
+	assert.Equal(t, 128, news[0].Metadata["descendants"])
+	assert.Equal(t, "alice", news[0].Metadata["by"])
+	assert.Equal(t, "story", news[0].Metadata["type"])
+	assert.Equal(t, "top", news[0].Metadata["list"])
+
+	// Item 102 is in both lists but emitted once, attributed to the first list
+	assert.Equal(t, "102", news[1].SourceID)
+	assert.Equal(t, "top", news[1].Metadata["list"])
+
+	// Text posts link to their discussion page
+	assert.Equal(t, "105", news[2].SourceID)
+	assert.Equal(t, "https://news.ycombinator.com/item?id=105", news[2].URL)
+	assert.Equal(t, "<p>Looking for recommendations</p>", news[2].Content)
+	assert.Equal(t, "ask", news[2].Metadata["list"])
+
+	assert.EqualValues(t, 5, atomic.LoadInt32(itemRequests))
+
+	// A second run skips everything already seen
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+	assert.EqualValues(t, 5, atomic.LoadInt32(itemRequests))
+}
+
+func TestGetNewsListError(t *testing.T) {
+	server, _ := newFakeAPI(t, map[string][]int{}, nil)
+	connector := newTestConnector(t, server.URL, "best")
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to fetch best stories")
+}
+
+func TestStreamNewsCheckpointsEachBatch(t *testing.T) {
+	items := map[int]Item{
+		201: {ID: 201, Type: "story", Title: "First"},
+		202: {ID: 202, Type: "story", Title: "Second"},
+		203: {ID: 203, Type: "story", Title: "Third"},
+	}
+	server, itemRequests := newFakeAPI(t, map[string][]int{"new": {201, 202, 203}}, items)
+	stateRepo := storage.NewMemoryStateRepository()
+	connector, err := New(config.HackerNewsConfig{
+		Enabled: true,
+		Lists:   []string{"new"},
+		Settings: config.HackerNewsSettings{
+			BaseURL:   server.URL + "/v0",
+			Timeout:   5 * time.Second,
+			Limit:     10,
+			Workers:   1,
+			BatchSize: 2,
+		},
+	}, stateRepo, nil)
+	require.NoError(t, err)
+
+	// The run stops when storing the second batch fails; the first one is checkpointed
+	var batches [][]string
+	err = connector.StreamNews(context.Background(), func(ctx context.Context, batch models.NewsBatch) error {
+		var ids []string
+		for _, item := range batch.News {
+			ids = append(ids, item.SourceID)
+		}
+		batches = append(batches, ids)
+		if len(batches) == 2 {
+			return errors.New("storage is down")
+		}
+		return stateRepo.UpdateChannelState(ctx, batch.State)
+	})
+	require.Error(t, err)
+	assert.Equal(t, "storage is down", err.Error())
+	assert.Equal(t, [][]string{{"201", "202"}, {"203"}}, batches)
+
+	state, err := stateRepo.GetChannelState(context.Background(), channelID)
+	require.NoError(t, err)
+	assert.Equal(t, "202", state.LastMessageID)
+	assert.Equal(t, 2, state.ProcessedMessages)
+
+	// The next run fetches only the item of the lost batch
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "203", news[0].SourceID)
+	assert.EqualValues(t, 4, atomic.LoadInt32(itemRequests))
+}
+
+// chanPool is a worker pool of cap(slots) slots
+type chanPool struct {
+	slots chan struct{}
+}
+
+func (p *chanPool) Acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *chanPool) Release() {
+	<-p.slots
+}
+
+func TestFetchItemsTakesPoolSlots(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var id int
+		fmt.Sscanf(r.URL.Path, "/v0/item/%d.json", &id)
+		json.NewEncoder(w).Encode(Item{ID: id, Type: "story", Title: "Story"})
+	}))
+	defer server.Close()
+	connector := newTestConnector(t, server.URL, "top")
+	ids := []int{1, 2, 3, 4, 5, 6}
+
+	// The run holds one slot and the pool has one more free, so two fetches run at once
+	ctx := models.WithWorkerPool(context.Background(), &chanPool{slots: make(chan struct{}, 1)})
+	items := connector.fetchItems(ctx, ids)
+	assert.Len(t, items, len(ids))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	// With every other slot taken, the slot of the run fetches all items
+	atomic.StoreInt32(&maxInFlight, 0)
+	ctx = models.WithWorkerPool(context.Background(), &chanPool{slots: make(chan struct{})})
+	items = connector.fetchItems(ctx, ids)
+	assert.Len(t, items, len(ids))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxInFlight))
+
+	// Without a pool every worker fetches
+	atomic.StoreInt32(&maxInFlight, 0)
+	items = connector.fetchItems(context.Background(), ids)
+	assert.Len(t, items, len(ids))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxInFlight))
+}
@@ -0,0 +1,460 @@
+package imap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	goimap "github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset" // decode non-UTF-8 newsletters
+	"github.com/emersion/go-message/mail"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/htmltext"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// source is a configured newsletter with its split rules compiled
+type source struct {
+	config.IMAPSourceConfig
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// message is the part of a fetched email the connector uses
+type message struct {
+	uid       uint32
+	messageID string
+	subject   string
+	from      string
+	date      time.Time
+	html      string
+	text      string
+}
+
+// Connector implements NewsConnector for newsletters in an IMAP mailbox
+type Connector struct {
+	address         string
+	useTLS          bool
+	username        string
+	password        string
+	timeout         time.Duration
+	maxMessages     int
+	sources         []source
+	stateRepository models.ChannelStateRepository
+}
+
+// New creates a new IMAP newsletter connector
+func New(cfg config.IMAPConfig, stateRepo models.ChannelStateRepository) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("imap connector is disabled in config")
+	}
+
+	sources := make([]source, len(cfg.Sources))
+	for i, sourceConfig := range cfg.Sources {
+		sources[i].IMAPSourceConfig = sourceConfig
+		for _, pattern := range sourceConfig.Split.IncludeURLs {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include_urls pattern of %s: %w", sourceConfig.Name, err)
+			}
+			sources[i].include = append(sources[i].include, re)
+		}
+		for _, pattern := range sourceConfig.Split.ExcludeURLs {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude_urls pattern of %s: %w", sourceConfig.Name, err)
+			}
+			sources[i].exclude = append(sources[i].exclude, re)
+		}
+	}
+
+	return &Connector{
+		address:         cfg.Server.Address,
+		useTLS:          cfg.Server.TLS,
+		username:        cfg.Server.Username.Value(),
+		password:        cfg.Server.Password.Value(),
+		timeout:         cfg.Settings.Timeout,
+		maxMessages:     cfg.Settings.MaxMessages,
+		sources:         sources,
+		stateRepository: stateRepo,
+	}, nil
+}
+
+// GetNews logs into the mailbox and returns the news in messages that arrived since the
+// previous run, saving the cursor of every source it read
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	err := c.StreamNews(ctx, func(ctx context.Context, batch models.NewsBatch) error {
+		if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+			return fmt.Errorf("failed to update state of %s: %w", batch.State.ChannelID, err)
+		}
+		allNews = append(allNews, batch.News...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allNews, nil
+}
+
+// StreamNews logs into the mailbox and emits the news of every source in a batch with
+// the state that moves its cursor past the messages read. A failing source is logged
+// and skipped; an error is returned only if every source failed.
+func (c *Connector) StreamNews(ctx context.Context, emit func(context.Context, models.NewsBatch) error) error {
+	imapClient, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer imapClient.Logout()
+
+	// go-imap has no context support; closing the connection aborts pending commands
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			imapClient.Terminate()
+		case <-done:
+		}
+	}()
+
+	var firstErr error
+	failed := 0
+
+	for _, src := range c.sources {
+		batch, err := c.sourceNews(ctx, imapClient, src)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to read newsletter %s: %v", src.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to read newsletter %s: %w", src.Name, err)
+			}
+			failed++
+			continue
+		}
+		if err := emit(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 && failed == len(c.sources) {
+		return firstErr
+	}
+	return nil
+}
+
+// connect dials the server and logs in
+func (c *Connector) connect() (*client.Client, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var imapClient *client.Client
+	var err error
+	if c.useTLS {
+		imapClient, err = client.DialWithDialerTLS(dialer, c.address, nil)
+	} else {
+		imapClient, err = client.DialWithDialer(dialer, c.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.address, err)
+	}
+	imapClient.Timeout = c.timeout
+
+	if err := imapClient.Login(c.username, c.password); err != nil {
+		imapClient.Logout()
+		return nil, fmt.Errorf("failed to log in to %s: %w", c.address, err)
+	}
+	return imapClient, nil
+}
+
+// sourceNews reads the messages of a source newer than its UID cursor. The cursor is
+// stored as "<uidvalidity>/<uid>" so it resets when the server renumbers the folder.
+func (c *Connector) sourceNews(ctx context.Context, imapClient *client.Client, src source) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "imap:"+src.Name)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	mailbox, err := imapClient.Select(src.Folder, true)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to open folder %s: %w", src.Folder, err)
+	}
+
+	lastUID := parseCursor(state.LastMessageID, mailbox.UidValidity)
+	uids, err := c.searchNew(imapClient, src, lastUID)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	messages, err := c.fetchMessages(imapClient, uids)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	fetchedAt := time.Now()
+	var news []models.RawNews
+	for _, msg := range messages {
+		if src.Split.Enabled {
+			news = append(news, c.splitLinks(src, msg, fetchedAt)...)
+		} else {
+			news = append(news, c.toRawNews(src, msg, fetchedAt))
+		}
+	}
+	// Every UID searched for was read, so messages that could not be parsed are skipped
+	// instead of being fetched again on every run
+	if len(uids) > 0 {
+		lastUID = uids[len(uids)-1]
+	}
+
+	state.LastMessageID = fmt.Sprintf("%d/%d", mailbox.UidValidity, lastUID)
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(messages)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// searchNew returns the UIDs above lastUID matching the source, oldest first and at
+// most c.maxMessages of them
+func (c *Connector) searchNew(imapClient *client.Client, src source, lastUID uint32) ([]uint32, error) {
+	criteria := goimap.NewSearchCriteria()
+	criteria.Uid = new(goimap.SeqSet)
+	criteria.Uid.AddRange(lastUID+1, 0)
+	if src.From != "" {
+		criteria.Header.Add("From", src.From)
+	}
+
+	found, err := imapClient.UidSearch(criteria)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search folder %s: %w", src.Folder, err)
+	}
+
+	// "n:*" always matches the last message, even when its UID is below n
+	var uids []uint32
+	for _, uid := range found {
+		if uid > lastUID {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	if len(uids) > c.maxMessages {
+		uids = uids[:c.maxMessages]
+	}
+	return uids, nil
+}
+
+// fetchMessages downloads and parses the given messages without marking them as read
+func (c *Connector) fetchMessages(imapClient *client.Client, uids []uint32) ([]message, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	seqSet := new(goimap.SeqSet)
+	seqSet.AddNum(uids...)
+	section := &goimap.BodySectionName{Peek: true}
+	items := []goimap.FetchItem{goimap.FetchUid, goimap.FetchInternalDate, section.FetchItem()}
+
+	fetched := make(chan *goimap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- imapClient.UidFetch(seqSet, items, fetched)
+	}()
+
+	var messages []message
+	for imapMessage := range fetched {
+		body := imapMessage.GetBody(section)
+		if body == nil {
+			continue
+		}
+		msg, err := parseMessage(body)
+		if err != nil {
+			log.Printf("Failed to parse message %d: %v", imapMessage.Uid, err)
+			continue
+		}
+		msg.uid = imapMessage.Uid
+		if msg.date.IsZero() {
+			msg.date = imapMessage.InternalDate
+		}
+		messages = append(messages, *msg)
+	}
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	sort.Slice(messages, func(i, j int) bool { return messages[i].uid < messages[j].uid })
+	return messages, nil
+}
+
+// parseMessage reads the headers and the HTML and plain text bodies of an email
+func parseMessage(r io.Reader) (*message, error) {
+	reader, err := mail.CreateReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	msg := &message{}
+	msg.subject, _ = reader.Header.Subject()
+	msg.messageID, _ = reader.Header.MessageID()
+	msg.date, _ = reader.Header.Date()
+	if from, err := reader.Header.AddressList("From"); err == nil && len(from) > 0 {
+		msg.from = from[0].String()
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		inline, ok := part.Header.(*mail.InlineHeader)
+		if !ok {
+			continue // attachment
+		}
+		contentType, _, _ := mime.ParseMediaType(inline.Get("Content-Type"))
+		body, err := io.ReadAll(part.Body)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case contentType == "text/html" && msg.html == "":
+			msg.html = string(body)
+		case (contentType == "text/plain" || contentType == "") && msg.text == "":
+			msg.text = string(body)
+		}
+	}
+	return msg, nil
+}
+
+// toRawNews converts a whole message to the standard news format
+func (c *Connector) toRawNews(src source, msg message, fetchedAt time.Time) models.RawNews {
+	content := strings.TrimSpace(msg.text)
+	if msg.html != "" {
+		content = htmltext.ToText(msg.html)
+	}
+
+	return models.RawNews{
+		SourceType:  "imap",
+		SourceID:    messageSourceID(msg),
+		SourceName:  src.Name,
+		Title:       msg.subject,
+		Content:     content,
+		PublishedAt: msg.date,
+		FetchedAt:   fetchedAt,
+		Metadata:    messageMetadata(src, msg),
+	}
+}
+
+// splitLinks emits one item per link of the message that passes the split rules. The
+// text of the block around a link becomes its content.
+func (c *Connector) splitLinks(src source, msg message, fetchedAt time.Time) []models.RawNews {
+	if msg.html == "" {
+		return nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(msg.html))
+	if err != nil {
+		log.Printf("Failed to parse HTML of message %d: %v", msg.uid, err)
+		return nil
+	}
+
+	var news []models.RawNews
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(_ int, link *goquery.Selection) {
+		href := strings.TrimSpace(link.AttrOr("href", ""))
+		title := strings.Join(strings.Fields(link.Text()), " ")
+		if seen[href] || !src.keepLink(href, title) {
+			return
+		}
+		seen[href] = true
+
+		block := link.Closest("p, li, td, div")
+		content := ""
+		if block.Length() > 0 {
+			if html, err := block.Html(); err == nil {
+				content = htmltext.ToText(html)
+			}
+		}
+
+		metadata := messageMetadata(src, msg)
+		metadata["linkIndex"] = len(news)
+		news = append(news, models.RawNews{
+			SourceType:  "imap",
+			SourceID:    messageSourceID(msg) + "#" + strconv.Itoa(len(news)),
+			SourceName:  src.Name,
+			Title:       title,
+			Content:     content,
+			URL:         href,
+			PublishedAt: msg.date,
+			FetchedAt:   fetchedAt,
+			Metadata:    metadata,
+		})
+	})
+	return news
+}
+
+// keepLink applies the split rules to a link
+func (s source) keepLink(href, title string) bool {
+	if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+		return false
+	}
+	if len([]rune(title)) < s.Split.MinTitleLength || title == "" {
+		return false
+	}
+	for _, re := range s.exclude {
+		if re.MatchString(href) {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, re := range s.include {
+		if re.MatchString(href) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageSourceID prefers the Message-ID header, which survives folder renumbering
+func messageSourceID(msg message) string {
+	if msg.messageID != "" {
+		return msg.messageID
+	}
+	return "uid:" + strconv.FormatUint(uint64(msg.uid), 10)
+}
+
+// messageMetadata describes the email an item came from
+func messageMetadata(src source, msg message) map[string]interface{} {
+	return map[string]interface{}{
+		"folder":    src.Folder,
+		"from":      msg.from,
+		"subject":   msg.subject,
+		"messageID": msg.messageID,
+		"uid":       msg.uid,
+	}
+}
+
+// parseCursor returns the UID stored in cursor, or 0 when it belongs to another UIDVALIDITY
+func parseCursor(cursor string, uidValidity uint32) uint32 {
+	parts := strings.SplitN(cursor, "/", 2)
+	if len(parts) != 2 || parts[0] != strconv.FormatUint(uint64(uidValidity), 10) {
+		return 0
+	}
+	uid, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(uid)
+}
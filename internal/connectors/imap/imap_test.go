@@ -0,0 +1,201 @@
+package imap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+)
+
+const weeklyIssue = "From: Golang Weekly <peter@golangweekly.com>\r\n" +
+	"To: news@example.com\r\n" +
+	"Subject: Golang Weekly #600\r\n" +
+	"Date: Thu, 05 Mar 2026 15:00:00 +0000\r\n" +
+	"Message-ID: <issue600@golangweekly.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain version\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<table><tr><td><a href=3D\"https://go.dev/blog/go1.26\">Go 1.26 is released</a> =E2=80=94 generics get faster.</td></tr>\r\n" +
+	"<tr><td><p><a href=3D\"https://example.com/sqlc\">Typed SQL with sqlc</a> A tour of sqlc.</p></td></tr>\r\n" +
+	"<tr><td><a href=3D\"https://go.dev/blog/go1.26\">Go 1.26 is released</a></td></tr>\r\n" +
+	"<tr><td><a href=3D\"https://x.com/golangweekly\">Follow us</a> <a href=3D\"https://golangweekly.com/unsubscribe\">Unsubscribe here</a></td></tr></table>\r\n" +
+	"--b1--\r\n"
+
+const otherMessage = "From: Someone <someone@example.com>\r\n" +
+	"Subject: Lunch?\r\n" +
+	"Date: Thu, 05 Mar 2026 16:00:00 +0000\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Not a newsletter"
+
+// malformedMessage is a multipart message without a boundary, which cannot be parsed
+const malformedMessage = "From: Golang Weekly <peter@golangweekly.com>\r\n" +
+	"Subject: Broken\r\n" +
+	"Content-Type: multipart/alternative\r\n" +
+	"\r\n" +
+	"--b1\r\n"
+
+// newTestServer starts an in-process IMAP server with the given messages appended to INBOX
+func newTestServer(t *testing.T, messages ...string) (string, *memory.Backend) {
+	t.Helper()
+	backend := memory.New()
+	appendMessages(t, backend, messages...)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	imapServer := server.New(backend)
+	imapServer.AllowInsecureAuth = true
+	go imapServer.Serve(listener)
+	t.Cleanup(func() { imapServer.Close() })
+
+	return listener.Addr().String(), backend
+}
+
+func appendMessages(t *testing.T, backend *memory.Backend, messages ...string) {
+	t.Helper()
+	user, err := backend.Login(nil, "username", "password")
+	require.NoError(t, err)
+	inbox, err := user.GetMailbox("INBOX")
+	require.NoError(t, err)
+	for _, body := range messages {
+		require.NoError(t, inbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(body)))
+	}
+}
+
+func newTestConnector(t *testing.T, address string, src config.IMAPSourceConfig) *Connector {
+	t.Helper()
+	connector, err := New(config.IMAPConfig{
+		Enabled: true,
+		Server: config.IMAPServerConfig{
+			Address:  address,
+			Username: config.Secret("username"),
+			Password: config.Secret("password"),
+		},
+		Sources:  []config.IMAPSourceConfig{src},
+		Settings: config.IMAPSettings{Timeout: 5 * time.Second, MaxMessages: 10},
+	}, storage.NewMemoryStateRepository())
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNewsSplitLinks(t *testing.T) {
+	address, backend := newTestServer(t, weeklyIssue, otherMessage)
+	connector := newTestConnector(t, address, config.IMAPSourceConfig{
+		Name:   "Golang Weekly",
+		Folder: "INBOX",
+		From:   "golangweekly.com",
+		Split: config.IMAPSplitConfig{
+			Enabled:        true,
+			MinTitleLength: 10,
+			ExcludeURLs:    []string{"unsubscribe", `^https://x\.com/`},
+		},
+	})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+
+	assert.Equal(t, "imap", news[0].SourceType)
+	assert.Equal(t, "Golang Weekly", news[0].SourceName)
+	assert.Equal(t, "issue600@golangweekly.com#0", news[0].SourceID)
+	assert.Equal(t, "Go 1.26 is released", news[0].Title)
+	assert.Equal(t, "https://go.dev/blog/go1.26", news[0].URL)
+	assert.Equal(t, "Go 1.26 is released — generics get faster.", news[0].Content)
+	assert.Equal(t, time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC), news[0].PublishedAt.UTC())
+	assert.Equal(t, "Golang Weekly #600", news[0].Metadata["subject"])
+
+	assert.Equal(t, "Typed SQL with sqlc", news[1].Title)
+	assert.Equal(t, "Typed SQL with sqlc A tour of sqlc.", news[1].Content)
+
+	// The UID cursor skips messages read before and picks up new ones
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+
+	appendMessages(t, backend, weeklyIssue)
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, news, 2)
+}
+
+func TestGetNewsWholeMessage(t *testing.T) {
+	address, _ := newTestServer(t, weeklyIssue)
+	connector := newTestConnector(t, address, config.IMAPSourceConfig{Name: "Inbox", Folder: "INBOX"})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+
+	// memory.New seeds INBOX with a plain text message
+	require.Len(t, news, 2)
+	assert.Equal(t, "A little message, just for you", news[0].Title)
+	assert.Equal(t, "Hi there :)", news[0].Content)
+
+	assert.Equal(t, "Golang Weekly #600", news[1].Title)
+	assert.Equal(t, "issue600@golangweekly.com", news[1].SourceID)
+	assert.Contains(t, news[1].Content, "Go 1.26 is released — generics get faster.")
+	assert.NotContains(t, news[1].Content, "<a")
+}
+
+func TestGetNewsSkipsMalformedMessages(t *testing.T) {
+	address, backend := newTestServer(t, weeklyIssue, malformedMessage)
+	connector := newTestConnector(t, address, config.IMAPSourceConfig{Name: "Inbox", Folder: "INBOX"})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+	assert.Equal(t, "Golang Weekly #600", news[1].Title)
+
+	// The cursor moves past the malformed message, the newest one in the folder
+	user, err := backend.Login(nil, "username", "password")
+	require.NoError(t, err)
+	inbox, err := user.GetMailbox("INBOX")
+	require.NoError(t, err)
+	messages := inbox.(*memory.Mailbox).Messages
+	state, err := connector.stateRepository.GetChannelState(context.Background(), "imap:Inbox")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(state.LastMessageID, fmt.Sprintf("/%d", messages[len(messages)-1].Uid)), state.LastMessageID)
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+
+	appendMessages(t, backend, weeklyIssue)
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, news, 1)
+}
+
+func TestGetNewsLoginFailure(t *testing.T) {
+	address, _ := newTestServer(t)
+	connector := newTestConnector(t, address, config.IMAPSourceConfig{Name: "Inbox", Folder: "INBOX"})
+	connector.password = "wrong"
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to log in")
+}
+
+func TestParseCursor(t *testing.T) {
+	assert.Equal(t, uint32(42), parseCursor("7/42", 7))
+	assert.Equal(t, uint32(0), parseCursor("6/42", 7), "UIDVALIDITY changed")
+	assert.Equal(t, uint32(0), parseCursor("", 7))
+}
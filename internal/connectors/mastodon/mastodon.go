@@ -0,0 +1,398 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/htmltext"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// titleLength is the maximum length of a title cut from the status text
+const titleLength = 100
+
+// Account is the author of a status
+type Account struct {
+	ID          string `json:"id"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+	URL         string `json:"url"`
+}
+
+// MediaAttachment is an image, video or audio file attached to a status
+type MediaAttachment struct {
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	PreviewURL  string `json:"preview_url"`
+	Description string `json:"description"`
+}
+
+// Tag is a hashtag used in a status
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Status is a post returned by the timelines APIs
+type Status struct {
+	ID               string            `json:"id"`
+	CreatedAt        time.Time         `json:"created_at"`
+	InReplyToID      *string           `json:"in_reply_to_id"`
+	Sensitive        bool              `json:"sensitive"`
+	SpoilerText      string            `json:"spoiler_text"`
+	Visibility       string            `json:"visibility"`
+	Language         string            `json:"language"`
+	URI              string            `json:"uri"`
+	URL              string            `json:"url"`
+	RepliesCount     int               `json:"replies_count"`
+	ReblogsCount     int               `json:"reblogs_count"`
+	FavouritesCount  int               `json:"favourites_count"`
+	Content          string            `json:"content"`
+	Account          Account           `json:"account"`
+	MediaAttachments []MediaAttachment `json:"media_attachments"`
+	Tags             []Tag             `json:"tags"`
+	// Reblog is the boosted status when this status is a boost
+	Reblog *Status `json:"reblog"`
+}
+
+// Connector implements NewsConnector for Mastodon accounts and hashtags
+type Connector struct {
+	client          *http.Client
+	userAgent       string
+	limit           int
+	maxPages        int
+	excludeReplies  bool
+	excludeBoosts   bool
+	sources         []config.MastodonSourceConfig
+	stateRepository models.ChannelStateRepository
+
+	mu         sync.Mutex
+	accountIDs map[string]string
+}
+
+// New creates a new Mastodon connector
+func New(cfg config.MastodonConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("mastodon connector is disabled in config")
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		userAgent:       cfg.Settings.UserAgent,
+		limit:           cfg.Settings.Limit,
+		maxPages:        cfg.Settings.MaxPages,
+		excludeReplies:  cfg.Settings.ExcludeReplies,
+		excludeBoosts:   cfg.Settings.ExcludeBoosts,
+		sources:         cfg.Sources,
+		stateRepository: stateRepo,
+		accountIDs:      make(map[string]string),
+	}, nil
+}
+
+// GetNews returns the statuses posted to every source since the previous run.
+// A failing source is logged and skipped; an error is returned only if every source failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, source := range c.sources {
+		news, err := c.GetSourceNews(ctx, sourceName(source))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch Mastodon timeline %s: %v", sourceName(source), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to fetch Mastodon timeline %s: %w", sourceName(source), err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.sources) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured timelines
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, sourceName(source))
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named timeline only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named timeline with the state that
+// marks its statuses as seen, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, source := range c.sources {
+		if sourceName(source) == name {
+			return c.sourceNews(ctx, source)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// sourceNews pages through a timeline from the newest status back to the last seen
+// one. Without state only the newest page is read, so history is not backfilled.
+func (c *Connector) sourceNews(ctx context.Context, source config.MastodonSourceConfig) (models.NewsBatch, error) {
+	channelID := "mastodon:" + sourceName(source)
+	state, err := c.stateRepository.GetChannelState(ctx, channelID)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	endpoint, err := c.timelineURL(ctx, source)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	sinceID := state.LastMessageID
+	maxPages := c.maxPages
+	if sinceID == "" {
+		maxPages = 1
+	}
+
+	var statuses []Status
+	maxID := ""
+	for page := 0; page < maxPages; page++ {
+		query := url.Values{"limit": {fmt.Sprint(c.limit)}}
+		if sinceID != "" {
+			query.Set("since_id", sinceID)
+		}
+		if maxID != "" {
+			query.Set("max_id", maxID)
+		}
+		if source.Account != "" {
+			query.Set("exclude_replies", fmt.Sprint(c.excludeReplies))
+			query.Set("exclude_reblogs", fmt.Sprint(c.excludeBoosts))
+		}
+
+		var batch []Status
+		if err := c.getJSON(ctx, endpoint+"?"+query.Encode(), &batch); err != nil {
+			return models.NewsBatch{}, err
+		}
+		statuses = append(statuses, batch...)
+		if len(batch) < c.limit {
+			break
+		}
+		// Timelines are newest first; continue below the oldest status of this page
+		maxID = batch[len(batch)-1].ID
+	}
+
+	fetchedAt := time.Now()
+	var news []models.RawNews
+	lastID := sinceID
+	for _, status := range statuses {
+		if newerID(status.ID, lastID) {
+			lastID = status.ID
+		}
+		if c.excludeReplies && status.InReplyToID != nil {
+			continue
+		}
+		if c.excludeBoosts && status.Reblog != nil {
+			continue
+		}
+		news = append(news, c.toRawNews(source, status, fetchedAt))
+	}
+
+	state.LastMessageID = lastID
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// timelineURL returns the statuses endpoint of an account or a hashtag
+func (c *Connector) timelineURL(ctx context.Context, source config.MastodonSourceConfig) (string, error) {
+	instance := strings.TrimRight(source.Instance, "/")
+	if source.Hashtag != "" {
+		return fmt.Sprintf("%s/api/v1/timelines/tag/%s", instance, url.PathEscape(strings.TrimPrefix(source.Hashtag, "#"))), nil
+	}
+
+	id, err := c.accountID(ctx, instance, strings.TrimPrefix(source.Account, "@"))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/v1/accounts/%s/statuses", instance, url.PathEscape(id)), nil
+}
+
+// accountID resolves an account name to its ID on the instance, caching the result
+func (c *Connector) accountID(ctx context.Context, instance, acct string) (string, error) {
+	key := instance + " " + acct
+	c.mu.Lock()
+	id, ok := c.accountIDs[key]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var account Account
+	if err := c.getJSON(ctx, instance+"/api/v1/accounts/lookup?acct="+url.QueryEscape(acct), &account); err != nil {
+		return "", fmt.Errorf("failed to look up account %s: %w", acct, err)
+	}
+
+	c.mu.Lock()
+	c.accountIDs[key] = account.ID
+	c.mu.Unlock()
+	return account.ID, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into out
+func (c *Connector) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// toRawNews converts a status to the standard news format. For boosts the boosted
+// status provides the content and the booster is recorded in Metadata.
+func (c *Connector) toRawNews(source config.MastodonSourceConfig, status Status, fetchedAt time.Time) models.RawNews {
+	post := status
+	boostedBy := ""
+	if status.Reblog != nil {
+		post = *status.Reblog
+		boostedBy = status.Account.Acct
+	}
+
+	text := htmltext.ToText(post.Content)
+	title := post.SpoilerText
+	if title == "" {
+		title = titleFromText(text)
+	}
+	if title == "" {
+		// Media-only posts have no text
+		title = "Post by @" + post.Account.Acct
+	}
+	postURL := post.URL
+	if postURL == "" {
+		postURL = post.URI
+	}
+
+	media := make([]map[string]interface{}, 0, len(post.MediaAttachments))
+	for _, attachment := range post.MediaAttachments {
+		media = append(media, map[string]interface{}{
+			"type":        attachment.Type,
+			"url":         attachment.URL,
+			"previewURL":  attachment.PreviewURL,
+			"description": attachment.Description,
+		})
+	}
+	tags := make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	return models.RawNews{
+		SourceType:  "mastodon",
+		SourceID:    status.ID,
+		SourceName:  sourceName(source),
+		SourceURL:   sourceURL(source),
+		Title:       title,
+		Content:     text,
+		URL:         postURL,
+		PublishedAt: post.CreatedAt,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"author":         post.Account.Acct,
+			"authorName":     post.Account.DisplayName,
+			"boosts":         post.ReblogsCount,
+			"favourites":     post.FavouritesCount,
+			"replies":        post.RepliesCount,
+			"isReply":        post.InReplyToID != nil,
+			"boostedBy":      boostedBy,
+			"contentWarning": post.SpoilerText,
+			"sensitive":      post.Sensitive,
+			"language":       post.Language,
+			"tags":           tags,
+			"media":          media,
+		},
+	}
+}
+
+// sourceName identifies a source as host/@account or host/#hashtag
+func sourceName(source config.MastodonSourceConfig) string {
+	host := source.Instance
+	if parsed, err := url.Parse(source.Instance); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	if source.Hashtag != "" {
+		return host + "/#" + strings.TrimPrefix(source.Hashtag, "#")
+	}
+	return host + "/@" + strings.TrimPrefix(source.Account, "@")
+}
+
+// sourceURL is the public web page of a source
+func sourceURL(source config.MastodonSourceConfig) string {
+	instance := strings.TrimRight(source.Instance, "/")
+	if source.Hashtag != "" {
+		return instance + "/tags/" + strings.TrimPrefix(source.Hashtag, "#")
+	}
+	return instance + "/@" + strings.TrimPrefix(source.Account, "@")
+}
+
+// titleFromText cuts the first line of text to at most titleLength characters at a word boundary
+func titleFromText(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if utf8.RuneCountInString(text) <= titleLength {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:titleLength])
+	if i := strings.LastIndexByte(cut, ' '); i > titleLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// newerID reports whether status ID a is newer than b. IDs are numeric strings
+// that grow over time, so a longer ID is newer.
+func newerID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
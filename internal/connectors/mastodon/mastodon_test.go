@@ -0,0 +1,173 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInstance serves a tag timeline of statuses and honours limit, since_id and max_id
+type fakeInstance struct {
+	statuses []Status
+	queries  []string
+}
+
+func (f *fakeInstance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v1/accounts/lookup":
+		json.NewEncoder(w).Encode(Account{ID: "42", Acct: r.URL.Query().Get("acct")})
+		return
+	case "/api/v1/timelines/tag/golang", "/api/v1/accounts/42/statuses":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	f.queries = append(f.queries, r.URL.RawQuery)
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	page := []Status{}
+	for _, status := range f.statuses {
+		if since := query.Get("since_id"); since != "" && !newerID(status.ID, since) {
+			continue
+		}
+		if max := query.Get("max_id"); max != "" && !newerID(max, status.ID) {
+			continue
+		}
+		if len(page) < limit {
+			page = append(page, status)
+		}
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+// post adds a status; statuses are kept newest first like a real timeline
+func (f *fakeInstance) post(status Status) {
+	f.statuses = append(f.statuses, status)
+	sort.Slice(f.statuses, func(i, j int) bool { return newerID(f.statuses[i].ID, f.statuses[j].ID) })
+}
+
+func newTestConnector(t *testing.T, source config.MastodonSourceConfig, settings config.MastodonSettings) *Connector {
+	t.Helper()
+	settings.Timeout = 5 * time.Second
+	connector, err := New(config.MastodonConfig{
+		Enabled:  true,
+		Sources:  []config.MastodonSourceConfig{source},
+		Settings: settings,
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNewsHashtag(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reply := "5"
+	instance := &fakeInstance{}
+	instance.post(Status{
+		ID: "9", CreatedAt: created, URL: "https://fosstodon.org/@gopher/9",
+		Content:      "<p>Go 1.26 is out!</p><p>Release notes: <a href=\"https://go.dev/doc/go1.26\">go.dev/doc/go1.26</a></p>",
+		Account:      Account{Acct: "gopher", DisplayName: "Gopher"},
+		ReblogsCount: 12, FavouritesCount: 30, RepliesCount: 4, Language: "en",
+		Tags:             []Tag{{Name: "golang"}},
+		MediaAttachments: []MediaAttachment{{Type: "image", URL: "https://files.example/1.png", Description: "Gopher"}},
+	})
+	instance.post(Status{
+		ID: "10", CreatedAt: created, InReplyToID: &reply, SpoilerText: "Generics rant",
+		Content: "<p>Long thread</p>", Account: Account{Acct: "alice@example.social"},
+	})
+	server := httptest.NewServer(instance)
+	defer server.Close()
+
+	connector := newTestConnector(t,
+		config.MastodonSourceConfig{Instance: server.URL, Hashtag: "golang"},
+		config.MastodonSettings{Limit: 2, MaxPages: 3})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+
+	assert.Equal(t, "10", news[0].SourceID)
+	assert.Equal(t, "Generics rant", news[0].Title, "the content warning is the title")
+	assert.Equal(t, "Generics rant", news[0].Metadata["contentWarning"])
+	assert.Equal(t, true, news[0].Metadata["isReply"])
+
+	status := news[1]
+	assert.Equal(t, "mastodon", status.SourceType)
+	assert.Equal(t, "Go 1.26 is out!", status.Title)
+	assert.Equal(t, "Go 1.26 is out!\n\nRelease notes: go.dev/doc/go1.26", status.Content)
+	assert.Equal(t, "https://fosstodon.org/@gopher/9", status.URL)
+	assert.Equal(t, 12, status.Metadata["boosts"])
+	assert.Equal(t, 30, status.Metadata["favourites"])
+	assert.Equal(t, 4, status.Metadata["replies"])
+	assert.Equal(t, []string{"golang"}, status.Metadata["tags"])
+	media := status.Metadata["media"].([]map[string]interface{})
+	require.Len(t, media, 1)
+	assert.Equal(t, "image", media[0]["type"])
+
+	// Catching up pages back from the newest status to since_id
+	for id := 11; id <= 15; id++ {
+		instance.post(Status{ID: strconv.Itoa(id), CreatedAt: created, Content: "<p>post " + strconv.Itoa(id) + "</p>"})
+	}
+	instance.queries = nil
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	var ids []string
+	for _, item := range news {
+		ids = append(ids, item.SourceID)
+	}
+	assert.Equal(t, []string{"15", "14", "13", "12", "11"}, ids)
+	assert.Equal(t, []string{
+		"limit=2&since_id=10",
+		"limit=2&max_id=14&since_id=10",
+		"limit=2&max_id=12&since_id=10",
+	}, instance.queries)
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+}
+
+func TestGetNewsAccountBoost(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	instance := &fakeInstance{}
+	instance.post(Status{
+		ID: "20", CreatedAt: created, Account: Account{Acct: "golang"},
+		Reblog: &Status{ID: "7", CreatedAt: created, URL: "https://example.social/@bob/7", Content: "<p>New linter release</p>", Account: Account{Acct: "bob@example.social"}, ReblogsCount: 3},
+	})
+	server := httptest.NewServer(instance)
+	defer server.Close()
+
+	connector := newTestConnector(t,
+		config.MastodonSourceConfig{Instance: server.URL, Account: "golang"},
+		config.MastodonSettings{Limit: 20, MaxPages: 1})
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "20", news[0].SourceID)
+	assert.Equal(t, "New linter release", news[0].Content)
+	assert.Equal(t, "https://example.social/@bob/7", news[0].URL)
+	assert.Equal(t, "bob@example.social", news[0].Metadata["author"])
+	assert.Equal(t, "golang", news[0].Metadata["boostedBy"])
+	assert.Equal(t, 3, news[0].Metadata["boosts"])
+	assert.Equal(t, []string{"exclude_reblogs=false&exclude_replies=false&limit=20"}, instance.queries)
+}
+
+func TestTitleFromText(t *testing.T) {
+	assert.Equal(t, "Short", titleFromText("Short\nsecond line"))
+	long := "Go generics make writing reusable data structures and algorithms much easier than it used to be before 1.18"
+	title := titleFromText(long)
+	assert.True(t, len([]rune(title)) <= titleLength+1)
+	assert.Equal(t, "Go generics make writing reusable data structures and algorithms much easier than it used to be…", title)
+}
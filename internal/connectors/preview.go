@@ -0,0 +1,201 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+)
+
+const (
+	// DefaultPreviewLimit is the number of items returned by a preview without a limit
+	DefaultPreviewLimit = 20
+	// MaxPreviewLimit bounds the number of items returned by a preview
+	MaxPreviewLimit = 100
+)
+
+var (
+	// ErrPreviewUnsupported is returned for connector types that cannot be previewed:
+	// unknown ones, and those that push news or read a private mailbox
+	ErrPreviewUnsupported = errors.New("connector type cannot be previewed")
+	// ErrPreviewFailed is returned when the connector could not fetch anything
+	ErrPreviewFailed = errors.New("preview fetch failed")
+)
+
+// previewTypes lists the connector types that fetch public sources over HTTP
+var previewTypes = map[string]bool{
+	"reddit":        true,
+	"hackernews":    true,
+	"scraper":       true,
+	"github":        true,
+	"mastodon":      true,
+	"youtube":       true,
+	"arxiv":         true,
+	"stackexchange": true,
+	"bluesky":       true,
+}
+
+// PreviewResult is what a connector would collect from an ad-hoc source definition
+type PreviewResult struct {
+	News     []models.RawNews `json:"news"`
+	Total    int              `json:"total"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// Previewer runs a connector once from a definition that is not part of the config.
+// News is returned instead of stored, and state and cache validators stay in memory,
+// so neither storage, the queue nor the ChannelState of configured sources are touched.
+type Previewer struct {
+	service      *ConnectorService
+	newTransport func() (http.RoundTripper, error)
+	timeout      time.Duration
+}
+
+// NewPreviewer creates a previewer sharing the worker pool of service. newTransport
+// creates the HTTP transport of one preview; timeout bounds a preview, zero meaning
+// no bound.
+func NewPreviewer(service *ConnectorService, newTransport func() (http.RoundTripper, error), timeout time.Duration) *Previewer {
+	return &Previewer{
+		service:      service,
+		newTransport: newTransport,
+		timeout:      timeout,
+	}
+}
+
+// Preview creates a connector of the given type from settings, written like its section
+// of connectors.yaml in YAML or JSON, and fetches it once. At most limit items are
+// returned, newest first. Invalid settings are returned as config.ValidationErrors.
+func (p *Previewer) Preview(ctx context.Context, kind string, settings []byte, limit int) (*PreviewResult, error) {
+	if !previewTypes[kind] {
+		return nil, fmt.Errorf("%w: %q", ErrPreviewUnsupported, kind)
+	}
+	if limit <= 0 {
+		limit = DefaultPreviewLimit
+	}
+	limit = min(limit, MaxPreviewLimit)
+
+	cfg, err := config.ParseConnectorSection(kind, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fresh repository per preview, so cache validators never turn a fetch into a 304
+	stateRepo := storage.NewMemoryStateRepository()
+	transport, err := p.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	connector, err := NewFactory(cfg, stateRepo, transport).CreateConnector(kind)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreviewFailed, err)
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	result := &PreviewResult{}
+	news, err := p.fetch(ctx, connector, result)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreviewFailed, err)
+	}
+
+	sort.SliceStable(news, func(i, j int) bool {
+		return news[i].PublishedAt.After(news[j].PublishedAt)
+	})
+	result.Total = len(news)
+	result.Warnings = append(result.Warnings, checkPreviewNews(news)...)
+	if len(news) > limit {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("showing the newest %d of %d items", limit, len(news)))
+		news = news[:limit]
+	}
+	if news == nil {
+		news = []models.RawNews{}
+	}
+	result.News = news
+	return result, nil
+}
+
+// fetch runs the connector once. Failing sources of a source connector become warnings
+// unless every source failed.
+func (p *Previewer) fetch(ctx context.Context, connector models.NewsConnector, result *PreviewResult) ([]models.RawNews, error) {
+	sourced, ok := connector.(models.SourceConnector)
+	if !ok {
+		if err := p.service.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer p.service.release()
+		return connector.GetNews(models.WithWorkerPool(ctx, workerPool{p.service}))
+	}
+
+	var news []models.RawNews
+	var lastErr error
+	sources := sourced.Sources()
+	failed := 0
+	for _, source := range sources {
+		if err := p.service.acquire(ctx); err != nil {
+			return nil, err
+		}
+		items, err := sourced.GetSourceNews(ctx, source)
+		p.service.release()
+		if err != nil {
+			failed++
+			lastErr = err
+			result.Warnings = append(result.Warnings, fmt.Sprintf("source %s: %v", source, err))
+			continue
+		}
+		news = append(news, items...)
+	}
+	if len(sources) > 0 && failed == len(sources) {
+		return nil, lastErr
+	}
+	return news, nil
+}
+
+// checkPreviewNews describes what would make the news less useful once stored
+func checkPreviewNews(news []models.RawNews) []string {
+	if len(news) == 0 {
+		return []string{"no news found"}
+	}
+
+	var noTitle, noURL, noDate, duplicates int
+	seen := make(map[string]bool, len(news))
+	for _, item := range news {
+		if item.Title == "" {
+			noTitle++
+		}
+		if item.URL == "" {
+			noURL++
+		}
+		if item.PublishedAt.IsZero() {
+			noDate++
+		}
+		if seen[item.SourceID] {
+			duplicates++
+		}
+		seen[item.SourceID] = true
+	}
+
+	var warnings []string
+	if noTitle > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no title", noTitle))
+	}
+	if noURL > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no URL", noURL))
+	}
+	if noDate > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items have no publication date", noDate))
+	}
+	if duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d items share a source ID with another item and would be stored once", duplicates))
+	}
+	return warnings
+}
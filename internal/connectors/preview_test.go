@@ -0,0 +1,105 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeHackerNews serves a new stories list of three items
+func newFakeHackerNews(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0/newstories.json":
+			fmt.Fprint(w, `[1, 2, 3]`)
+		case "/v0/item/1.json":
+			fmt.Fprint(w, `{"id": 1, "type": "story", "time": 1767225600, "title": "Go 1.26", "url": "https://go.dev/blog/go1.26"}`)
+		case "/v0/item/2.json":
+			fmt.Fprint(w, `{"id": 2, "type": "story", "time": 1767229200, "title": "Fuzzing in practice", "url": "https://example.com/fuzz"}`)
+		case "/v0/item/3.json":
+			fmt.Fprint(w, `{"id": 3, "type": "story", "time": 1767232800, "title": "Ask HN: Favourite Go tools?"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestPreviewer(news *memoryNews, states models.ChannelStateRepository) *Previewer {
+	service := NewConnectorService(nil, news, states, news, nil, RunSettings{Workers: 1})
+	return NewPreviewer(service, func() (http.RoundTripper, error) {
+		return http.DefaultTransport, nil
+	}, 0)
+}
+
+func TestPreviewFetchesWithoutStoring(t *testing.T) {
+	server := newFakeHackerNews(t)
+	news := &memoryNews{}
+	states := storage.NewMemoryStateRepository()
+	previewer := newTestPreviewer(news, states)
+
+	settings := fmt.Sprintf(`{"lists": ["new"], "settings": {"base_url": %q, "timeout": "5s", "limit": 10, "workers": 2}}`, server.URL+"/v0")
+	result, err := previewer.Preview(context.Background(), "hackernews", []byte(settings), 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Total)
+	require.Len(t, result.News, 2)
+	assert.Equal(t, "Ask HN: Favourite Go tools?", result.News[0].Title, "newest first")
+	assert.Equal(t, "Fuzzing in practice", result.News[1].Title)
+	assert.Equal(t, []string{"showing the newest 2 of 3 items"}, result.Warnings)
+
+	assert.Empty(t, news.saved)
+	assert.Zero(t, news.queued)
+	state, err := states.GetChannelState(context.Background(), "hackernews:hackernews")
+	require.NoError(t, err)
+	assert.Empty(t, state.SeenIDs)
+	assert.Zero(t, state.ProcessedMessages)
+}
+
+func TestPreviewRejectsInvalidDefinitions(t *testing.T) {
+	previewer := newTestPreviewer(&memoryNews{}, nil)
+
+	_, err := previewer.Preview(context.Background(), "webhook", []byte(`{}`), 0)
+	assert.True(t, errors.Is(err, ErrPreviewUnsupported))
+
+	_, err = previewer.Preview(context.Background(), "hackernews", []byte(`{"lists": ["old"]}`), 0)
+	var errs config.ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+}
+
+func TestPreviewReportsFailedFetch(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	previewer := newTestPreviewer(&memoryNews{}, nil)
+
+	settings := fmt.Sprintf(`{"lists": ["new"], "settings": {"base_url": %q, "timeout": "5s", "limit": 10, "workers": 2}}`, server.URL)
+	_, err := previewer.Preview(context.Background(), "hackernews", []byte(settings), 0)
+	assert.True(t, errors.Is(err, ErrPreviewFailed))
+}
+
+func TestPreviewRefusesInternalHosts(t *testing.T) {
+	service := NewConnectorService(nil, &memoryNews{}, nil, &memoryNews{}, nil, RunSettings{Workers: 1})
+	previewer := NewPreviewer(service, func() (http.RoundTripper, error) {
+		return httpfetch.New(httpfetch.Options{PublicOnly: true})
+	}, 0)
+
+	for _, target := range []string{"http://127.0.0.1/v0", "http://169.254.169.254/latest"} {
+		settings := fmt.Sprintf(`{"lists": ["new"], "settings": {"base_url": %q, "timeout": "5s", "limit": 10, "workers": 2}}`, target)
+		_, err := previewer.Preview(context.Background(), "hackernews", []byte(settings), 0)
+		require.Error(t, err, target)
+		assert.True(t, errors.Is(err, ErrPreviewFailed), target)
+		assert.Contains(t, err.Error(), httpfetch.ErrNotPublic.Error(), target)
+	}
+}
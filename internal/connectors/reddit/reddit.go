@@ -12,6 +12,9 @@ import (
 	"github.com/vartanbeno/go-reddit/v2/reddit"
 )
 
+// backfillPageSize is the number of posts per backfill page, the most a listing returns
+const backfillPageSize = 100
+
 // Connector implements NewsConnector for Reddit
 type Connector struct {
 	client          *reddit.Client
@@ -22,7 +25,7 @@ type Connector struct {
 }
 
 // New creates a new Reddit connector
-func New(cfg config.RedditConfig, stateRepo models.ChannelStateRepository) (*Connector, error) {
+func New(cfg config.RedditConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
 	if !cfg.Enabled {
 		return nil, fmt.Errorf("reddit connector is disabled in config")
 	}
@@ -31,27 +34,20 @@ func New(cfg config.RedditConfig, stateRepo models.ChannelStateRepository) (*Con
 	var err error
 
 	httpClient := &http.Client{
-		Timeout: cfg.Settings.Timeout,
+		Timeout:   cfg.Settings.Timeout,
+		Transport: transport,
 	}
 
-	// Check if credentials are provided and not placeholder values
-	if cfg.Settings.ClientID != "your_client_id" &&
-		cfg.Settings.ClientSecret != "your_client_secret" &&
-		cfg.Settings.Username != "your_username" &&
-		cfg.Settings.Password != "your_password" {
-
-		// Log all the variables
-		log.Printf("ClientID: %s", cfg.Settings.ClientID)
-		log.Printf("ClientSecret: %s", cfg.Settings.ClientSecret)
-		log.Printf("Username: %s", cfg.Settings.Username)
-		log.Printf("Password: %s", cfg.Settings.Password)
+	// Placeholder credentials are rejected by config validation, so any complete set is real
+	if cfg.Settings.HasCredentials() {
+		log.Println("Using authenticated Reddit client.")
 
 		// Use authenticated client
 		credentials := reddit.Credentials{
-			ID:       cfg.Settings.ClientID,
-			Secret:   cfg.Settings.ClientSecret,
-			Username: cfg.Settings.Username,
-			Password: cfg.Settings.Password,
+			ID:       cfg.Settings.ClientID.Value(),
+			Secret:   cfg.Settings.ClientSecret.Value(),
+			Username: cfg.Settings.Username.Value(),
+			Password: cfg.Settings.Password.Value(),
 		}
 
 		client, err = reddit.NewClient(credentials,
@@ -63,20 +59,31 @@ func New(cfg config.RedditConfig, stateRepo models.ChannelStateRepository) (*Con
 		}
 	} else {
 		// Use read-only client without authentication
-		fmt.Println("Using read-only Reddit client. Rate limits will be lower.")
+		log.Println("Using read-only Reddit client. Rate limits will be lower.")
 		userAgent := cfg.Settings.UserAgent
 		if userAgent == "" || userAgent == "NewsAggregator/1.0 (by /u/your_username)" {
 			userAgent = "Mozilla/5.0 (compatible; NewsAggregator/1.0)"
 		}
 
-		client = &reddit.Client{}
+		client, err = reddit.NewReadonlyClient(
+			reddit.WithHTTPClient(httpClient),
+			reddit.WithUserAgent(userAgent))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to create read-only Reddit client: %w", err)
+		}
+	}
+
+	sort := cfg.Settings.Sort
+	if sort == "" {
+		sort = "hot"
 	}
 
 	return &Connector{
 		client:          client,
 		subreddits:      cfg.Subreddits,
 		limit:           cfg.Settings.Limit,
-		sort:            cfg.Settings.Sort,
+		sort:            sort,
 		stateRepository: stateRepo,
 	}, nil
 }
@@ -86,55 +93,134 @@ func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
 	var allNews []models.RawNews
 
 	for _, subreddit := range c.subreddits {
-		// Set options for fetching posts
-		listOptions := &reddit.ListPostOptions{
-			ListOptions: reddit.ListOptions{
-				Limit: c.limit,
-			},
-			Time: "day", // Default to day, can be customized based on c.sort if needed
+		news, err := c.subredditNews(ctx, subreddit)
+		if err != nil {
+			return nil, err
 		}
+		allNews = append(allNews, news...)
+	}
 
-		// Fetch only top posts
-		posts, _, err := c.client.Subreddit.TopPosts(ctx, subreddit.Name, listOptions)
+	return allNews, nil
+}
 
+// Sources returns the names of the configured subreddits
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.subreddits))
+	for _, subreddit := range c.subreddits {
+		names = append(names, subreddit.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named subreddit only
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	for _, subreddit := range c.subreddits {
+		if subreddit.Name == name {
+			return c.subredditNews(ctx, subreddit)
+		}
+	}
+	return nil, fmt.Errorf("unknown subreddit %q", name)
+}
+
+// BackfillPage returns a page of the newest posts of the named subreddit, following
+// the listing cursor of the previous page
+func (c *Connector) BackfillPage(ctx context.Context, name, cursor string) (models.BackfillPage, error) {
+	for _, subreddit := range c.subreddits {
+		if subreddit.Name != name {
+			continue
+		}
+
+		posts, resp, err := c.client.Subreddit.NewPosts(ctx, subreddit.Name, &reddit.ListOptions{
+			Limit: backfillPageSize,
+			After: cursor,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch top posts from r/%s: %w", subreddit.Name, err)
+			return models.BackfillPage{}, fmt.Errorf("failed to fetch new posts from r/%s: %w", subreddit.Name, err)
 		}
 
-		// Current time for FetchedAt field
 		fetchedAt := time.Now()
-
-		// Convert posts to RawNews
+		page := models.BackfillPage{Next: resp.After}
 		for _, post := range posts {
 			if post == nil || post.Created == nil {
-				continue // Skip posts with missing data
+				continue
 			}
+			page.News = append(page.News, toRawNews(subreddit, post, fetchedAt))
+		}
+		return page, nil
+	}
+	return models.BackfillPage{}, fmt.Errorf("unknown subreddit %q", name)
+}
 
-			// Create a new RawNews item
-			newsItem := models.RawNews{
-				SourceType:  "reddit",
-				SourceID:    post.ID,
-				SourceName:  subreddit.Name,
-				SourceURL:   subreddit.URL, // Using URL from config
-				Title:       post.Title,
-				Content:     "", // Оставляем поле Content пустым
-				URL:         fmt.Sprintf("https://www.reddit.com%s", post.Permalink),
-				PublishedAt: post.Created.Time,
-				FetchedAt:   fetchedAt,
-				Metadata: map[string]interface{}{
-					"author":           post.Author,
-					"score":            post.Score,
-					"numberOfComments": post.NumberOfComments,
-					"isNSFW":           post.NSFW,
-					"upvoteRatio":      post.UpvoteRatio,
-					"subreddit":        subreddit.Name,
-				},
-			}
+// subredditNews retrieves the posts of a subreddit from the listing selected by sort
+func (c *Connector) subredditNews(ctx context.Context, subreddit config.SubredditConfig) ([]models.RawNews, error) {
+	var news []models.RawNews
 
-			// Добавляем все посты в список новостей
-			allNews = append(allNews, newsItem)
+	posts, err := c.listing(ctx, subreddit.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s posts from r/%s: %w", c.sort, subreddit.Name, err)
+	}
+
+	// Current time for FetchedAt field
+	fetchedAt := time.Now()
+
+	// Convert posts to RawNews
+	for _, post := range posts {
+		if post == nil || post.Created == nil {
+			continue // Skip posts with missing data
 		}
+
+		// Добавляем все посты в список новостей
+		news = append(news, toRawNews(subreddit, post, fetchedAt))
 	}
 
-	return allNews, nil
+	return news, nil
+}
+
+// listing fetches one page of the subreddit listing matching the configured sort.
+// Top posts are taken from the past day, in line with the polling interval.
+func (c *Connector) listing(ctx context.Context, subreddit string) ([]*reddit.Post, error) {
+	opts := reddit.ListOptions{Limit: c.limit}
+
+	var posts []*reddit.Post
+	var err error
+	switch c.sort {
+	case "new":
+		posts, _, err = c.client.Subreddit.NewPosts(ctx, subreddit, &opts)
+	case "top":
+		posts, _, err = c.client.Subreddit.TopPosts(ctx, subreddit, &reddit.ListPostOptions{ListOptions: opts, Time: "day"})
+	case "rising":
+		posts, _, err = c.client.Subreddit.RisingPosts(ctx, subreddit, &opts)
+	default:
+		posts, _, err = c.client.Subreddit.HotPosts(ctx, subreddit, &opts)
+	}
+	return posts, err
+}
+
+// toRawNews converts a post to the standard news format
+func toRawNews(subreddit config.SubredditConfig, post *reddit.Post, fetchedAt time.Time) models.RawNews {
+	metadata := map[string]interface{}{
+		"author":           post.Author,
+		"score":            post.Score,
+		"numberOfComments": post.NumberOfComments,
+		"isNSFW":           post.NSFW,
+		"upvoteRatio":      post.UpvoteRatio,
+		"subreddit":        subreddit.Name,
+	}
+	if !post.IsSelfPost && post.URL != "" {
+		// The item URL is the discussion; link posts also point at the article
+		metadata["linkURL"] = post.URL
+	}
+
+	return models.RawNews{
+		SourceType:  "reddit",
+		SourceID:    post.ID,
+		SourceName:  subreddit.Name,
+		SourceURL:   subreddit.URL, // Using URL from config
+		Title:       post.Title,
+		Content:     post.Body, // Markdown of self posts, empty for link posts
+		URL:         fmt.Sprintf("https://www.reddit.com%s", post.Permalink),
+		PublishedAt: post.Created.Time,
+		FetchedAt:   fetchedAt,
+		Metadata:    metadata,
+	}
 }
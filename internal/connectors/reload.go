@@ -0,0 +1,102 @@
+package connectors
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// ReloadResult describes which connectors were touched by a config reload
+type ReloadResult struct {
+	Created []string `json:"created,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Stopped []string `json:"stopped,omitempty"`
+}
+
+// Changed reports whether the reload touched any connector
+func (r ReloadResult) Changed() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Stopped) > 0
+}
+
+// Reloader applies new connectors configurations to a running ConnectorService
+type Reloader struct {
+	mu              sync.Mutex
+	service         *ConnectorService
+	stateRepository models.ChannelStateRepository
+	transport       http.RoundTripper
+	current         *config.ConnectorsConfig
+}
+
+// NewReloader creates a reloader for a service that was built from the given config
+func NewReloader(service *ConnectorService, stateRepo models.ChannelStateRepository, transport http.RoundTripper, current *config.ConnectorsConfig) *Reloader {
+	return &Reloader{
+		service:         service,
+		stateRepository: stateRepo,
+		transport:       transport,
+		current:         current,
+	}
+}
+
+// Apply diffs the new config against the running one and creates, updates or stops
+// only the affected connectors. Every new or changed connector is built before the
+// service is touched, so an invalid config leaves the previous one running.
+func (r *Reloader) Apply(cfg *config.ConnectorsConfig) (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result ReloadResult
+	oldSections := EnabledSections(r.current)
+	newSections := EnabledSections(cfg)
+	factory := NewFactory(cfg, r.stateRepository, r.transport)
+
+	built := make(map[string]models.NewsConnector)
+	for _, name := range sortedKeys(newSections) {
+		oldSection, exists := oldSections[name]
+		if exists && reflect.DeepEqual(oldSection, newSections[name]) && r.service.HasConnector(name) {
+			continue
+		}
+
+		connector, err := factory.CreateConnector(name)
+		if err != nil {
+			return ReloadResult{}, fmt.Errorf("failed to create %s connector: %w", name, err)
+		}
+		built[name] = connector
+
+		if exists {
+			result.Updated = append(result.Updated, name)
+		} else {
+			result.Created = append(result.Created, name)
+		}
+	}
+
+	for _, name := range sortedKeys(oldSections) {
+		if _, exists := newSections[name]; !exists {
+			result.Stopped = append(result.Stopped, name)
+		}
+	}
+
+	for name, connector := range built {
+		r.service.SetConnector(name, connector)
+	}
+	for _, name := range result.Stopped {
+		r.service.RemoveConnector(name)
+	}
+
+	r.current = cfg
+	return result, nil
+}
+
+// sortedKeys returns the keys of a sections map in a stable order
+func sortedKeys(sections map[string]interface{}) []string {
+	keys := make([]string, 0, len(sections))
+	for key := range sections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package connectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubStateRepo struct{}
+
+func (stubStateRepo) GetChannelState(ctx context.Context, channelID string) (*models.ChannelState, error) {
+	return &models.ChannelState{ChannelID: channelID}, nil
+}
+
+func (stubStateRepo) UpdateChannelState(ctx context.Context, state *models.ChannelState) error {
+	return nil
+}
+
+func redditConfig(limit int) *config.ConnectorsConfig {
+	return &config.ConnectorsConfig{
+		Reddit: config.RedditConfig{
+			Enabled: true,
+			Subreddits: []config.SubredditConfig{
+				{Name: "golang", URL: "https://www.reddit.com/r/golang"},
+			},
+			Settings: config.RedditSettings{
+				Timeout: 10 * time.Second,
+				Limit:   limit,
+				Sort:    "top",
+			},
+		},
+	}
+}
+
+func TestReloaderApply(t *testing.T) {
+	initial := redditConfig(10)
+	connectorMap, err := NewFactory(initial, stubStateRepo{}, nil).CreateAllConnectors()
+	require.NoError(t, err)
+
+	service := NewConnectorService(connectorMap, nil, nil, nil, nil, RunSettings{})
+	reloader := NewReloader(service, stubStateRepo{}, nil, initial)
+
+	// Unchanged config touches nothing
+	result, err := reloader.Apply(redditConfig(10))
+	require.NoError(t, err)
+	assert.False(t, result.Changed())
+
+	// A changed section rebuilds only that connector
+	result, err = reloader.Apply(redditConfig(25))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reddit"}, result.Updated)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Stopped)
+
+	// Disabling a connector stops it
+	disabled := redditConfig(25)
+	disabled.Reddit.Enabled = false
+	result, err = reloader.Apply(disabled)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reddit"}, result.Stopped)
+	assert.False(t, service.HasConnector("reddit"))
+
+	// Enabling it again creates it
+	result, err = reloader.Apply(redditConfig(25))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reddit"}, result.Created)
+	assert.Equal(t, []string{"reddit"}, service.ConnectorNames())
+}
@@ -0,0 +1,476 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/temoto/robotstxt"
+)
+
+// defaultUserAgent is sent when the config does not set one
+const defaultUserAgent = "NewsAggregator/1.0"
+
+// minSeenURLs is the lower bound of remembered article URLs per site
+const minSeenURLs = 1000
+
+// robotsTTL is how long a parsed robots.txt is reused before it is fetched again
+const robotsTTL = 24 * time.Hour
+
+// fallbackDateLayouts are tried when a site has no date_format
+var fallbackDateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// ErrDisallowed is returned for pages that robots.txt does not allow us to fetch
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsEntry is a cached robots.txt group for a host
+type robotsEntry struct {
+	group     *robotstxt.Group
+	fetchedAt time.Time
+}
+
+// Connector implements NewsConnector for sites without a feed, using CSS selectors
+type Connector struct {
+	client          *http.Client
+	userAgent       string
+	crawlDelay      time.Duration
+	maxItems        int
+	sites           []config.SiteConfig
+	stateRepository models.ChannelStateRepository
+
+	mu          sync.Mutex
+	robots      map[string]robotsEntry
+	lastRequest map[string]time.Time
+}
+
+// New creates a new web scraping connector
+func New(cfg config.ScraperConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("scraper connector is disabled in config")
+	}
+
+	userAgent := cfg.Settings.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		userAgent:       userAgent,
+		crawlDelay:      cfg.Settings.CrawlDelay,
+		maxItems:        cfg.Settings.MaxItems,
+		sites:           cfg.Sites,
+		stateRepository: stateRepo,
+		robots:          make(map[string]robotsEntry),
+		lastRequest:     make(map[string]time.Time),
+	}, nil
+}
+
+// GetNews scrapes every configured site and returns the articles not seen before.
+// A failing site is logged and skipped; an error is returned only if every site failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, site := range c.sites {
+		news, err := c.GetSourceNews(ctx, site.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to scrape %s: %v", site.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to scrape %s: %w", site.Name, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.sites) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured sites
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.sites))
+	for _, site := range c.sites {
+		names = append(names, site.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named site only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named site with the state that
+// marks its articles as seen, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, site := range c.sites {
+		if site.Name == name {
+			return c.scrapeSite(ctx, site)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// listItem is an article link found on a list page
+type listItem struct {
+	url   string
+	title string
+	date  string
+}
+
+// scrapeSite walks the list pages of a site and fetches the unseen articles
+func (c *Connector) scrapeSite(ctx context.Context, site config.SiteConfig) (models.NewsBatch, error) {
+	channelID := "scraper:" + site.Name
+	state, err := c.stateRepository.GetChannelState(ctx, channelID)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+	seen := state.SeenSet()
+
+	items, err := c.collectItems(ctx, site, seen)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	fetchedAt := time.Now()
+	var news []models.RawNews
+	var fetched []string
+	for _, item := range items {
+		article, err := c.fetchArticle(ctx, site, item, fetchedAt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return models.NewsBatch{}, ctx.Err()
+			}
+			if errors.Is(err, ErrDisallowed) {
+				// Never going to be allowed; do not retry it on every run
+				fetched = append(fetched, item.url)
+				continue
+			}
+			// Leave it unseen so the next run retries it
+			log.Printf("Failed to fetch article %s: %v", item.url, err)
+			continue
+		}
+		fetched = append(fetched, item.url)
+		news = append(news, *article)
+	}
+
+	state.MarkSeen(c.seenLimit(), fetched...)
+	if len(fetched) > 0 {
+		state.LastMessageID = fetched[0]
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// collectItems reads up to site.MaxPages list pages and returns at most c.maxItems
+// unseen items in page order. It stops early on a page with nothing new.
+func (c *Connector) collectItems(ctx context.Context, site config.SiteConfig, seen map[string]bool) ([]listItem, error) {
+	maxPages := site.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+
+	var items []listItem
+	queued := make(map[string]bool)
+	pageURL := site.URL
+	for page := 0; page < maxPages && pageURL != ""; page++ {
+		doc, finalURL, err := c.fetchDocument(ctx, pageURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch list page %s: %w", pageURL, err)
+		}
+
+		found := 0
+		doc.Find(site.Selectors.Item).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			link := s.Find(site.Selectors.Link).First()
+			href, ok := link.Attr("href")
+			if !ok {
+				return true
+			}
+			articleURL, err := resolve(finalURL, href)
+			if err != nil || seen[articleURL] || queued[articleURL] {
+				return true
+			}
+
+			title := link.Text()
+			if site.Selectors.Title != "" {
+				title = s.Find(site.Selectors.Title).First().Text()
+			}
+			var date string
+			if site.Selectors.Date != "" {
+				date = dateText(s.Find(site.Selectors.Date).First())
+			}
+
+			queued[articleURL] = true
+			items = append(items, listItem{url: articleURL, title: collapse(title), date: date})
+			found++
+			return len(items) < c.maxItems
+		})
+		if found == 0 || len(items) >= c.maxItems || site.Selectors.Next == "" {
+			break
+		}
+
+		pageURL = ""
+		if href, ok := doc.Find(site.Selectors.Next).First().Attr("href"); ok {
+			if next, err := resolve(finalURL, href); err == nil {
+				pageURL = next
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// fetchArticle downloads an article page and builds its RawNews
+func (c *Connector) fetchArticle(ctx context.Context, site config.SiteConfig, item listItem, fetchedAt time.Time) (*models.RawNews, error) {
+	doc, _, err := c.fetchDocument(ctx, item.url)
+	if err != nil {
+		return nil, err
+	}
+
+	title := item.title
+	if title == "" {
+		selector := site.Selectors.Title
+		if selector == "" {
+			selector = "title"
+		}
+		title = collapse(doc.Find(selector).First().Text())
+	}
+	if title == "" {
+		return nil, fmt.Errorf("no title found")
+	}
+
+	date := item.date
+	if date == "" && site.Selectors.Date != "" {
+		date = dateText(doc.Find(site.Selectors.Date).First())
+	}
+	publishedAt := fetchedAt
+	if date != "" {
+		parsed, err := parseDate(date, site.DateFormat)
+		if err != nil {
+			log.Printf("Failed to parse date %q of %s: %v", date, item.url, err)
+		} else {
+			publishedAt = parsed
+		}
+	}
+
+	body, err := doc.Find(site.Selectors.Body).First().Html()
+	if err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"site": site.Name,
+	}
+	if href, ok := doc.Find(`link[rel="canonical"]`).First().Attr("href"); ok {
+		if canonicalURL, err := resolve(item.url, href); err == nil {
+			metadata["canonicalURL"] = canonicalURL
+		}
+	}
+
+	return &models.RawNews{
+		SourceType:  "scraper",
+		SourceID:    item.url,
+		SourceName:  site.Name,
+		SourceURL:   site.URL,
+		Title:       title,
+		Content:     strings.TrimSpace(body),
+		URL:         item.url,
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Metadata:    metadata,
+	}, nil
+}
+
+// fetchDocument GETs a page once robots.txt and the crawl delay allow it, and
+// returns the parsed document with its final URL after redirects
+func (c *Connector) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, string, error) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	group, err := c.robotsGroup(ctx, parsed)
+	if err != nil {
+		return nil, "", err
+	}
+	if !group.Test(parsed.RequestURI()) {
+		return nil, "", ErrDisallowed
+	}
+
+	delay := c.crawlDelay
+	if group.CrawlDelay > delay {
+		delay = group.CrawlDelay
+	}
+	if err := c.wait(ctx, parsed.Host, delay); err != nil {
+		return nil, "", err
+	}
+
+	resp, err := c.get(ctx, pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return doc, resp.Request.URL.String(), nil
+}
+
+// robotsGroup returns the robots.txt rules that apply to us on the page's host
+func (c *Connector) robotsGroup(ctx context.Context, page *url.URL) (*robotstxt.Group, error) {
+	host := page.Scheme + "://" + page.Host
+
+	c.mu.Lock()
+	entry, ok := c.robots[host]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < robotsTTL {
+		return entry.group, nil
+	}
+
+	resp, err := c.get(ctx, host+"/robots.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// FromResponse allows everything on 4xx and disallows everything on 5xx
+	robots, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse robots.txt: %w", err)
+	}
+	group := robots.FindGroup(c.userAgent)
+
+	c.mu.Lock()
+	c.robots[host] = robotsEntry{group: group, fetchedAt: time.Now()}
+	c.lastRequest[page.Host] = time.Now()
+	c.mu.Unlock()
+
+	return group, nil
+}
+
+// wait blocks until delay has passed since the previous request to host
+func (c *Connector) wait(ctx context.Context, host string, delay time.Duration) error {
+	c.mu.Lock()
+	next := c.lastRequest[host].Add(delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.lastRequest[host] = next
+	c.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// get performs a GET request with the configured user agent
+func (c *Connector) get(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	return c.client.Do(req)
+}
+
+// seenLimit is how many article URLs are remembered per site
+func (c *Connector) seenLimit() int {
+	limit := 10 * c.maxItems
+	if limit < minSeenURLs {
+		limit = minSeenURLs
+	}
+	return limit
+}
+
+// resolve makes href absolute against base and drops the fragment
+func resolve(base, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	resolved := baseURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return "", fmt.Errorf("unsupported link %q", href)
+	}
+	resolved.Fragment = ""
+	return resolved.String(), nil
+}
+
+// dateText prefers the machine-readable datetime attribute of <time> elements
+func dateText(s *goquery.Selection) string {
+	if value, ok := s.Attr("datetime"); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return collapse(s.Text())
+}
+
+// parseDate parses value with layout, or with the fallback layouts when layout is empty
+func parseDate(value, layout string) (time.Time, error) {
+	if layout != "" {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	for _, fallback := range fallbackDateLayouts {
+		if t, err := time.Parse(fallback, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no layout matched")
+}
+
+// collapse trims text and collapses runs of whitespace into single spaces
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
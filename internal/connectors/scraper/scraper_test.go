@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listPage1 = `<html><body>
+<div class="post"><a class="title" href="/posts/one"><span class="headline">First  post</span></a><span class="date">March 2, 2026</span></div>
+<div class="post"><a class="title" href="/posts/one#comments">12 comments</a></div>
+<div class="post"><a class="title" href="/private/secret">Secret post</a></div>
+<a class="next" href="/news?page=2">Older</a>
+</body></html>`
+
+const listPage2 = `<html><body>
+<div class="post"><a class="title" href="/posts/two"><img src="/two.png"></a></div>
+</body></html>`
+
+const articleTwo = `<html><head><title>Ignored</title></head><body>
+<h1 class="headline">Second post, full title</h1>
+<time datetime="2026-03-01T08:00:00Z">yesterday</time>
+<article><p>Body of <b>two</b></p></article>
+</body></html>`
+
+// fakeSite serves a small blog and records the request times of every path
+type fakeSite struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+}
+
+func (f *fakeSite) hits(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests[path])
+}
+
+func newFakeSite(t *testing.T) (*httptest.Server, *fakeSite) {
+	t.Helper()
+	site := &fakeSite{requests: make(map[string][]time.Time)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.mu.Lock()
+		site.requests[r.URL.Path] = append(site.requests[r.URL.Path], time.Now())
+		site.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+		case "/news":
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(listPage2))
+				return
+			}
+			w.Write([]byte(listPage1))
+		case "/posts/one":
+			w.Write([]byte(`<html><body><article><p>Body of one</p></article></body></html>`))
+		case "/posts/two":
+			w.Write([]byte(articleTwo))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, site
+}
+
+func newTestConnector(t *testing.T, site config.SiteConfig, delay time.Duration) *Connector {
+	t.Helper()
+	connector, err := New(config.ScraperConfig{
+		Enabled: true,
+		Sites:   []config.SiteConfig{site},
+		Settings: config.ScraperSettings{
+			Timeout:    5 * time.Second,
+			CrawlDelay: delay,
+			MaxItems:   10,
+		},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	return connector
+}
+
+func TestGetNews(t *testing.T) {
+	server, fake := newFakeSite(t)
+	site := config.SiteConfig{
+		Name: "Example Blog",
+		URL:  server.URL + "/news",
+		Selectors: config.ScraperSelectors{
+			Item:  ".post",
+			Link:  "a.title",
+			Title: ".headline",
+			Date:  ".date, time",
+			Body:  "article",
+			Next:  "a.next",
+		},
+		DateFormat: "January 2, 2006",
+		MaxPages:   3,
+	}
+	connector := newTestConnector(t, site, 0)
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+
+	assert.Equal(t, "scraper", news[0].SourceType)
+	assert.Equal(t, "First post", news[0].Title)
+	assert.Equal(t, server.URL+"/posts/one", news[0].SourceID)
+	assert.Equal(t, server.URL+"/posts/one", news[0].URL)
+	assert.Equal(t, "Example Blog", news[0].SourceName)
+	assert.Equal(t, "<p>Body of one</p>", news[0].Content)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), news[0].PublishedAt)
+
+	// Title and date fall back to the article page
+	assert.Equal(t, "Second post, full title", news[1].Title)
+	assert.Equal(t, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), news[1].PublishedAt)
+	assert.Equal(t, "<p>Body of <b>two</b></p>", news[1].Content)
+
+	// robots.txt is fetched once and disallowed pages are never requested
+	assert.Equal(t, 1, fake.hits("/robots.txt"))
+	assert.Equal(t, 0, fake.hits("/private/secret"))
+
+	// A second run only reads the first list page, which has nothing new
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+	assert.Equal(t, 1, fake.hits("/posts/one"))
+	assert.Equal(t, 3, fake.hits("/news"))
+}
+
+func TestGetNewsCrawlDelay(t *testing.T) {
+	server, fake := newFakeSite(t)
+	delay := 50 * time.Millisecond
+	connector := newTestConnector(t, config.SiteConfig{
+		Name:      "Example Blog",
+		URL:       server.URL + "/news",
+		Selectors: config.ScraperSelectors{Item: ".post", Link: "a.title", Body: "article"},
+	}, delay)
+
+	_, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+
+	var times []time.Time
+	for _, path := range []string{"/robots.txt", "/news", "/posts/one"} {
+		require.Equal(t, 1, fake.hits(path), path)
+		times = append(times, fake.requests[path][0])
+	}
+	for i := 1; i < len(times); i++ {
+		assert.True(t, times[i].Sub(times[i-1]) >= delay-5*time.Millisecond, "requests %d and %d are too close", i-1, i)
+	}
+}
+
+func TestGetNewsAllSitesFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	connector := newTestConnector(t, config.SiteConfig{
+		Name:      "Closed",
+		URL:       server.URL + "/news",
+		Selectors: config.ScraperSelectors{Item: ".post", Link: "a", Body: "article"},
+	}, 0)
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrDisallowed.Error())
+}
@@ -2,51 +2,472 @@ package connectors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/dzianismalei/infoBro/internal/breaker"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
 	"github.com/dzianismalei/infoBro/internal/models"
 )
 
+// ErrUnknownSource is returned for a connector or source that is not registered
+var ErrUnknownSource = errors.New("unknown source")
+
 // ConnectorService manages running connectors and storing their results
 type ConnectorService struct {
+	mu         sync.RWMutex
 	connectors map[string]models.NewsConnector
 	storage    models.NewsStorage
-	queue      models.NewsQueue
+	// states saves the checkpoints of streamed runs
+	states models.ChannelStateRepository
+	queue  models.NewsQueue
+	// breakers pauses failing sources of connectors that implement models.SourceConnector; nil disables it
+	breakers *breaker.Manager
+	settings RunSettings
+	// slots is the worker pool shared by all runs; nil when the number of workers is unlimited
+	slots chan struct{}
+}
+
+// RunSettings bounds connector runs; zero values remove the limit
+type RunSettings struct {
+	// Workers is the number of connectors and sources fetched at the same time
+	Workers int
+	// ConnectorTimeout bounds the run of a single connector
+	ConnectorTimeout time.Duration
+	// Deadline bounds a run of all connectors
+	Deadline time.Duration
 }
 
 // NewConnectorService creates a new connector service
-func NewConnectorService(connectors map[string]models.NewsConnector, storage models.NewsStorage, queue models.NewsQueue) *ConnectorService {
+func NewConnectorService(connectors map[string]models.NewsConnector, storage models.NewsStorage, states models.ChannelStateRepository, queue models.NewsQueue, breakers *breaker.Manager, settings RunSettings) *ConnectorService {
+	if connectors == nil {
+		connectors = make(map[string]models.NewsConnector)
+	}
+	var slots chan struct{}
+	if settings.Workers > 0 {
+		slots = make(chan struct{}, settings.Workers)
+	}
 	return &ConnectorService{
 		connectors: connectors,
 		storage:    storage,
+		states:     states,
 		queue:      queue,
+		breakers:   breakers,
+		settings:   settings,
+		slots:      slots,
 	}
 }
 
-// RunConnector runs a specific connector and processes its results
-func (s *ConnectorService) RunConnector(ctx context.Context, name string) (int, error) {
-	connector, exists := s.connectors[name]
+// ConnectorNames returns the sorted names of all registered connectors
+func (s *ConnectorService) ConnectorNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.connectors))
+	for name := range s.connectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasConnector reports whether a connector is registered under the given name
+func (s *ConnectorService) HasConnector(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.connectors[name]
+	return exists
+}
+
+// SetConnector registers a connector under the given name, stopping the one it replaces
+func (s *ConnectorService) SetConnector(name string, connector models.NewsConnector) {
+	s.mu.Lock()
+	previous := s.connectors[name]
+	s.connectors[name] = connector
+	s.mu.Unlock()
+
+	if previous != nil {
+		stopConnector(name, previous)
+	}
+}
+
+// RemoveConnector stops and unregisters the named connector
+func (s *ConnectorService) RemoveConnector(name string) {
+	s.mu.Lock()
+	previous, exists := s.connectors[name]
+	delete(s.connectors, name)
+	s.mu.Unlock()
+
+	if exists {
+		stopConnector(name, previous)
+	}
+}
+
+// stopConnector releases resources held by connectors that implement io.Closer
+func stopConnector(name string, connector models.NewsConnector) {
+	closer, ok := connector.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		log.Printf("Failed to stop connector %s: %v", name, err)
+	}
+}
+
+// RunConnector runs a specific connector and processes its results. The result
+// reports the HTTP requests the connector made, also when it failed, and has the
+// status "timeout" if the connector timeout or the caller's deadline expired.
+func (s *ConnectorService) RunConnector(ctx context.Context, name string) (ConnectorResult, error) {
+	connector, exists := s.Connector(name)
 	if !exists {
-		return 0, fmt.Errorf("connector %s not found", name)
+		return ConnectorResult{Status: "error"}, fmt.Errorf("connector %s not found", name)
+	}
+
+	if s.settings.ConnectorTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.settings.ConnectorTimeout)
+		defer cancel()
+	}
+	stats := &httpfetch.Stats{}
+	ctx = httpfetch.WithStats(ctx, stats)
+	ctx = models.WithWorkerPool(ctx, workerPool{s})
+	result := ConnectorResult{Status: "error"}
+
+	if streaming, ok := connector.(models.StreamingConnector); ok {
+		// Batches are stored as they arrive, so a failed run keeps the ones before it
+		var err error
+		result.Processed, err = s.streamNews(ctx, name, streaming)
+		result.setStats(stats.Snapshot())
+		if err != nil {
+			result.Status = runStatus(ctx, err)
+			return result, fmt.Errorf("failed to stream news from %s: %w", name, err)
+		}
+		result.Status = "success"
+		return result, nil
 	}
 
 	// Get news from the connector
-	news, err := connector.GetNews(ctx)
+	var batches []models.NewsBatch
+	var err error
+	if sources, ok := connector.(models.SourceConnector); ok {
+		batches, result.Paused, err = s.getSourceNews(ctx, name, sources)
+	} else {
+		var news []models.RawNews
+		news, err = s.getNews(ctx, connector)
+		batches = []models.NewsBatch{{News: news}}
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get news from %s: %w", name, err)
+		result.Status = runStatus(ctx, err)
+		if len(batches) > 0 {
+			// The news of sources that finished before the deadline is stored and their
+			// state saved even though the run was cancelled
+			result.Processed, _ = s.storeBatches(context.WithoutCancel(ctx), name, batches)
+		}
+		result.setStats(stats.Snapshot())
+		return result, fmt.Errorf("failed to get news from %s: %w", name, err)
 	}
 
+	count, err := s.storeBatches(ctx, name, batches)
+	result.setStats(stats.Snapshot())
+	if err != nil {
+		result.Status = runStatus(ctx, err)
+		return result, err
+	}
+	result.Status = "success"
+	result.Processed = count
+	return result, nil
+}
+
+// getNews runs a connector without sources in a slot of the worker pool
+func (s *ConnectorService) getNews(ctx context.Context, connector models.NewsConnector) ([]models.RawNews, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	return connector.GetNews(ctx)
+}
+
+// streamNews runs a streaming connector in a slot of the worker pool, storing each
+// batch and then saving its checkpoint. It returns the number of news stored, also
+// when the run failed.
+func (s *ConnectorService) streamNews(ctx context.Context, name string, connector models.StreamingConnector) (int, error) {
+	if err := s.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer s.release()
+
+	processed := 0
+	err := connector.StreamNews(ctx, func(ctx context.Context, batch models.NewsBatch) error {
+		count, err := s.StoreNews(ctx, name, batch.News)
+		if err != nil {
+			return err
+		}
+		processed += count
+		return s.checkpoint(ctx, batch.State)
+	})
+	return processed, err
+}
+
+// storeBatches stores the news of all batches and then saves their states, so no
+// state accounts for news that failed to store. It returns the number of news stored.
+func (s *ConnectorService) storeBatches(ctx context.Context, name string, batches []models.NewsBatch) (int, error) {
+	var news []models.RawNews
+	for _, batch := range batches {
+		news = append(news, batch.News...)
+	}
+	count, err := s.StoreNews(ctx, name, news)
+	if err != nil {
+		return 0, err
+	}
+
+	var firstErr error
+	for _, batch := range batches {
+		if err := s.checkpoint(ctx, batch.State); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return count, firstErr
+}
+
+// checkpoint saves the state of a batch whose news is stored; nil states are skipped
+func (s *ConnectorService) checkpoint(ctx context.Context, state *models.ChannelState) error {
+	if state == nil {
+		return nil
+	}
+	if s.states == nil {
+		return fmt.Errorf("no channel state repository to checkpoint %s", state.ChannelID)
+	}
+	if err := s.states.UpdateChannelState(ctx, state); err != nil {
+		return fmt.Errorf("failed to checkpoint %s: %w", state.ChannelID, err)
+	}
+	return nil
+}
+
+// sourceResult is the outcome of fetching one source
+type sourceResult struct {
+	batch  models.NewsBatch
+	err    error
+	paused bool
+}
+
+// getSourceNews fetches the sources of a connector concurrently, each in a slot of the
+// worker pool and only if its breaker allows it. It returns a batch per fetched source
+// in source order and the names of the paused sources. Like GetNews, it fails only if
+// every fetched source failed. If ctx is done, the batches of the sources that finished
+// are returned with the context error.
+func (s *ConnectorService) getSourceNews(ctx context.Context, name string, connector models.SourceConnector) ([]models.NewsBatch, []string, error) {
+	sources := connector.Sources()
+	results := make([]sourceResult, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(result *sourceResult, source string) {
+			defer wg.Done()
+			*result = s.fetchSource(ctx, name, connector, source)
+		}(&results[i], source)
+	}
+	wg.Wait()
+
+	var batches []models.NewsBatch
+	var paused []string
+	var firstErr error
+	attempted, failed := 0, 0
+	for i, result := range results {
+		switch {
+		case result.paused:
+			paused = append(paused, sources[i])
+		case result.err != nil:
+			attempted++
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("source %s: %w", sources[i], result.err)
+			}
+		default:
+			attempted++
+			batches = append(batches, result.batch)
+		}
+	}
+
+	if len(paused) > 0 {
+		log.Printf("Skipped %d paused sources of %s: %v", len(paused), name, paused)
+	}
+	if err := ctx.Err(); err != nil {
+		return batches, paused, err
+	}
+	if failed > 0 && failed == attempted {
+		return nil, paused, firstErr
+	}
+	return batches, paused, nil
+}
+
+// fetchSource fetches one source of a connector and records the outcome in its breaker.
+// The state of sources of a models.CheckpointConnector is returned in the batch unsaved.
+func (s *ConnectorService) fetchSource(ctx context.Context, name string, connector models.SourceConnector, source string) sourceResult {
+	if s.breakers != nil {
+		allowed, err := s.breakers.Allow(ctx, name, source)
+		if err != nil {
+			log.Printf("Failed to check breaker of %s/%s: %v", name, source, err)
+		}
+		if !allowed {
+			return sourceResult{paused: true}
+		}
+	}
+
+	if err := s.acquire(ctx); err != nil {
+		return sourceResult{err: err}
+	}
+	var batch models.NewsBatch
+	var err error
+	if checkpoints, ok := connector.(models.CheckpointConnector); ok {
+		batch, err = checkpoints.GetSourceBatch(ctx, source)
+	} else {
+		batch.News, err = connector.GetSourceNews(ctx, source)
+	}
+	s.release()
+	if err != nil && ctx.Err() != nil {
+		// Cancelled fetches say nothing about the health of the source
+		return sourceResult{err: err}
+	}
+
+	if s.breakers != nil {
+		if recordErr := s.breakers.Record(ctx, name, source, err); recordErr != nil {
+			log.Printf("Failed to update breaker of %s/%s: %v", name, source, recordErr)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to fetch %s source %s: %v", name, source, err)
+	}
+	return sourceResult{batch: batch, err: err}
+}
+
+// acquire waits for a free slot in the worker pool
+func (s *ConnectorService) acquire(ctx context.Context) error {
+	if s.slots == nil {
+		return ctx.Err()
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken with acquire
+func (s *ConnectorService) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// workerPool exposes the worker pool of a service to connectors as models.WorkerPool
+type workerPool struct {
+	service *ConnectorService
+}
+
+// Acquire waits for a free slot in the worker pool
+func (p workerPool) Acquire(ctx context.Context) error {
+	return p.service.acquire(ctx)
+}
+
+// Release frees a slot taken with Acquire
+func (p workerPool) Release() {
+	p.service.release()
+}
+
+// runStatus returns "timeout" for runs that failed because their deadline expired
+func runStatus(ctx context.Context, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return "error"
+}
+
+// ConnectorStatus describes a registered connector and the breakers of its failing sources
+type ConnectorStatus struct {
+	Name     string                 `json:"name"`
+	Sources  []string               `json:"sources,omitempty"`
+	Breakers []models.SourceBreaker `json:"breakers,omitempty"`
+}
+
+// Status returns the status of every registered connector, sorted by name
+func (s *ConnectorService) Status(ctx context.Context) ([]ConnectorStatus, error) {
+	var statuses []ConnectorStatus
+	for _, name := range s.ConnectorNames() {
+		connector, exists := s.Connector(name)
+		if !exists {
+			continue
+		}
+
+		status := ConnectorStatus{Name: name}
+		if sources, ok := connector.(models.SourceConnector); ok {
+			status.Sources = sources.Sources()
+		}
+		if s.breakers != nil {
+			breakers, err := s.breakers.List(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			sort.Slice(breakers, func(i, j int) bool { return breakers[i].Source < breakers[j].Source })
+			status.Breakers = breakers
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ResetBreaker closes the breaker of a source so it is fetched on the next run
+func (s *ConnectorService) ResetBreaker(ctx context.Context, name, source string) error {
+	if s.breakers == nil {
+		return fmt.Errorf("source breakers are disabled")
+	}
+	connector, exists := s.Connector(name)
+	if !exists {
+		return fmt.Errorf("%w: connector %s not found", ErrUnknownSource, name)
+	}
+	if sources, ok := connector.(models.SourceConnector); ok {
+		for _, known := range sources.Sources() {
+			if known == source {
+				return s.breakers.Reset(ctx, name, source)
+			}
+		}
+	}
+	return fmt.Errorf("%w: connector %s has no source %s", ErrUnknownSource, name, source)
+}
+
+// Connector returns the connector registered under the given name
+func (s *ConnectorService) Connector(name string) (models.NewsConnector, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	connector, exists := s.connectors[name]
+	return connector, exists
+}
+
+// StoreNews saves news fetched by the named source and adds it to the processing queue
+func (s *ConnectorService) StoreNews(ctx context.Context, name string, news []models.RawNews) (int, error) {
 	if len(news) == 0 {
 		// No new items, but not an error
 		return 0, nil
 	}
 
-	// Save the news to storage
+	// Save the news to storage; news stored before is skipped
 	ids, err := s.storage.SaveRawNews(ctx, news)
 	if err != nil {
 		return 0, fmt.Errorf("failed to save news from %s: %w", name, err)
 	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
 
 	// Add the IDs to the queue for processing
 	err = s.queue.AddToQueue(ctx, ids)
@@ -54,37 +475,53 @@ func (s *ConnectorService) RunConnector(ctx context.Context, name string) (int,
 		return 0, fmt.Errorf("failed to add news to queue from %s: %w", name, err)
 	}
 
-	return len(news), nil
+	return len(ids), nil
+}
+
+// IngestNews hands a payload pushed by source to the named push connector and stores
+// the news it accepts through the same path as polled news
+func (s *ConnectorService) IngestNews(ctx context.Context, name, source string, payload []byte, header http.Header) (int, error) {
+	connector, exists := s.Connector(name)
+	if !exists {
+		return 0, fmt.Errorf("connector %s not found", name)
+	}
+	push, ok := connector.(models.PushConnector)
+	if !ok {
+		return 0, fmt.Errorf("connector %s does not accept pushed news", name)
+	}
+
+	return push.Ingest(ctx, source, payload, header, func(ctx context.Context, news []models.RawNews) (int, error) {
+		return s.StoreNews(ctx, name, news)
+	})
 }
 
-// RunAllConnectors runs all available connectors in parallel
+// RunAllConnectors runs all available connectors in parallel, sharing the worker pool.
+// Connectors still running when the deadline expires are cancelled and reported with
+// the status "timeout"; the results of the others are kept.
 func (s *ConnectorService) RunAllConnectors(ctx context.Context) (map[string]ConnectorResult, error) {
+	if s.settings.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.settings.Deadline)
+		defer cancel()
+	}
 	results := make(map[string]ConnectorResult)
 	var wg sync.WaitGroup
 	resultMutex := sync.Mutex{}
 
-	for name := range s.connectors {
+	for _, name := range s.ConnectorNames() {
 		wg.Add(1)
 		go func(connectorName string) {
 			defer wg.Done()
 
-			count, err := s.RunConnector(ctx, connectorName)
+			result, err := s.RunConnector(ctx, connectorName)
 			
 			resultMutex.Lock()
 			defer resultMutex.Unlock()
 			
 			if err != nil {
-				results[connectorName] = ConnectorResult{
-					Status:    "error",
-					Message:   err.Error(),
-					Processed: 0,
-				}
-			} else {
-				results[connectorName] = ConnectorResult{
-					Status:    "success",
-					Processed: count,
-				}
+				result.Message = err.Error()
 			}
+			results[connectorName] = result
 		}(name)
 	}
 
@@ -97,4 +534,21 @@ type ConnectorResult struct {
 	Status    string `json:"status"`
 	Message   string `json:"message,omitempty"`
 	Processed int    `json:"processed"`
-}
\ No newline at end of file
+	// Paused lists the sources skipped because their breaker is open
+	Paused []string `json:"paused,omitempty"`
+	// Requests counts the HTTP requests sent, including retries
+	Requests int64 `json:"requests,omitempty"`
+	// CacheHits counts conditional requests answered with 304 Not Modified
+	CacheHits int64 `json:"cache_hits,omitempty"`
+	Retries   int64 `json:"retries,omitempty"`
+	// Throttled counts 429 and 503 responses and requests held back by the local rate limit
+	Throttled int64 `json:"throttled,omitempty"`
+}
+
+// setStats copies the HTTP counters of a run into the result
+func (r *ConnectorResult) setStats(stats httpfetch.StatsSnapshot) {
+	r.Requests = stats.Requests
+	r.CacheHits = stats.CacheHits
+	r.Retries = stats.Retries
+	r.Throttled = stats.Throttled + stats.Delayed
+}
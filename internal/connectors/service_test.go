@@ -0,0 +1,387 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/breaker"
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/connectors/youtube"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubSourceConnector fails the sources listed in failing and counts fetches per source
+type stubSourceConnector struct {
+	mu      sync.Mutex
+	sources []string
+	failing map[string]bool
+	fetches map[string]int
+}
+
+func (c *stubSourceConnector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	return nil, errors.New("GetNews is not used for source connectors")
+}
+
+func (c *stubSourceConnector) Sources() []string {
+	return c.sources
+}
+
+func (c *stubSourceConnector) GetSourceNews(ctx context.Context, source string) ([]models.RawNews, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetches[source]++
+	if c.failing[source] {
+		return nil, errors.New("unexpected status 503")
+	}
+	return nil, nil
+}
+
+func TestRunConnectorPausesFailingSources(t *testing.T) {
+	ctx := context.Background()
+	connector := &stubSourceConnector{
+		sources: []string{"golang", "dead"},
+		failing: map[string]bool{"dead": true},
+		fetches: make(map[string]int),
+	}
+	breakers := breaker.New(storage.NewMemoryBreakerRepository(), breaker.Settings{Threshold: 2, CoolDown: time.Minute, MaxCoolDown: time.Hour})
+	service := NewConnectorService(map[string]models.NewsConnector{"stub": connector}, nil, nil, nil, breakers, RunSettings{})
+
+	for run := 0; run < 3; run++ {
+		result, err := service.RunConnector(ctx, "stub")
+		require.NoError(t, err, "a failing source does not fail the connector")
+		assert.Equal(t, "success", result.Status)
+		if run == 2 {
+			assert.Equal(t, []string{"dead"}, result.Paused)
+		}
+	}
+	assert.Equal(t, 3, connector.fetches["golang"])
+	assert.Equal(t, 2, connector.fetches["dead"], "the open breaker skips the third fetch")
+
+	statuses, err := service.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, []string{"golang", "dead"}, statuses[0].Sources)
+	require.Len(t, statuses[0].Breakers, 1)
+	assert.Equal(t, models.BreakerOpen, statuses[0].Breakers[0].State)
+
+	require.NoError(t, service.ResetBreaker(ctx, "stub", "dead"))
+	_, err = service.RunConnector(ctx, "stub")
+	require.NoError(t, err)
+	assert.Equal(t, 3, connector.fetches["dead"])
+
+	err = service.ResetBreaker(ctx, "stub", "missing")
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+}
+
+func TestRunConnectorFailsWhenEverySourceFails(t *testing.T) {
+	connector := &stubSourceConnector{
+		sources: []string{"dead"},
+		failing: map[string]bool{"dead": true},
+		fetches: make(map[string]int),
+	}
+	breakers := breaker.New(storage.NewMemoryBreakerRepository(), breaker.Settings{Threshold: 3, CoolDown: time.Minute, MaxCoolDown: time.Hour})
+	service := NewConnectorService(map[string]models.NewsConnector{"stub": connector}, nil, nil, nil, breakers, RunSettings{})
+
+	result, err := service.RunConnector(context.Background(), "stub")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source dead")
+	assert.Equal(t, "error", result.Status)
+}
+
+// blockingConnector waits for delay or until ctx is done, and tracks how many of its
+// fetches run at the same time in the shared counter
+type blockingConnector struct {
+	sources []string
+	delay   time.Duration
+	running *concurrency
+}
+
+// concurrency records the highest number of fetches running at once
+type concurrency struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *concurrency) enter() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+}
+
+func (c *concurrency) leave() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current--
+}
+
+func (c *blockingConnector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	return c.GetSourceNews(ctx, "")
+}
+
+func (c *blockingConnector) Sources() []string {
+	return c.sources
+}
+
+func (c *blockingConnector) GetSourceNews(ctx context.Context, source string) ([]models.RawNews, error) {
+	if c.running != nil {
+		c.running.enter()
+		defer c.running.leave()
+	}
+	select {
+	case <-time.After(c.delay):
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// plainConnector hides the sources of a connector
+type plainConnector struct {
+	models.NewsConnector
+}
+
+func TestRunAllConnectorsBoundsWorkers(t *testing.T) {
+	running := &concurrency{}
+	service := NewConnectorService(map[string]models.NewsConnector{
+		"first":  &blockingConnector{sources: []string{"a", "b", "c"}, delay: 20 * time.Millisecond, running: running},
+		"second": &blockingConnector{sources: []string{"a", "b"}, delay: 20 * time.Millisecond, running: running},
+		"plain":  plainConnector{&blockingConnector{delay: 20 * time.Millisecond, running: running}},
+	}, nil, nil, nil, nil, RunSettings{Workers: 2})
+
+	results, err := service.RunAllConnectors(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for name, result := range results {
+		assert.Equal(t, "success", result.Status, name)
+	}
+	assert.Equal(t, 2, running.max)
+}
+
+func TestRunAllConnectorsReportsTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings RunSettings
+	}{
+		{"connector timeout", RunSettings{Workers: 4, ConnectorTimeout: 50 * time.Millisecond}},
+		{"deadline", RunSettings{Workers: 4, Deadline: 50 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewConnectorService(map[string]models.NewsConnector{
+				"fast":  &blockingConnector{sources: []string{"a"}},
+				"slow":  &blockingConnector{sources: []string{"a", "b"}, delay: time.Minute},
+				"plain": plainConnector{&blockingConnector{delay: time.Minute}},
+			}, nil, nil, nil, nil, tt.settings)
+
+			started := time.Now()
+			results, err := service.RunAllConnectors(context.Background())
+			require.NoError(t, err)
+			assert.Less(t, int64(time.Since(started)), int64(5*time.Second), "cancellation reaches the fetches")
+
+			assert.Equal(t, "success", results["fast"].Status)
+			assert.Equal(t, "timeout", results["slow"].Status)
+			assert.Contains(t, results["slow"].Message, "deadline exceeded")
+			assert.Equal(t, "timeout", results["plain"].Status)
+		})
+	}
+}
+
+// memoryNews stores raw news not stored yet and queued IDs in memory, failing once
+// failAfter saves succeeded
+type memoryNews struct {
+	mu        sync.Mutex
+	saved     []models.RawNews
+	queued    int
+	failAfter int
+	saves     int
+}
+
+func (m *memoryNews) SaveRawNews(ctx context.Context, news []models.RawNews) ([]primitive.ObjectID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failAfter > 0 && m.saves == m.failAfter {
+		return nil, errors.New("storage is down")
+	}
+	m.saves++
+	var ids []primitive.ObjectID
+	for _, item := range news {
+		if item.SourceID != "" && m.stored(item) {
+			continue
+		}
+		m.saved = append(m.saved, item)
+		ids = append(ids, primitive.NewObjectID())
+	}
+	return ids, nil
+}
+
+// stored reports whether news with the source type and ID of item was saved before
+func (m *memoryNews) stored(item models.RawNews) bool {
+	for _, saved := range m.saved {
+		if saved.SourceType == item.SourceType && saved.SourceID == item.SourceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memoryNews) AddToQueue(ctx context.Context, ids []primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queued += len(ids)
+	return nil
+}
+
+// stubStreamingConnector emits one batch per element of batches, checkpointing the
+// number of news emitted so far
+type stubStreamingConnector struct {
+	batches [][]string
+}
+
+func (c *stubStreamingConnector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	return nil, errors.New("GetNews is not used for streaming connectors")
+}
+
+func (c *stubStreamingConnector) StreamNews(ctx context.Context, emit func(context.Context, models.NewsBatch) error) error {
+	state := &models.ChannelState{ChannelID: "stub"}
+	for _, ids := range c.batches {
+		var news []models.RawNews
+		for _, id := range ids {
+			news = append(news, models.RawNews{SourceType: "stub", SourceID: id, Title: id})
+		}
+		state.LastMessageID = ids[len(ids)-1]
+		state.ProcessedMessages += len(news)
+		if err := emit(ctx, models.NewsBatch{News: news, State: state}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRunConnectorStoresStreamedBatches(t *testing.T) {
+	connector := &stubStreamingConnector{batches: [][]string{{"1", "2"}, {"3", "4"}, {"5"}}}
+	news := &memoryNews{failAfter: 2}
+	states := storage.NewMemoryStateRepository()
+	service := NewConnectorService(map[string]models.NewsConnector{"stub": connector}, news, states, news, nil, RunSettings{})
+
+	result, err := service.RunConnector(context.Background(), "stub")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage is down")
+	assert.Equal(t, "error", result.Status)
+	assert.Equal(t, 4, result.Processed, "batches stored before the failure are kept")
+	assert.Len(t, news.saved, 4)
+	assert.Equal(t, 4, news.queued)
+
+	state, err := states.GetChannelState(context.Background(), "stub")
+	require.NoError(t, err)
+	assert.Equal(t, "4", state.LastMessageID, "the checkpoint of the failed batch is not saved")
+	assert.Equal(t, 4, state.ProcessedMessages)
+
+	news.failAfter = 0
+	result, err = service.RunConnector(context.Background(), "stub")
+	require.NoError(t, err)
+	assert.Equal(t, "success", result.Status)
+	assert.Equal(t, 1, result.Processed, "news stored by the failed run is skipped")
+	assert.Len(t, news.saved, 5)
+}
+
+// stubCheckpointConnector returns the item next of every source with the state that
+// marks it as read
+type stubCheckpointConnector struct {
+	stubSourceConnector
+	next string
+}
+
+func (c *stubCheckpointConnector) GetSourceBatch(ctx context.Context, source string) (models.NewsBatch, error) {
+	return models.NewsBatch{
+		News:  []models.RawNews{{SourceType: "stub", SourceID: source + c.next, Title: c.next}},
+		State: &models.ChannelState{ChannelID: "stub:" + source, LastMessageID: c.next},
+	}, nil
+}
+
+func TestRunConnectorSavesSourceStatesAfterStoring(t *testing.T) {
+	ctx := context.Background()
+	connector := &stubCheckpointConnector{stubSourceConnector: stubSourceConnector{sources: []string{"a", "b"}}, next: "1"}
+	news := &memoryNews{}
+	states := storage.NewMemoryStateRepository()
+	service := NewConnectorService(map[string]models.NewsConnector{"stub": connector}, news, states, news, nil, RunSettings{})
+
+	result, err := service.RunConnector(ctx, "stub")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Processed)
+	for _, source := range []string{"a", "b"} {
+		state, err := states.GetChannelState(ctx, "stub:"+source)
+		require.NoError(t, err)
+		assert.Equal(t, "1", state.LastMessageID)
+	}
+
+	connector.next = "2"
+	news.failAfter = 1
+	_, err = service.RunConnector(ctx, "stub")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage is down")
+	for _, source := range []string{"a", "b"} {
+		state, err := states.GetChannelState(ctx, "stub:"+source)
+		require.NoError(t, err)
+		assert.Equal(t, "1", state.LastMessageID, "no state is saved for news that was not stored")
+	}
+}
+
+func TestRunConnectorKeepsValidatorsUntilNewsIsStored(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"feed-v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"feed-v1"`)
+		fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:yt="http://www.youtube.com/xml/schemas/2015">`+
+			`<entry><yt:videoId>abc123</yt:videoId><title>Understanding the Go scheduler</title>`+
+			`<published>2026-02-20T17:00:00+00:00</published></entry></feed>`)
+	}))
+	defer server.Close()
+
+	transport, err := httpfetch.New(httpfetch.Options{})
+	require.NoError(t, err)
+	states := storage.NewMemoryStateRepository()
+	connector, err := youtube.New(config.YouTubeConfig{
+		Enabled:  true,
+		Sources:  []config.YouTubeSourceConfig{{Name: "GopherCon", ChannelID: "UCgophercon"}},
+		Settings: config.YouTubeSettings{BaseURL: server.URL, Timeout: 5 * time.Second},
+	}, states, transport)
+	require.NoError(t, err)
+	// The first save fails
+	news := &memoryNews{failAfter: 1, saves: 1}
+	service := NewConnectorService(map[string]models.NewsConnector{"youtube": connector}, news, states, news, nil, RunSettings{})
+
+	_, err = service.RunConnector(context.Background(), "youtube")
+	require.Error(t, err)
+	assert.Empty(t, news.saved)
+
+	// The validators of the failed run were not saved, so the feed is fetched again
+	news.failAfter = 0
+	result, err := service.RunConnector(context.Background(), "youtube")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed)
+	require.Len(t, news.saved, 1)
+	assert.Equal(t, "Understanding the Go scheduler", news.saved[0].Title)
+
+	state, err := states.GetChannelState(context.Background(), "youtube:GopherCon")
+	require.NoError(t, err)
+	require.Len(t, state.Validators, 1)
+	assert.Equal(t, `"feed-v1"`, state.Validators[0].ETag)
+}
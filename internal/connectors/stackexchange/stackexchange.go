@@ -0,0 +1,446 @@
+package stackexchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/htmltext"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// seenLimit is how many question IDs are remembered per source
+const seenLimit = 2000
+
+// quotaWarning is the remaining daily quota below which every response is logged
+const quotaWarning = 50
+
+// ErrQuotaExhausted is returned while the daily request quota is used up
+var ErrQuotaExhausted = errors.New("stack exchange daily quota exhausted")
+
+// Owner is the author of a question
+type Owner struct {
+	DisplayName string `json:"display_name"`
+	Reputation  int    `json:"reputation"`
+	Link        string `json:"link"`
+}
+
+// Question is a question returned by the /questions method
+type Question struct {
+	QuestionID       int64    `json:"question_id"`
+	Title            string   `json:"title"`
+	Body             string   `json:"body"`
+	Link             string   `json:"link"`
+	Tags             []string `json:"tags"`
+	Score            int      `json:"score"`
+	AnswerCount      int      `json:"answer_count"`
+	ViewCount        int      `json:"view_count"`
+	IsAnswered       bool     `json:"is_answered"`
+	AcceptedAnswerID int64    `json:"accepted_answer_id"`
+	CreationDate     int64    `json:"creation_date"`
+	LastActivityDate int64    `json:"last_activity_date"`
+	Owner            Owner    `json:"owner"`
+}
+
+// Response is the common wrapper of every API response, including errors
+type Response struct {
+	Items          []Question `json:"items"`
+	HasMore        bool       `json:"has_more"`
+	Backoff        int        `json:"backoff"`
+	QuotaMax       int        `json:"quota_max"`
+	QuotaRemaining int        `json:"quota_remaining"`
+	ErrorID        int        `json:"error_id"`
+	ErrorName      string     `json:"error_name"`
+	ErrorMessage   string     `json:"error_message"`
+}
+
+// Connector implements NewsConnector for tagged Stack Exchange questions
+type Connector struct {
+	client          *http.Client
+	baseURL         string
+	key             string
+	userAgent       string
+	pageSize        int
+	maxPages        int
+	recheckWindow   time.Duration
+	sources         []config.StackExchangeSourceConfig
+	stateRepository models.ChannelStateRepository
+	now             func() time.Time
+
+	mu sync.Mutex
+	// notBefore is when the API allows the next request after a backoff
+	notBefore time.Time
+	// quotaResetAt is when an exhausted daily quota is replenished
+	quotaResetAt time.Time
+}
+
+// New creates a new Stack Exchange connector
+func New(cfg config.StackExchangeConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("stackexchange connector is disabled in config")
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		baseURL:         strings.TrimRight(cfg.Settings.BaseURL, "/"),
+		key:             cfg.Settings.Key.Value(),
+		userAgent:       cfg.Settings.UserAgent,
+		pageSize:        cfg.Settings.PageSize,
+		maxPages:        cfg.Settings.MaxPages,
+		recheckWindow:   cfg.Settings.RecheckWindow,
+		sources:         cfg.Sources,
+		stateRepository: stateRepo,
+		now:             time.Now,
+	}, nil
+}
+
+// GetNews returns the questions of every source that pass its filters and were not
+// reported before. A failing source is logged and skipped; an error is returned only
+// if every source failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, source := range c.sources {
+		news, err := c.GetSourceNews(ctx, source.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch Stack Exchange questions %s: %v", source.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to fetch Stack Exchange questions %s: %w", source.Name, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.sources) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured sources
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named source only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named source with the state that
+// moves its cursor past them, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, source := range c.sources {
+		if source.Name == name {
+			return c.sourceNews(ctx, source)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// sourceNews reads the questions created since the fromdate cursor kept in
+// LastMessageID. Questions that do not pass the filters yet hold the cursor back
+// for recheckWindow, so they are reported once they gain score or an answer.
+// Without a cursor only the newest page is read. A run that reads maxPages pages
+// while more questions are left keeps the cursor, and the following runs read the
+// rest, older than the oldest question read, before moving it.
+func (c *Connector) sourceNews(ctx context.Context, source config.StackExchangeSourceConfig) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "stackexchange:"+source.Name)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	cursor, err := parseCursor(state.LastMessageID)
+	if err != nil {
+		log.Printf("Ignoring malformed Stack Exchange cursor %q for %s", state.LastMessageID, source.Name)
+	}
+	maxPages := c.maxPages
+	if cursor.fromDate == 0 {
+		maxPages = 1
+	}
+
+	var questions []Question
+	hasMore := false
+	for page := 1; page <= maxPages; page++ {
+		resp, err := c.fetchQuestions(ctx, source, cursor.fromDate, cursor.toDate, page)
+		if err != nil {
+			return models.NewsBatch{}, err
+		}
+		questions = append(questions, resp.Items...)
+		hasMore = resp.HasMore
+		if !hasMore {
+			break
+		}
+	}
+
+	fetchedAt := c.now()
+	seen := state.SeenSet()
+	newest := cursor.fromDate
+	var oldest, pending int64
+	var news []models.RawNews
+	var ids []string
+	for _, question := range questions {
+		if question.CreationDate > newest {
+			newest = question.CreationDate
+		}
+		if oldest == 0 || question.CreationDate < oldest {
+			oldest = question.CreationDate
+		}
+		id := strconv.FormatInt(question.QuestionID, 10)
+		if seen[id] {
+			continue
+		}
+		if !passes(source, question) {
+			if fetchedAt.Sub(time.Unix(question.CreationDate, 0)) < c.recheckWindow && (pending == 0 || question.CreationDate < pending) {
+				pending = question.CreationDate
+			}
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		news = append(news, toRawNews(source, question, fetchedAt))
+	}
+
+	// While the rest of an earlier run is read, the newest question was already seen
+	next := newest
+	if cursor.toDate != 0 {
+		next = cursor.next
+	}
+	if pending != 0 && pending < next {
+		next = pending
+	}
+	if hasMore && cursor.fromDate != 0 && oldest != 0 {
+		cursor = sourceCursor{fromDate: cursor.fromDate, next: next, toDate: oldest}
+	} else {
+		cursor = sourceCursor{fromDate: next}
+	}
+
+	state.MarkSeen(seenLimit, ids...)
+	if cursor.fromDate != 0 {
+		state.LastMessageID = cursor.String()
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// sourceCursor is the position of a source, kept in LastMessageID as the fromdate of
+// the next request. While the rest of a run that ran out of pages is read, the cursor
+// to move to afterwards and the creation date of the oldest question read so far, the
+// todate of the requests, are appended: "<fromdate> <next> <todate>".
+type sourceCursor struct {
+	fromDate int64
+	next     int64
+	toDate   int64
+}
+
+// parseCursor parses a cursor written by sourceCursor.String; "" is the zero cursor
+func parseCursor(value string) (sourceCursor, error) {
+	if value == "" {
+		return sourceCursor{}, nil
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) != 1 && len(fields) != 3 {
+		return sourceCursor{}, fmt.Errorf("expected 1 or 3 fields, got %d", len(fields))
+	}
+	dates := make([]int64, len(fields))
+	for i, field := range fields {
+		date, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || date <= 0 {
+			return sourceCursor{}, fmt.Errorf("invalid date %q", field)
+		}
+		dates[i] = date
+	}
+	if len(dates) == 1 {
+		return sourceCursor{fromDate: dates[0]}, nil
+	}
+	return sourceCursor{fromDate: dates[0], next: dates[1], toDate: dates[2]}, nil
+}
+
+// String formats the cursor for LastMessageID
+func (c sourceCursor) String() string {
+	if c.toDate == 0 {
+		return strconv.FormatInt(c.fromDate, 10)
+	}
+	return fmt.Sprintf("%d %d %d", c.fromDate, c.next, c.toDate)
+}
+
+// passes reports whether a question meets the filters of a source
+func passes(source config.StackExchangeSourceConfig, question Question) bool {
+	if question.Score < source.MinScore {
+		return false
+	}
+	return !source.AnsweredOnly || question.IsAnswered
+}
+
+// fetchQuestions requests one page of questions carrying all tags of a source, newest first
+func (c *Connector) fetchQuestions(ctx context.Context, source config.StackExchangeSourceConfig, fromDate, toDate int64, page int) (*Response, error) {
+	query := url.Values{
+		"site":     {source.Site},
+		"tagged":   {strings.Join(source.Tags, ";")},
+		"sort":     {"creation"},
+		"order":    {"desc"},
+		"filter":   {"withbody"},
+		"page":     {strconv.Itoa(page)},
+		"pagesize": {strconv.Itoa(c.pageSize)},
+	}
+	if fromDate != 0 {
+		query.Set("fromdate", strconv.FormatInt(fromDate, 10))
+	}
+	if toDate != 0 {
+		query.Set("todate", strconv.FormatInt(toDate, 10))
+	}
+	if c.key != "" {
+		query.Set("key", c.key)
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/questions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	// Responses are always gzip compressed; the transport decompresses them
+	// as long as no Accept-Encoding header is set here
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body Response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	c.observe(&body)
+
+	if body.ErrorID != 0 {
+		return nil, fmt.Errorf("API error %d %s: %s", body.ErrorID, body.ErrorName, body.ErrorMessage)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return &body, nil
+}
+
+// observe records the backoff and quota reported in a response
+func (c *Connector) observe(body *Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if body.Backoff > 0 {
+		log.Printf("Stack Exchange asked to back off for %ds", body.Backoff)
+		c.notBefore = now.Add(time.Duration(body.Backoff) * time.Second)
+	}
+	if body.ErrorID != 0 {
+		return
+	}
+	if body.QuotaRemaining <= 0 {
+		// The quota is replenished at midnight UTC
+		c.quotaResetAt = now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		log.Printf("Stack Exchange quota exhausted, pausing until %s", c.quotaResetAt.Format(time.RFC3339))
+	} else if body.QuotaRemaining < quotaWarning {
+		log.Printf("Stack Exchange quota running low: %d of %d requests left", body.QuotaRemaining, body.QuotaMax)
+	}
+}
+
+// wait blocks until a backoff requested by the API has passed. It fails right away
+// while the daily quota is exhausted.
+func (c *Connector) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := c.now()
+	if now.Before(c.quotaResetAt) {
+		c.mu.Unlock()
+		return fmt.Errorf("%w until %s", ErrQuotaExhausted, c.quotaResetAt.Format(time.RFC3339))
+	}
+	delay := c.notBefore.Sub(now)
+	c.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// toRawNews converts a question to the standard news format
+func toRawNews(source config.StackExchangeSourceConfig, question Question, fetchedAt time.Time) models.RawNews {
+	sourceURL := ""
+	if link, err := url.Parse(question.Link); err == nil && link.Host != "" {
+		tags := make([]string, len(source.Tags))
+		for i, tag := range source.Tags {
+			tags[i] = url.PathEscape(tag)
+		}
+		sourceURL = link.Scheme + "://" + link.Host + "/questions/tagged/" + strings.Join(tags, "+")
+	}
+
+	return models.RawNews{
+		SourceType:  "stackexchange",
+		SourceID:    fmt.Sprintf("%s:%d", source.Site, question.QuestionID),
+		SourceName:  source.Name,
+		SourceURL:   sourceURL,
+		Title:       html.UnescapeString(question.Title),
+		Content:     htmltext.ToText(question.Body),
+		URL:         question.Link,
+		PublishedAt: time.Unix(question.CreationDate, 0).UTC(),
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"site":             source.Site,
+			"questionID":       question.QuestionID,
+			"tags":             question.Tags,
+			"score":            question.Score,
+			"answerCount":      question.AnswerCount,
+			"views":            question.ViewCount,
+			"isAnswered":       question.IsAnswered,
+			"accepted":         question.AcceptedAnswerID != 0,
+			"acceptedAnswerID": question.AcceptedAnswerID,
+			"author":           html.UnescapeString(question.Owner.DisplayName),
+			"authorReputation": question.Owner.Reputation,
+		},
+	}
+}
@@ -0,0 +1,258 @@
+package stackexchange
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// stubAPI serves a fixed response gzip compressed, like the real API does
+type stubAPI struct {
+	response Response
+	status   int
+	queries  []url.Values
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.queries = append(s.queries, r.URL.Query())
+	w.Header().Set("Content-Encoding", "gzip")
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+	}
+	writer := gzip.NewWriter(w)
+	defer writer.Close()
+	json.NewEncoder(writer).Encode(s.response)
+}
+
+func newTestConnector(t *testing.T, baseURL string) *Connector {
+	t.Helper()
+	connector, err := New(config.StackExchangeConfig{
+		Enabled: true,
+		Sources: []config.StackExchangeSourceConfig{{
+			Name:         "Go concurrency",
+			Site:         "stackoverflow",
+			Tags:         []string{"go", "goroutine"},
+			MinScore:     3,
+			AnsweredOnly: true,
+		}},
+		Settings: config.StackExchangeSettings{
+			BaseURL:       baseURL,
+			Key:           config.Secret("app-key"),
+			Timeout:       5 * time.Second,
+			PageSize:      50,
+			MaxPages:      3,
+			RecheckWindow: 48 * time.Hour,
+		},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+	connector.now = func() time.Time { return testNow }
+	return connector
+}
+
+func question(id int64, score int, answered bool, created time.Time) Question {
+	return Question{
+		QuestionID:   id,
+		Title:        "Why does my &quot;select&quot; block?",
+		Body:         "<p>My goroutine hangs:</p><pre><code>select {}</code></pre>",
+		Link:         "https://stackoverflow.com/questions/1/why-does-my-select-block",
+		Tags:         []string{"go", "goroutine", "channels"},
+		Score:        score,
+		AnswerCount:  1,
+		IsAnswered:   answered,
+		CreationDate: created.Unix(),
+		Owner:        Owner{DisplayName: "gopher", Reputation: 1200},
+	}
+}
+
+func TestGetNews(t *testing.T) {
+	accepted := question(3, 10, true, testNow.Add(-time.Hour))
+	accepted.AcceptedAnswerID = 33
+	young := question(2, 0, false, testNow.Add(-2*time.Hour))
+	stale := question(1, 5, false, testNow.Add(-72*time.Hour))
+
+	api := &stubAPI{response: Response{Items: []Question{accepted, young, stale}, QuotaMax: 10000, QuotaRemaining: 9000}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL)
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+
+	query := api.queries[0]
+	assert.Equal(t, "stackoverflow", query.Get("site"))
+	assert.Equal(t, "go;goroutine", query.Get("tagged"))
+	assert.Equal(t, "withbody", query.Get("filter"))
+	assert.Equal(t, "app-key", query.Get("key"))
+	assert.Empty(t, query.Get("fromdate"))
+
+	item := news[0]
+	assert.Equal(t, "stackexchange", item.SourceType)
+	assert.Equal(t, "stackoverflow:3", item.SourceID)
+	assert.Equal(t, "Go concurrency", item.SourceName)
+	assert.Equal(t, "https://stackoverflow.com/questions/tagged/go+goroutine", item.SourceURL)
+	assert.Equal(t, `Why does my "select" block?`, item.Title)
+	assert.Equal(t, "My goroutine hangs:\n\nselect {}", item.Content)
+	assert.Equal(t, accepted.CreationDate, item.PublishedAt.Unix())
+	assert.Equal(t, 10, item.Metadata["score"])
+	assert.Equal(t, 1, item.Metadata["answerCount"])
+	assert.Equal(t, true, item.Metadata["accepted"])
+	assert.Equal(t, []string{"go", "goroutine", "channels"}, item.Metadata["tags"])
+
+	// The young question holds the cursor back and is reported once it qualifies
+	young.Score = 4
+	young.IsAnswered = true
+	api.response.Items = []Question{accepted, young}
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+	assert.Equal(t, "stackoverflow:2", news[0].SourceID)
+	assert.Equal(t, "1773136800", api.queries[1].Get("fromdate"))
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+	assert.Equal(t, "1773140400", api.queries[2].Get("fromdate"))
+}
+
+// pagedAPI serves questions, newest first, filtered by fromdate and todate and split
+// into pages
+type pagedAPI struct {
+	questions []Question
+	queries   []url.Values
+}
+
+func (s *pagedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.queries = append(s.queries, query)
+	var fromDate, toDate int64
+	var page, pageSize int
+	fmt.Sscan(query.Get("fromdate"), &fromDate)
+	fmt.Sscan(query.Get("todate"), &toDate)
+	fmt.Sscan(query.Get("page"), &page)
+	fmt.Sscan(query.Get("pagesize"), &pageSize)
+
+	var matching []Question
+	for _, q := range s.questions {
+		if q.CreationDate >= fromDate && (toDate == 0 || q.CreationDate <= toDate) {
+			matching = append(matching, q)
+		}
+	}
+	start := (page - 1) * pageSize
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + pageSize
+	if end > len(matching) {
+		end = len(matching)
+	}
+	json.NewEncoder(w).Encode(Response{Items: matching[start:end], HasMore: end < len(matching), QuotaMax: 10000, QuotaRemaining: 9000})
+}
+
+func TestGetNewsResumesUnreadPages(t *testing.T) {
+	api := &pagedAPI{questions: []Question{question(1, 10, true, testNow.Add(-10*time.Hour))}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL)
+	connector.pageSize = 2
+	ctx := context.Background()
+
+	news, err := connector.GetNews(ctx)
+	require.NoError(t, err)
+	require.Len(t, news, 1)
+
+	// 8 new questions are more than the 3 pages of 2 a run reads
+	for id := int64(2); id <= 9; id++ {
+		api.questions = append([]Question{question(id, 10, true, testNow.Add(time.Duration(id-11)*time.Hour))}, api.questions...)
+	}
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	assert.Len(t, news, 6)
+	state, err := connector.stateRepository.GetChannelState(ctx, "stackexchange:Go concurrency")
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%d %d %d", api.questions[8].CreationDate, api.questions[0].CreationDate, api.questions[5].CreationDate),
+		state.LastMessageID, "the cursor stays until the older questions are read")
+
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, item := range news {
+		ids = append(ids, item.SourceID)
+	}
+	assert.Equal(t, []string{"stackoverflow:3", "stackoverflow:2"}, ids)
+	last := api.queries[len(api.queries)-1]
+	assert.Equal(t, strconv.FormatInt(api.questions[5].CreationDate, 10), last.Get("todate"))
+
+	news, err = connector.GetNews(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, news)
+	last = api.queries[len(api.queries)-1]
+	assert.Equal(t, strconv.FormatInt(api.questions[0].CreationDate, 10), last.Get("fromdate"))
+	assert.Empty(t, last.Get("todate"))
+}
+
+func TestParseCursor(t *testing.T) {
+	cursor, err := parseCursor("100 300 200")
+	require.NoError(t, err)
+	assert.Equal(t, sourceCursor{fromDate: 100, next: 300, toDate: 200}, cursor)
+	assert.Equal(t, "100 300 200", cursor.String())
+
+	cursor, err = parseCursor("100")
+	require.NoError(t, err)
+	assert.Equal(t, "100", cursor.String())
+
+	for _, malformed := range []string{"yesterday", "100 300", "100 300 -1"} {
+		_, err := parseCursor(malformed)
+		assert.Error(t, err, malformed)
+	}
+}
+
+func TestGetNewsQuotaAndBackoff(t *testing.T) {
+	api := &stubAPI{response: Response{Backoff: 10, QuotaMax: 300, QuotaRemaining: 0}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL)
+
+	_, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, testNow.Add(10*time.Second), connector.notBefore)
+
+	// Further requests fail without reaching the API until midnight UTC
+	_, err = connector.GetNews(context.Background())
+	assert.True(t, errors.Is(err, ErrQuotaExhausted), "got %v", err)
+	assert.Len(t, api.queries, 1)
+
+	connector.now = func() time.Time { return time.Date(2026, 3, 11, 0, 0, 1, 0, time.UTC) }
+	_, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, api.queries, 2)
+}
+
+func TestGetNewsAPIError(t *testing.T) {
+	api := &stubAPI{
+		status:   http.StatusBadRequest,
+		response: Response{ErrorID: 400, ErrorName: "bad_parameter", ErrorMessage: "site is required"},
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	connector := newTestConnector(t, server.URL)
+
+	_, err := connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API error 400 bad_parameter: site is required")
+}
@@ -0,0 +1,265 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// Request headers set by pushing sources
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of
+	// "<timestamp>.<body>" keyed with the source secret
+	SignatureHeader = "X-Infobro-Signature"
+	// TimestampHeader carries the signing time in Unix seconds
+	TimestampHeader = "X-Infobro-Timestamp"
+	// IdempotencyHeader optionally carries a key that makes retries of a request no-ops
+	IdempotencyHeader = "Idempotency-Key"
+)
+
+// minSeenKeys is how many idempotency keys and item IDs are remembered per source
+const minSeenKeys = 10000
+
+// Errors returned by Ingest
+var (
+	ErrUnknownSource = errors.New("unknown webhook source")
+	ErrUnauthorized  = errors.New("invalid or missing signature")
+)
+
+// InvalidPayloadError describes why a payload was rejected
+type InvalidPayloadError struct {
+	Problems []string
+}
+
+// Error joins the problems found in the payload
+func (e *InvalidPayloadError) Error() string {
+	return "invalid payload: " + strings.Join(e.Problems, "; ")
+}
+
+// Connector receives news pushed to the ingest endpoint. It implements
+// models.PushConnector; GetNews returns nothing because there is nothing to poll.
+type Connector struct {
+	secrets         map[string][]byte
+	maxBatch        int
+	maxSkew         time.Duration
+	stateRepository models.ChannelStateRepository
+	now             func() time.Time
+
+	// mu serializes ingestion so concurrent retries of a request are stored once
+	mu sync.Mutex
+}
+
+// New creates a new webhook connector
+func New(cfg config.WebhookConfig, stateRepo models.ChannelStateRepository) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("webhook connector is disabled in config")
+	}
+
+	secrets := make(map[string][]byte, len(cfg.Sources))
+	for _, source := range cfg.Sources {
+		secrets[source.Name] = []byte(source.Secret.Value())
+	}
+
+	return &Connector{
+		secrets:         secrets,
+		maxBatch:        cfg.Settings.MaxBatch,
+		maxSkew:         cfg.Settings.MaxSkew,
+		stateRepository: stateRepo,
+		now:             time.Now,
+	}, nil
+}
+
+// GetNews returns no news; webhook sources push to Ingest instead
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	return nil, nil
+}
+
+// Ingest verifies the signature of a payload pushed by source, decodes one item or a
+// batch, drops items and requests seen before, and passes the rest to store
+func (c *Connector) Ingest(ctx context.Context, source string, payload []byte, header http.Header, store func(context.Context, []models.RawNews) (int, error)) (int, error) {
+	secret, ok := c.secrets[source]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownSource, source)
+	}
+	if err := c.verify(secret, payload, header); err != nil {
+		return 0, err
+	}
+
+	news, err := c.decode(source, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.stateRepository.GetChannelState(ctx, "webhook:"+source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load webhook state: %w", err)
+	}
+	seen := state.SeenSet()
+
+	requestKey := ""
+	if key := strings.TrimSpace(header.Get(IdempotencyHeader)); key != "" {
+		requestKey = "request:" + key
+		if seen[requestKey] {
+			return 0, nil
+		}
+	}
+
+	var fresh []models.RawNews
+	var keys []string
+	for _, item := range news {
+		key := "item:" + item.SourceID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+		fresh = append(fresh, item)
+	}
+
+	stored, err := store(ctx, fresh)
+	if err != nil {
+		return 0, err
+	}
+
+	if requestKey != "" {
+		keys = append(keys, requestKey)
+	}
+	state.MarkSeen(minSeenKeys, keys...)
+	if len(fresh) > 0 {
+		state.LastMessageID = fresh[len(fresh)-1].SourceID
+	}
+	state.LastUpdateTime = c.now()
+	state.ProcessedMessages += stored
+	if err := c.stateRepository.UpdateChannelState(ctx, state); err != nil {
+		return stored, fmt.Errorf("failed to update webhook state: %w", err)
+	}
+
+	return stored, nil
+}
+
+// verify checks the HMAC signature and that the signed timestamp is recent
+func (c *Connector) verify(secret, payload []byte, header http.Header) error {
+	timestamp := header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed %s", ErrUnauthorized, TimestampHeader)
+	}
+	skew := c.now().Sub(time.Unix(seconds, 0))
+	if skew > c.maxSkew || skew < -c.maxSkew {
+		return fmt.Errorf("%w: timestamp is outside the allowed window", ErrUnauthorized)
+	}
+
+	signature := strings.TrimPrefix(header.Get(SignatureHeader), "sha256=")
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(got) == 0 {
+		return fmt.Errorf("%w: missing or malformed %s", ErrUnauthorized, SignatureHeader)
+	}
+	if !hmac.Equal(got, Sign(secret, timestamp, payload)) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of "<timestamp>.<payload>" keyed with secret
+func Sign(secret []byte, timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// decode parses one RawNews object or an array of them and validates every item
+func (c *Connector) decode(source string, payload []byte) ([]models.RawNews, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 {
+		return nil, &InvalidPayloadError{Problems: []string{"empty body"}}
+	}
+
+	var news []models.RawNews
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+	var err error
+	if trimmed[0] == '[' {
+		err = decoder.Decode(&news)
+	} else {
+		var item models.RawNews
+		err = decoder.Decode(&item)
+		news = []models.RawNews{item}
+	}
+	if err != nil {
+		return nil, &InvalidPayloadError{Problems: []string{err.Error()}}
+	}
+
+	if len(news) == 0 {
+		return nil, &InvalidPayloadError{Problems: []string{"batch is empty"}}
+	}
+	if len(news) > c.maxBatch {
+		return nil, &InvalidPayloadError{Problems: []string{fmt.Sprintf("batch has %d items, at most %d are allowed", len(news), c.maxBatch)}}
+	}
+
+	fetchedAt := c.now()
+	var problems []string
+	ids := make(map[string]bool, len(news))
+	for i := range news {
+		item := &news[i]
+		prefix := fmt.Sprintf("item %d: ", i)
+
+		if strings.TrimSpace(item.SourceID) == "" {
+			problems = append(problems, prefix+"source_id is required")
+		} else if ids[item.SourceID] {
+			problems = append(problems, prefix+fmt.Sprintf("duplicate source_id %q", item.SourceID))
+		}
+		ids[item.SourceID] = true
+		if strings.TrimSpace(item.Title) == "" {
+			problems = append(problems, prefix+"title is required")
+		}
+		if item.URL != "" && !isAbsoluteURL(item.URL) {
+			problems = append(problems, prefix+fmt.Sprintf("url %q is not an absolute http(s) URL", item.URL))
+		}
+
+		// Pushed items cannot pose as another connector, nor clash with the IDs
+		// pushed by another source, as news is stored once per type and ID
+		item.SourceType = "webhook"
+		item.SourceID = source + ":" + item.SourceID
+		if item.SourceName == "" {
+			item.SourceName = source
+		}
+		if item.PublishedAt.IsZero() {
+			item.PublishedAt = fetchedAt
+		}
+		item.FetchedAt = fetchedAt
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]interface{})
+		}
+		item.Metadata["webhookSource"] = source
+	}
+	if len(problems) > 0 {
+		return nil, &InvalidPayloadError{Problems: problems}
+	}
+
+	return news, nil
+}
+
+// isAbsoluteURL reports whether value is an absolute http or https URL
+func isAbsoluteURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestConnector(t *testing.T) *Connector {
+	t.Helper()
+	connector, err := New(config.WebhookConfig{
+		Enabled: true,
+		Sources: []config.WebhookSourceConfig{
+			{Name: "ci-bot", Secret: config.Secret("s3cret")},
+			{Name: "deploy-bot", Secret: config.Secret("d3ploy")},
+		},
+		Settings: config.WebhookSettings{MaxBatch: 3, MaxSkew: 5 * time.Minute},
+	}, storage.NewMemoryStateRepository())
+	require.NoError(t, err)
+	connector.now = func() time.Time { return testNow }
+	return connector
+}
+
+// signedHeader signs payload the way a pushing source does
+func signedHeader(secret, payload string, at time.Time) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	header := http.Header{}
+	header.Set(TimestampHeader, timestamp)
+	header.Set(SignatureHeader, "sha256="+hex.EncodeToString(Sign([]byte(secret), timestamp, []byte(payload))))
+	return header
+}
+
+// recorder is a store callback that remembers what it was given
+type recorder struct {
+	stored []models.RawNews
+	err    error
+}
+
+func (r *recorder) store(ctx context.Context, news []models.RawNews) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.stored = append(r.stored, news...)
+	return len(news), nil
+}
+
+func TestIngest(t *testing.T) {
+	connector := newTestConnector(t)
+	rec := &recorder{}
+
+	payload := `{"source_id": "build-1", "title": "Build #1 passed", "url": "https://ci.example.com/builds/1", "metadata": {"branch": "main"}}`
+	count, err := connector.Ingest(context.Background(), "ci-bot", []byte(payload), signedHeader("s3cret", payload, testNow), rec.store)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.Len(t, rec.stored, 1)
+	item := rec.stored[0]
+	assert.Equal(t, "webhook", item.SourceType)
+	assert.Equal(t, "ci-bot:build-1", item.SourceID)
+	assert.Equal(t, "ci-bot", item.SourceName)
+	assert.Equal(t, "Build #1 passed", item.Title)
+	assert.Equal(t, testNow, item.PublishedAt)
+	assert.Equal(t, testNow, item.FetchedAt)
+	assert.Equal(t, "main", item.Metadata["branch"])
+	assert.Equal(t, "ci-bot", item.Metadata["webhookSource"])
+
+	// A batch containing the same item again stores only the new one
+	batch := `[{"source_id": "build-1", "title": "Build #1 passed"}, {"source_id": "build-2", "title": "Build #2 failed", "source_type": "ci"}]`
+	count, err = connector.Ingest(context.Background(), "ci-bot", []byte(batch), signedHeader("s3cret", batch, testNow), rec.store)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "webhook", rec.stored[1].SourceType, "the source type cannot be set by the payload")
+	assert.Equal(t, "ci-bot:build-2", rec.stored[1].SourceID)
+}
+
+func TestIngestNamespacesSourceIDs(t *testing.T) {
+	connector := newTestConnector(t)
+	rec := &recorder{}
+
+	payload := `{"source_id": "42", "title": "Release 42", "source_type": "reddit"}`
+	_, err := connector.Ingest(context.Background(), "ci-bot", []byte(payload), signedHeader("s3cret", payload, testNow), rec.store)
+	require.NoError(t, err)
+	_, err = connector.Ingest(context.Background(), "deploy-bot", []byte(payload), signedHeader("d3ploy", payload, testNow), rec.store)
+	require.NoError(t, err)
+
+	require.Len(t, rec.stored, 2)
+	assert.Equal(t, "ci-bot:42", rec.stored[0].SourceID)
+	assert.Equal(t, "deploy-bot:42", rec.stored[1].SourceID)
+	for _, item := range rec.stored {
+		assert.Equal(t, "webhook", item.SourceType)
+	}
+}
+
+func TestIngestIdempotencyKey(t *testing.T) {
+	connector := newTestConnector(t)
+	failing := &recorder{err: errors.New("mongo down")}
+	rec := &recorder{}
+
+	payload := `{"source_id": "deploy-7", "title": "Deployed v7"}`
+	header := signedHeader("s3cret", payload, testNow)
+	header.Set(IdempotencyHeader, "req-7")
+
+	// A failed store does not consume the key, so the retry goes through
+	_, err := connector.Ingest(context.Background(), "ci-bot", []byte(payload), header, failing.store)
+	require.Error(t, err)
+
+	count, err := connector.Ingest(context.Background(), "ci-bot", []byte(payload), header, rec.store)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = connector.Ingest(context.Background(), "ci-bot", []byte(payload), header, rec.store)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Len(t, rec.stored, 1)
+}
+
+func TestIngestRejects(t *testing.T) {
+	connector := newTestConnector(t)
+	rec := &recorder{}
+	payload := `{"source_id": "1", "title": "ok"}`
+
+	tests := []struct {
+		name    string
+		source  string
+		payload string
+		header  http.Header
+		want    error
+	}{
+		{"unknown source", "other", payload, signedHeader("s3cret", payload, testNow), ErrUnknownSource},
+		{"wrong secret", "ci-bot", payload, signedHeader("guess", payload, testNow), ErrUnauthorized},
+		{"tampered body", "ci-bot", `{"source_id": "1", "title": "evil"}`, signedHeader("s3cret", payload, testNow), ErrUnauthorized},
+		{"stale timestamp", "ci-bot", payload, signedHeader("s3cret", payload, testNow.Add(-time.Hour)), ErrUnauthorized},
+		{"unsigned", "ci-bot", payload, http.Header{}, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := connector.Ingest(context.Background(), tt.source, []byte(tt.payload), tt.header, rec.store)
+			assert.True(t, errors.Is(err, tt.want), "got %v", err)
+		})
+	}
+
+	invalid := map[string]string{
+		`[]`: "batch is empty",
+		`[{"source_id": "1", "title": "a"}, {"source_id": "1", "title": "b"}]`:                           `item 1: duplicate source_id "1"`,
+		`{"title": "no id", "url": "ftp://example.com"}`:                                                 "item 0: source_id is required; item 0: url",
+		`{"source_id": "1", "title": "a", "unknown": true}`:                                              `unknown field "unknown"`,
+		`[{"source_id": "1", "title": "a"}, {"source_id": "2"}, {"source_id": "3"}, {"source_id": "4"}]`: "at most 3 are allowed",
+	}
+	for body, want := range invalid {
+		_, err := connector.Ingest(context.Background(), "ci-bot", []byte(body), signedHeader("s3cret", body, testNow), rec.store)
+		var payloadErr *InvalidPayloadError
+		require.True(t, errors.As(err, &payloadErr), "body %s: got %v", body, err)
+		assert.Contains(t, err.Error(), want)
+	}
+
+	assert.Empty(t, rec.stored)
+}
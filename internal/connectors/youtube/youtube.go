@@ -0,0 +1,257 @@
+package youtube
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// seenLimit is how many video IDs are remembered per source; a feed lists the latest 15
+const seenLimit = 500
+
+// Feed is a channel or playlist videos.xml document
+type Feed struct {
+	Title   string  `xml:"title"`
+	Entries []Entry `xml:"entry"`
+}
+
+// Entry is a video in a feed. Fields outside the Atom namespace use the yt and
+// media namespaces of videos.xml.
+type Entry struct {
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	Title     string `xml:"title"`
+	Link      struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Author struct {
+		Name string `xml:"name"`
+		URI  string `xml:"uri"`
+	} `xml:"author"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Group     MediaGroup `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// MediaGroup holds the media:group details of a video
+type MediaGroup struct {
+	Description string `xml:"http://search.yahoo.com/mrss/ description"`
+	Thumbnail   struct {
+		URL    string `xml:"url,attr"`
+		Width  int    `xml:"width,attr"`
+		Height int    `xml:"height,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Community struct {
+		StarRating struct {
+			Count   int     `xml:"count,attr"`
+			Average float64 `xml:"average,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ starRating"`
+		Statistics struct {
+			Views int64 `xml:"views,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ statistics"`
+	} `xml:"http://search.yahoo.com/mrss/ community"`
+}
+
+// Connector implements NewsConnector for YouTube channels and playlists
+type Connector struct {
+	client          *http.Client
+	baseURL         string
+	userAgent       string
+	sources         []config.YouTubeSourceConfig
+	stateRepository models.ChannelStateRepository
+}
+
+// New creates a new YouTube connector
+func New(cfg config.YouTubeConfig, stateRepo models.ChannelStateRepository, transport http.RoundTripper) (*Connector, error) {
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("youtube connector is disabled in config")
+	}
+
+	return &Connector{
+		client:          &http.Client{Timeout: cfg.Settings.Timeout, Transport: transport},
+		baseURL:         cfg.Settings.BaseURL,
+		userAgent:       cfg.Settings.UserAgent,
+		sources:         cfg.Sources,
+		stateRepository: stateRepo,
+	}, nil
+}
+
+// GetNews returns the videos of every source that have not been seen before.
+// A failing source is logged and skipped; an error is returned only if every source failed.
+func (c *Connector) GetNews(ctx context.Context) ([]models.RawNews, error) {
+	var allNews []models.RawNews
+	var firstErr error
+	failed := 0
+
+	for _, source := range c.sources {
+		news, err := c.GetSourceNews(ctx, source.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to fetch YouTube feed %s: %v", source.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to fetch YouTube feed %s: %w", source.Name, err)
+			}
+			failed++
+			continue
+		}
+		allNews = append(allNews, news...)
+	}
+
+	if failed > 0 && failed == len(c.sources) {
+		return nil, firstErr
+	}
+	return allNews, nil
+}
+
+// Sources returns the names of the configured feeds
+func (c *Connector) Sources() []string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// GetSourceNews returns the news of the named feed only and saves its state
+func (c *Connector) GetSourceNews(ctx context.Context, name string) ([]models.RawNews, error) {
+	batch, err := c.GetSourceBatch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stateRepository.UpdateChannelState(ctx, batch.State); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	return batch.News, nil
+}
+
+// GetSourceBatch returns the news of the named feed with the state that
+// marks its videos as seen, without saving the state
+func (c *Connector) GetSourceBatch(ctx context.Context, name string) (models.NewsBatch, error) {
+	for _, source := range c.sources {
+		if source.Name == name {
+			return c.sourceNews(ctx, source)
+		}
+	}
+	return models.NewsBatch{}, fmt.Errorf("unknown source %q", name)
+}
+
+// sourceNews reads the feed of a source and keeps the unseen videos
+func (c *Connector) sourceNews(ctx context.Context, source config.YouTubeSourceConfig) (models.NewsBatch, error) {
+	state, err := c.stateRepository.GetChannelState(ctx, "youtube:"+source.Name)
+	if err != nil {
+		return models.NewsBatch{}, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	feed, err := c.fetchFeed(httpfetch.WithState(ctx, state), source)
+	if err != nil {
+		return models.NewsBatch{}, err
+	}
+
+	fetchedAt := time.Now()
+	seen := state.SeenSet()
+	var news []models.RawNews
+	var ids []string
+	for _, entry := range feed.Entries {
+		if entry.VideoID == "" || seen[entry.VideoID] {
+			continue
+		}
+		seen[entry.VideoID] = true
+		ids = append(ids, entry.VideoID)
+		news = append(news, c.toRawNews(source, entry, fetchedAt))
+	}
+
+	state.MarkSeen(seenLimit, ids...)
+	if len(feed.Entries) > 0 {
+		state.LastMessageID = feed.Entries[0].VideoID
+	}
+	state.LastUpdateTime = fetchedAt
+	state.ProcessedMessages += len(news)
+	return models.NewsBatch{News: news, State: state}, nil
+}
+
+// fetchFeed downloads and decodes the videos.xml feed of a source
+func (c *Connector) fetchFeed(ctx context.Context, source config.YouTubeSourceConfig) (*Feed, error) {
+	query := url.Values{}
+	if source.ChannelID != "" {
+		query.Set("channel_id", source.ChannelID)
+	} else {
+		query.Set("playlist_id", source.PlaylistID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		// The feed is unchanged since the last run
+		return &Feed{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var feed Feed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// toRawNews converts a feed entry to the standard news format
+func (c *Connector) toRawNews(source config.YouTubeSourceConfig, entry Entry, fetchedAt time.Time) models.RawNews {
+	publishedAt, err := time.Parse(time.RFC3339, entry.Published)
+	if err != nil {
+		publishedAt = fetchedAt
+	}
+	videoURL := entry.Link.Href
+	if videoURL == "" {
+		videoURL = "https://www.youtube.com/watch?v=" + entry.VideoID
+	}
+	sourceURL := "https://www.youtube.com/channel/" + source.ChannelID
+	if source.PlaylistID != "" {
+		sourceURL = "https://www.youtube.com/playlist?list=" + source.PlaylistID
+	}
+
+	group := entry.Group
+	return models.RawNews{
+		SourceType:  "youtube",
+		SourceID:    entry.VideoID,
+		SourceName:  source.Name,
+		SourceURL:   sourceURL,
+		Title:       strings.TrimSpace(entry.Title),
+		Content:     strings.TrimSpace(group.Description),
+		URL:         videoURL,
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Metadata: map[string]interface{}{
+			"videoID":       entry.VideoID,
+			"channelID":     entry.ChannelID,
+			"author":        entry.Author.Name,
+			"thumbnail":     group.Thumbnail.URL,
+			"views":         group.Community.Statistics.Views,
+			"stars":         group.Community.StarRating.Count,
+			"averageRating": group.Community.StarRating.Average,
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/config"
+	"github.com/dzianismalei/infoBro/internal/httpfetch"
+	"github.com/dzianismalei/infoBro/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const channelFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <title>GopherCon</title>
+ <entry>
+  <id>yt:video:abc123</id>
+  <yt:videoId>abc123</yt:videoId>
+  <yt:channelId>UCgophercon</yt:channelId>
+  <title>Understanding the Go scheduler</title>
+  <link rel="alternate" href="https://www.youtube.com/watch?v=abc123"/>
+  <author><name>GopherCon</name><uri>https://www.youtube.com/channel/UCgophercon</uri></author>
+  <published>2026-02-20T17:00:00+00:00</published>
+  <updated>2026-02-21T08:00:00+00:00</updated>
+  <media:group>
+   <media:title>Understanding the Go scheduler</media:title>
+   <media:content url="https://www.youtube.com/v/abc123" type="application/x-shockwave-flash" width="640" height="390"/>
+   <media:thumbnail url="https://i.ytimg.com/vi/abc123/hqdefault.jpg" width="480" height="360"/>
+   <media:description>A deep dive into goroutines, Ps and Ms.</media:description>
+   <media:community>
+    <media:starRating count="1520" average="5.00" min="1" max="5"/>
+    <media:statistics views="48210"/>
+   </media:community>
+  </media:group>
+ </entry>
+ <entry>
+  <yt:videoId>def456</yt:videoId>
+  <title>Fuzzing in practice</title>
+  <published>2026-02-10T17:00:00+00:00</published>
+ </entry>
+</feed>`
+
+func TestGetNews(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(channelFeed))
+	}))
+	defer server.Close()
+
+	connector, err := New(config.YouTubeConfig{
+		Enabled:  true,
+		Sources:  []config.YouTubeSourceConfig{{Name: "GopherCon", ChannelID: "UCgophercon"}},
+		Settings: config.YouTubeSettings{BaseURL: server.URL + "/feeds/videos.xml", Timeout: 5 * time.Second},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, news, 2)
+	assert.Equal(t, "channel_id=UCgophercon", query)
+
+	video := news[0]
+	assert.Equal(t, "youtube", video.SourceType)
+	assert.Equal(t, "abc123", video.SourceID)
+	assert.Equal(t, "GopherCon", video.SourceName)
+	assert.Equal(t, "https://www.youtube.com/channel/UCgophercon", video.SourceURL)
+	assert.Equal(t, "Understanding the Go scheduler", video.Title)
+	assert.Equal(t, "A deep dive into goroutines, Ps and Ms.", video.Content)
+	assert.Equal(t, "https://www.youtube.com/watch?v=abc123", video.URL)
+	assert.Equal(t, time.Date(2026, 2, 20, 17, 0, 0, 0, time.UTC), video.PublishedAt.UTC())
+	assert.Equal(t, "https://i.ytimg.com/vi/abc123/hqdefault.jpg", video.Metadata["thumbnail"])
+	assert.Equal(t, int64(48210), video.Metadata["views"])
+	assert.Equal(t, 1520, video.Metadata["stars"])
+	assert.Equal(t, "UCgophercon", video.Metadata["channelID"])
+
+	// Entries without a link fall back to the watch URL
+	assert.Equal(t, "https://www.youtube.com/watch?v=def456", news[1].URL)
+
+	news, err = connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, news)
+}
+
+func TestGetNewsPlaylistError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "playlist_id=PLmissing", r.URL.RawQuery)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	connector, err := New(config.YouTubeConfig{
+		Enabled:  true,
+		Sources:  []config.YouTubeSourceConfig{{Name: "Missing", PlaylistID: "PLmissing"}},
+		Settings: config.YouTubeSettings{BaseURL: server.URL, Timeout: 5 * time.Second},
+	}, storage.NewMemoryStateRepository(), nil)
+	require.NoError(t, err)
+
+	_, err = connector.GetNews(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestGetNewsNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"feed-v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"feed-v1"`)
+		w.Write([]byte(channelFeed))
+	}))
+	defer server.Close()
+
+	stateRepo := storage.NewMemoryStateRepository()
+	transport, err := httpfetch.New(httpfetch.Options{})
+	require.NoError(t, err)
+	connector, err := New(config.YouTubeConfig{
+		Enabled:  true,
+		Sources:  []config.YouTubeSourceConfig{{Name: "GopherCon", ChannelID: "UCgophercon"}},
+		Settings: config.YouTubeSettings{BaseURL: server.URL, Timeout: 5 * time.Second},
+	}, stateRepo, transport)
+	require.NoError(t, err)
+
+	news, err := connector.GetNews(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, news, 2)
+
+	stats := &httpfetch.Stats{}
+	news, err = connector.GetNews(httpfetch.WithStats(context.Background(), stats))
+	require.NoError(t, err)
+	assert.Empty(t, news)
+	assert.Equal(t, int64(1), stats.Snapshot().CacheHits)
+}
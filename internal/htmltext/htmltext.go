@@ -0,0 +1,120 @@
+// Package htmltext converts HTML fragments such as post bodies into plain text.
+package htmltext
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockElements start a new paragraph
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Blockquote: true, atom.Pre: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Table: true, atom.Section: true, atom.Article: true,
+}
+
+// lineElements start a new line
+var lineElements = map[atom.Atom]bool{
+	atom.Br: true, atom.Li: true, atom.Tr: true, atom.Hr: true,
+}
+
+// cellElements are separated by a space
+var cellElements = map[atom.Atom]bool{atom.Td: true, atom.Th: true}
+
+// skippedElements are dropped together with their content
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Head: true, atom.Noscript: true, atom.Template: true,
+}
+
+// ToText converts an HTML fragment to plain text. Entities are decoded, paragraphs
+// are separated by a blank line, <br> and list items start a new line, and other
+// whitespace is collapsed. Invisible elements such as <script> are dropped.
+func ToText(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return strings.TrimSpace(fragment)
+	}
+
+	w := &writer{}
+	for _, node := range nodes {
+		w.walk(node)
+	}
+	return strings.TrimSpace(w.b.String())
+}
+
+// writer accumulates text, tracking pending whitespace and line breaks
+type writer struct {
+	b        strings.Builder
+	space    bool
+	newlines int
+}
+
+func (w *writer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skippedElements[n.DataAtom] {
+			return
+		}
+	}
+
+	block, line := blockElements[n.DataAtom], lineElements[n.DataAtom]
+	if block {
+		w.breakLine(2)
+	} else if line {
+		w.breakLine(1)
+	} else if cellElements[n.DataAtom] {
+		w.space = true
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.walk(child)
+	}
+	if block {
+		w.breakLine(2)
+	}
+}
+
+// text writes words separated by single spaces, flushing pending breaks first
+func (w *writer) text(s string) {
+	if strings.TrimSpace(s) == "" {
+		if s != "" {
+			w.space = true
+		}
+		return
+	}
+	if startsWithSpace(s) {
+		w.space = true
+	}
+
+	for i, word := range strings.Fields(s) {
+		if w.b.Len() > 0 {
+			if w.newlines > 0 {
+				w.b.WriteString(strings.Repeat("\n", w.newlines))
+			} else if w.space || i > 0 {
+				w.b.WriteByte(' ')
+			}
+		}
+		w.b.WriteString(word)
+		w.newlines, w.space = 0, false
+	}
+	w.space = endsWithSpace(s)
+}
+
+// breakLine requests n line breaks before the next word
+func (w *writer) breakLine(n int) {
+	if n > w.newlines {
+		w.newlines = n
+	}
+}
+
+func startsWithSpace(s string) bool {
+	return strings.TrimLeft(s, " \t\r\n\f") != s
+}
+
+func endsWithSpace(s string) bool {
+	return strings.TrimRight(s, " \t\r\n\f") != s
+}
@@ -0,0 +1,31 @@
+package htmltext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Hello, world", "Hello, world"},
+		{"entities", "Tom &amp; Jerry &lt;3 &#8220;Go&#8221;", "Tom & Jerry <3 “Go”"},
+		{"inline", "Go <b>1.26</b> is <a href=\"https://go.dev\">out</a>!", "Go 1.26 is out!"},
+		{"paragraphs", "<p>First</p><p>Second  line</p>", "First\n\nSecond line"},
+		{"breaks", "one<br>two<br/>three", "one\ntwo\nthree"},
+		{"list", "<ul><li>a</li><li>b</li></ul>after", "a\nb\n\nafter"},
+		{"table", "<table><tr><th>Version</th><th>Date</th></tr><tr><td>1.26</td><td>Feb</td></tr></table>", "Version Date\n1.26 Feb"},
+		{"scripts", "<p>Text</p><script>alert(1)</script><style>p{}</style>", "Text"},
+		{"mastodon", `<p><span class="h-card"><a href="https://fosstodon.org/@golang" class="u-url mention">@<span>golang</span></a></span> ships <a href="https://fosstodon.org/tags/go" class="mention hashtag" rel="tag">#<span>go</span></a></p>`, "@golang ships #go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ToText(tt.in))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package cassette records HTTP exchanges into testdata files and replays them, so
+// connectors can be tested end to end without network access.
+//
+// Tests replay by default. Run them with INFOBRO_RECORD=1 to send the requests to the
+// real servers and rewrite the cassettes, e.g.
+//
+//	INFOBRO_RECORD=1 go test ./internal/connectors/reddit/ -run TestGetNews
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RecordEnv is the environment variable that switches cassettes to recording
+const RecordEnv = "INFOBRO_RECORD"
+
+// redacted replaces credentials in recorded URLs
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never written to a cassette
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// sensitiveParams are query parameters whose values are redacted, both when recording
+// and when matching a request against a cassette
+var sensitiveParams = []string{"key", "api_key", "access_token", "token", "client_secret"}
+
+// Cassette is the content of a cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the part of a request used to match it
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response is a recorded response
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body"`
+}
+
+// Transport replays the interactions of a cassette, or records them when recording
+type Transport struct {
+	path      string
+	recording bool
+	next      http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	// used marks the replayed interactions, so repeated requests get successive responses
+	used []bool
+}
+
+// New returns a transport for the cassette at path, usually testdata/<test>.json.
+// When recording, requests go to the network and the cassette is written when the test ends.
+func New(t testing.TB, path string) *Transport {
+	t.Helper()
+
+	transport := &Transport{
+		path:      path,
+		recording: os.Getenv(RecordEnv) != "",
+		next:      http.DefaultTransport,
+	}
+	if transport.recording {
+		t.Cleanup(func() {
+			if err := transport.save(); err != nil {
+				t.Errorf("failed to write cassette: %v", err)
+			}
+		})
+		return transport
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cassette %s, record it with %s=1: %v", path, RecordEnv, err)
+	}
+	if err := json.Unmarshal(data, &transport.cassette); err != nil {
+		t.Fatalf("failed to decode cassette %s: %v", path, err)
+	}
+	transport.used = make([]bool, len(transport.cassette.Interactions))
+	return transport
+}
+
+// RoundTrip replays the first unused interaction matching the request, or records a
+// real exchange
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.recording {
+		return t.record(req)
+	}
+
+	key := Request{Method: req.Method, URL: redactURL(req.URL)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || interaction.Request != key {
+			continue
+		}
+		t.used[i] = true
+		return interaction.Response.toHTTP(req), nil
+	}
+	return nil, fmt.Errorf("cassette %s has no response for %s %s", t.path, key.Method, key.URL)
+}
+
+// record sends a request to the network and keeps the exchange
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	headers := resp.Header.Clone()
+	for _, name := range sensitiveHeaders {
+		headers.Del(name)
+	}
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: redactURL(req.URL)},
+		Response: Response{Status: resp.StatusCode, Headers: headers, Body: string(body)},
+	})
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// save writes the recorded interactions to the cassette file
+func (t *Transport) save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, append(data, '\n'), 0o644)
+}
+
+// toHTTP builds the response to a replayed request
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	headers := r.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// redactURL returns the URL with the values of sensitive query parameters replaced
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for _, name := range sensitiveParams {
+		if query.Has(name) {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	clean := *u
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
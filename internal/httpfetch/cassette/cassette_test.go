@@ -0,0 +1,73 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetch(t *testing.T, client *http.Client, target string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "text/plain")
+		if calls == 1 {
+			w.Write([]byte("first"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("second"))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "testdata", "exchange.json")
+
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(RecordEnv, "1")
+		client := &http.Client{Transport: New(t, path)}
+		fetch(t, client, server.URL+"/items?key=app-key&page=1")
+		fetch(t, client, server.URL+"/items?key=app-key&page=1")
+	})
+	require.Equal(t, 2, calls)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "app-key")
+	assert.NotContains(t, string(data), "secret")
+
+	t.Run("replay", func(t *testing.T) {
+		client := &http.Client{Transport: New(t, path)}
+
+		// Repeated requests get the recorded responses in order, whatever the key
+		status, body := fetch(t, client, server.URL+"/items?key=other-key&page=1")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "first", body)
+		status, body = fetch(t, client, server.URL+"/items?key=other-key&page=1")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "second", body)
+
+		_, err := client.Get(server.URL + "/items?page=2")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no response for GET")
+	})
+	assert.Equal(t, 2, calls, "replay does not reach the server")
+}
@@ -0,0 +1,116 @@
+package httpfetch
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+type contextKey int
+
+const (
+	statsKey contextKey = iota
+	stateKey
+	proxiedKey
+)
+
+// Stats counts what the transport did for the requests of a context
+type Stats struct {
+	requests  atomic.Int64
+	cacheHits atomic.Int64
+	retries   atomic.Int64
+	throttled atomic.Int64
+	delayed   atomic.Int64
+}
+
+// StatsSnapshot is a copy of the counters of Stats
+type StatsSnapshot struct {
+	// Requests is the number of requests sent, including retries
+	Requests int64
+	// CacheHits is the number of conditional requests answered with 304 Not Modified
+	CacheHits int64
+	Retries   int64
+	// Throttled is the number of 429 and 503 responses
+	Throttled int64
+	// Delayed is the number of requests held back by the local rate limit
+	Delayed int64
+}
+
+// Snapshot returns the current counters
+func (s *Stats) Snapshot() StatsSnapshot {
+	if s == nil {
+		return StatsSnapshot{}
+	}
+	return StatsSnapshot{
+		Requests:  s.requests.Load(),
+		CacheHits: s.cacheHits.Load(),
+		Retries:   s.retries.Load(),
+		Throttled: s.throttled.Load(),
+		Delayed:   s.delayed.Load(),
+	}
+}
+
+func (s *Stats) addRequest() {
+	if s != nil {
+		s.requests.Add(1)
+	}
+}
+
+func (s *Stats) addCacheHit() {
+	if s != nil {
+		s.cacheHits.Add(1)
+	}
+}
+
+func (s *Stats) addRetry() {
+	if s != nil {
+		s.retries.Add(1)
+	}
+}
+
+func (s *Stats) addThrottled() {
+	if s != nil {
+		s.throttled.Add(1)
+	}
+}
+
+func (s *Stats) addDelayed() {
+	if s != nil {
+		s.delayed.Add(1)
+	}
+}
+
+// WithStats returns a context whose requests are counted in stats
+func WithStats(ctx context.Context, stats *Stats) context.Context {
+	return context.WithValue(ctx, statsKey, stats)
+}
+
+func statsFrom(ctx context.Context) *Stats {
+	stats, _ := ctx.Value(statsKey).(*Stats)
+	return stats
+}
+
+// WithState returns a context whose GET requests are sent as conditional requests
+// with the ETag and Last-Modified recorded in state. The validators of responses are
+// recorded in state rather than stored, so they are saved with the state once the news
+// read from the responses is stored. A 304 Not Modified is returned as is for the
+// caller to handle.
+func WithState(ctx context.Context, state *models.ChannelState) context.Context {
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func stateFrom(ctx context.Context) *models.ChannelState {
+	state, _ := ctx.Value(stateKey).(*models.ChannelState)
+	return state
+}
+
+// withProxied marks the connections dialled for a request as connections to its proxy
+func withProxied(ctx context.Context) context.Context {
+	return context.WithValue(ctx, proxiedKey, true)
+}
+
+func proxiedFrom(ctx context.Context) bool {
+	proxied, _ := ctx.Value(proxiedKey).(bool)
+	return proxied
+}
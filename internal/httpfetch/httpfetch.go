@@ -0,0 +1,389 @@
+// Package httpfetch provides the HTTP transport shared by connectors. It adds
+// per-host rate limiting, retries with backoff, conditional requests, response
+// decompression and a default User-Agent to every request.
+package httpfetch
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// Limit is a token bucket: Rate requests per second with bursts of up to Burst requests
+type Limit struct {
+	// Rate is the sustained number of requests per second, 0 disables the limit
+	Rate  float64
+	Burst int
+}
+
+// Options configures a Transport
+type Options struct {
+	// UserAgent is set on requests that do not have one
+	UserAgent string
+	// Proxy is the proxy URL; empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	Proxy string
+	// Limit applies to every host without an entry in Hosts
+	Limit Limit
+	Hosts map[string]Limit
+	// MaxRetries is how often a GET or HEAD is retried after a 429, a 5xx or a network error
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles with every retry up to MaxDelay.
+	// A Retry-After longer than MaxDelay is not waited for and the response is returned as is.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// PublicOnly refuses requests, including redirects, to hosts that are not reachable
+	// from the internet, such as loopback, private and link-local addresses. It is set
+	// for fetches of URLs that come from API clients rather than the config.
+	PublicOnly bool
+}
+
+// Transport is an http.RoundTripper shared by all connectors
+type Transport struct {
+	base    http.RoundTripper
+	proxy   func(*http.Request) (*url.URL, error)
+	options Options
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// validatorsMu serializes access to the validators of the states of requests
+	validatorsMu sync.Mutex
+	random       *rand.Rand
+}
+
+// New creates a Transport
+func New(options Options) (*Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if options.Proxy != "" {
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL")
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+	if options.PublicOnly {
+		base.DialContext = publicDialer().DialContext
+	}
+
+	return &Transport{
+		base:    base,
+		proxy:   base.Proxy,
+		options: options,
+		buckets: make(map[string]*bucket),
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// RoundTrip sends a request, waiting for the rate limit of its host and retrying
+// throttled or failed idempotent requests
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	stats := statsFrom(ctx)
+	state := stateFrom(ctx)
+
+	if t.options.PublicOnly {
+		proxyURL, err := t.proxy(req)
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL != nil {
+			if err := t.checkProxied(ctx, req); err != nil {
+				return nil, err
+			}
+			ctx = withProxied(ctx)
+		}
+	}
+
+	req = req.Clone(ctx)
+	if req.Header.Get("User-Agent") == "" && t.options.UserAgent != "" {
+		req.Header.Set("User-Agent", t.options.UserAgent)
+	}
+	decode := false
+	if req.Header.Get("Accept-Encoding") == "" && req.Method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		decode = true
+	}
+
+	conditional := state != nil && req.Method == http.MethodGet &&
+		req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == ""
+	if conditional {
+		validators := t.loadValidators(state, req.URL.String())
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx, req.URL.Host, stats); err != nil {
+			return nil, err
+		}
+		stats.addRequest()
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil && ctx.Err() != nil {
+			// Cancelled requests are not retried
+			return nil, err
+		}
+		if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+			stats.addThrottled()
+		}
+
+		delay, retry := t.retryDelay(resp, err, attempt)
+		if !retryable || !retry || attempt >= t.options.MaxRetries {
+			if err != nil {
+				return nil, err
+			}
+			return t.finish(state, req, resp, decode, conditional, stats)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		stats.addRetry()
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// finish decodes the response body and records the validators of a conditional request
+func (t *Transport) finish(state *models.ChannelState, req *http.Request, resp *http.Response, decode, conditional bool, stats *Stats) (*http.Response, error) {
+	if resp.StatusCode == http.StatusNotModified {
+		stats.addCacheHit()
+	}
+
+	if conditional && resp.StatusCode == http.StatusOK {
+		validators := models.HTTPValidators{
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if validators.ETag != "" || validators.LastModified != "" {
+			t.recordValidators(state, validators)
+		}
+	}
+
+	if decode {
+		if err := decodeBody(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// retryDelay reports whether a response or error is worth retrying and how long to wait first
+func (t *Transport) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, ErrNotPublic) {
+		return 0, false
+	}
+	if err != nil {
+		return t.backoff(attempt), true
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, false
+	}
+
+	if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		return after, after <= t.options.MaxDelay
+	}
+	return t.backoff(attempt), true
+}
+
+// backoff returns the exponential delay of an attempt with jitter, between half and
+// all of BaseDelay * 2^attempt, capped at MaxDelay
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.options.BaseDelay << uint(attempt)
+	if delay <= 0 || delay > t.options.MaxDelay {
+		delay = t.options.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	jitter := time.Duration(t.random.Int63n(int64(delay)/2 + 1))
+	t.mu.Unlock()
+	return delay/2 + jitter
+}
+
+// wait blocks until the rate limit of host allows another request
+func (t *Transport) wait(ctx context.Context, host string, stats *Stats) error {
+	limit, ok := t.options.Hosts[host]
+	if !ok {
+		limit = t.options.Limit
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	b, ok := t.buckets[host]
+	if !ok {
+		b = newBucket(limit, time.Now())
+		t.buckets[host] = b
+	}
+	t.mu.Unlock()
+
+	delay := b.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	stats.addDelayed()
+	return sleep(ctx, delay)
+}
+
+// loadValidators returns the validators recorded in state for a URL
+func (t *Transport) loadValidators(state *models.ChannelState, target string) models.HTTPValidators {
+	t.validatorsMu.Lock()
+	defer t.validatorsMu.Unlock()
+
+	for _, validators := range state.Validators {
+		if validators.URL == target {
+			return validators
+		}
+	}
+	return models.HTTPValidators{}
+}
+
+// recordValidators records the validators of a URL in state, replacing earlier ones.
+// They are stored when the state is saved, together with the news of the response.
+func (t *Transport) recordValidators(state *models.ChannelState, validators models.HTTPValidators) {
+	t.validatorsMu.Lock()
+	defer t.validatorsMu.Unlock()
+
+	kept := make([]models.HTTPValidators, 0, len(state.Validators)+1)
+	for _, existing := range state.Validators {
+		if existing.URL != validators.URL {
+			kept = append(kept, existing)
+		}
+	}
+	state.Validators = append(kept, validators)
+}
+
+// decodeBody replaces a gzip or brotli encoded body with the decoded stream
+func decodeBody(resp *http.Response) error {
+	var decoded io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			if err == io.EOF {
+				// Empty bodies, e.g. of a 304, have nothing to decode
+				return nil
+			}
+			return fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+		decoded = reader
+	case "br":
+		decoded = brotli.NewReader(resp.Body)
+	default:
+		return nil
+	}
+
+	resp.Body = &decodedBody{Reader: decoded, closer: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody reads the decoded stream and closes the original body
+type decodedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+// Close closes the original body
+func (b *decodedBody) Close() error {
+	return b.closer.Close()
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		delay := at.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bucket is a token bucket. Tokens may go negative, which reserves future tokens
+// for callers that are already waiting.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: limit.Rate, burst: burst, tokens: burst, last: now}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
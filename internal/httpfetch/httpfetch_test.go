@@ -0,0 +1,266 @@
+package httpfetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, options Options) *http.Client {
+	t.Helper()
+	transport, err := New(options)
+	require.NoError(t, err)
+	return &http.Client{Transport: transport}
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context, target string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestRetriesThrottledRequests(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	stats := &Stats{}
+	resp, body := get(t, client, WithStats(context.Background(), stats), server.URL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, StatsSnapshot{Requests: 3, Retries: 2, Throttled: 1}, stats.Snapshot())
+}
+
+func TestLongRetryAfterIsNotWaitedFor(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	resp, _ := get(t, client, context.Background(), server.URL)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPostIsNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 1, calls)
+}
+
+func TestConditionalRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("feed"))
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{})
+	state := &models.ChannelState{ChannelID: "youtube:Go"}
+	ctx := WithState(context.Background(), state)
+
+	resp, body := get(t, client, ctx, server.URL+"/feed")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "feed", body)
+
+	require.Len(t, state.Validators, 1, "validators are recorded in the state of the request")
+	assert.Equal(t, server.URL+"/feed", state.Validators[0].URL)
+	assert.Equal(t, `"v1"`, state.Validators[0].ETag)
+
+	stats := &Stats{}
+	resp, _ = get(t, client, WithStats(ctx, stats), server.URL+"/feed")
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, int64(1), stats.Snapshot().CacheHits)
+
+	// Requests without a state are sent unconditionally
+	resp, _ = get(t, client, context.Background(), server.URL+"/feed")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDecodesCompressedResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip, br", r.Header.Get("Accept-Encoding"))
+		assert.Equal(t, "infoBro/test", r.Header.Get("User-Agent"))
+
+		var buf bytes.Buffer
+		switch r.URL.Path {
+		case "/gzip":
+			writer := gzip.NewWriter(&buf)
+			writer.Write([]byte("gzipped"))
+			writer.Close()
+			w.Header().Set("Content-Encoding", "gzip")
+		case "/br":
+			writer := brotli.NewWriter(&buf)
+			writer.Write([]byte("brotli"))
+			writer.Close()
+			w.Header().Set("Content-Encoding", "br")
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{UserAgent: "infoBro/test"})
+
+	resp, body := get(t, client, context.Background(), server.URL+"/gzip")
+	assert.Equal(t, "gzipped", body)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	_, body = get(t, client, context.Background(), server.URL+"/br")
+	assert.Equal(t, "brotli", body)
+}
+
+func TestRateLimitPerHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := newTestClient(t, Options{Limit: Limit{Rate: 20, Burst: 2}})
+
+	stats := &Stats{}
+	ctx := WithStats(context.Background(), stats)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		get(t, client, ctx, server.URL)
+	}
+	// The burst is sent right away, the other two wait 50ms each
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(90*time.Millisecond))
+	assert.Equal(t, int64(2), stats.Snapshot().Delayed)
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	b := newBucket(Limit{Rate: 1, Burst: 2}, now)
+
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, time.Second, b.reserve(now))
+	assert.Equal(t, 2*time.Second, b.reserve(now))
+	assert.Equal(t, time.Second, b.reserve(now.Add(2*time.Second)))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	delay, ok := parseRetryAfter("120", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter("Sun, 01 Mar 2026 12:00:30 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestInvalidProxy(t *testing.T) {
+	_, err := New(Options{Proxy: "not a url"})
+	require.Error(t, err)
+}
+
+func TestPublicOnlyRefusesInternalHosts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+	client := newTestClient(t, Options{PublicOnly: true, MaxRetries: 2})
+
+	for _, target := range []string{"http://127.0.0.1/", "http://169.254.169.254/latest/meta-data/", "http://[::1]/", server.URL} {
+		_, err := client.Get(target)
+		assert.True(t, errors.Is(err, ErrNotPublic), target)
+	}
+	assert.Zero(t, requests)
+
+	// Redirects are checked when their connection is dialled
+	client = newTestClient(t, Options{})
+	client.Transport.(*Transport).options.PublicOnly = true
+	client.Transport.(*Transport).base.(*http.Transport).DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "169.254.169.254:80" {
+			return publicDialer().DialContext(ctx, network, address)
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	_, err := client.Get("http://example.com/redirect")
+	assert.True(t, errors.Is(err, ErrNotPublic))
+	assert.Equal(t, 1, requests)
+}
+
+func TestPublicOnlyChecksTargetsOfProxiedRequests(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+	client := newTestClient(t, Options{PublicOnly: true, Proxy: proxy.URL})
+
+	_, err := client.Get("http://127.0.0.1/")
+	assert.True(t, errors.Is(err, ErrNotPublic))
+
+	// The proxy itself may be internal
+	resp, err := client.Get("http://93.184.215.14/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"http://93.184.215.14/"}, proxied)
+}
+
+func TestIsPublic(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "224.0.0.1"} {
+		assert.False(t, isPublic(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"93.184.215.14", "8.8.8.8", "2606:4700::1111"} {
+		assert.True(t, isPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
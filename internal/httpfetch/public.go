@@ -0,0 +1,70 @@
+package httpfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublic is returned for requests to loopback, private, link-local and other
+// addresses that are not reachable from the internet, when Options.PublicOnly is set
+var ErrNotPublic = errors.New("address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, not covered by IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicDialer returns a dialer refusing connections to addresses that are not public.
+// The address is checked after it is resolved, so neither redirects nor DNS answers
+// can reach internal hosts. Connections to a proxy are not checked; checkProxied
+// checks the target of proxied requests instead.
+func publicDialer() *net.Dialer {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	dialer.ControlContext = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		if proxiedFrom(ctx) {
+			return nil
+		}
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrNotPublic, address)
+		}
+		if !isPublic(addrPort.Addr()) {
+			return fmt.Errorf("%w: %s", ErrNotPublic, addrPort.Addr())
+		}
+		return nil
+	}
+	return dialer
+}
+
+// checkProxied resolves the host of a request sent through a proxy, which connects to
+// the host itself, and fails if any of its addresses is not public
+func (t *Transport) checkProxied(ctx context.Context, req *http.Request) error {
+	host := req.URL.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublic(addr) {
+			return fmt.Errorf("%w: %s", ErrNotPublic, addr)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNotPublic, host, addr)
+		}
+	}
+	return nil
+}
+
+// isPublic reports whether addr is a unicast address reachable from the internet
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
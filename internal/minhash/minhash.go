@@ -0,0 +1,143 @@
+// Package minhash computes locality-sensitive signatures of news text. The share of
+// equal values in two signatures estimates the Jaccard similarity of the word sets
+// of the texts, and signatures are split into bands so that similar texts can be
+// looked up by equal band keys instead of by comparing every pair.
+package minhash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Size is the number of values in a signature
+	Size = 64
+	// Rows is the number of values in a band. With 32 bands of 2 rows, texts with a
+	// similarity of 0.3 share a band with a probability of 95%, texts with a similarity
+	// of 0.05 with a probability of 8%.
+	Rows = 2
+
+	// maxContentTokens bounds the content words used, so long articles do not drown the title
+	maxContentTokens = 200
+	// minTokens is the number of distinct words below which a signature is not reliable
+	minTokens = 4
+)
+
+// stopWords are too common to tell stories apart
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "how": true, "in": true, "is": true, "it": true,
+	"its": true, "new": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true,
+	"to": true, "was": true, "what": true, "when": true, "why": true, "will": true, "with": true,
+	"you": true, "your": true, "we": true, "our": true, "now": true, "out": true, "can": true, "all": true,
+}
+
+// seeds derive the Size hash functions from a single word hash
+var seeds = func() [Size]uint64 {
+	var seeds [Size]uint64
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state = splitmix(state)
+		seeds[i] = state
+	}
+	return seeds
+}()
+
+// Signature is the MinHash signature of a text
+type Signature []uint32
+
+// Compute returns the signature of a news item from its title and plain text content.
+// It reports false when the text has too few distinct words for the signature to mean much.
+func Compute(title, content string) (Signature, bool) {
+	contentTokens := Tokenize(content)
+	if len(contentTokens) > maxContentTokens {
+		contentTokens = contentTokens[:maxContentTokens]
+	}
+
+	words := make(map[string]bool)
+	for _, token := range Tokenize(title) {
+		words[token] = true
+	}
+	for _, token := range contentTokens {
+		words[token] = true
+	}
+	if len(words) < minTokens {
+		return nil, false
+	}
+
+	signature := make(Signature, Size)
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+	for word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		for i, seed := range seeds {
+			if value := uint32(splitmix(sum^seed) >> 32); value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+	return signature, true
+}
+
+// Similarity estimates the Jaccard similarity of the texts of two signatures
+func (s Signature) Similarity(other Signature) float64 {
+	if len(s) != Size || len(other) != Size {
+		return 0
+	}
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / Size
+}
+
+// Bands returns the keys of the bands of the signature; similar texts are likely to
+// share at least one key
+func (s Signature) Bands() []string {
+	if len(s) != Size {
+		return nil
+	}
+	bands := make([]string, 0, Size/Rows)
+	buf := make([]byte, 4*Rows)
+	for band := 0; band < Size/Rows; band++ {
+		for row := 0; row < Rows; row++ {
+			binary.BigEndian.PutUint32(buf[4*row:], s[band*Rows+row])
+		}
+		bands = append(bands, fmt.Sprintf("%d:%x", band, buf))
+	}
+	return bands
+}
+
+// Tokenize splits text into lower case words without stop words. Dots inside words
+// are kept so versions such as 1.26 stay one word.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '+' && r != '#'
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.Trim(field, ".")
+		if field == "" || stopWords[field] || (len(field) == 1 && !unicode.IsDigit(rune(field[0]))) {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// splitmix scrambles x; it is the finalizer of the SplitMix64 generator
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,39 @@
+package minhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t,
+		[]string{"go", "1.26", "released", "green", "tea", "gc", "c++", "c#", "2", "them"},
+		Tokenize("Go 1.26 is released: the Green Tea GC, C++ and C# (2 of them)..."))
+}
+
+func TestSimilarity(t *testing.T) {
+	a, ok := Compute("Go 1.26 is released", "The Go team is happy to release Go 1.26 with the Green Tea garbage collector.")
+	require.True(t, ok)
+	b, ok := Compute("Go 1.26 released", "Go 1.26 is released with the Green Tea garbage collector enabled by default.")
+	require.True(t, ok)
+	c, ok := Compute("PostgreSQL 18 Beta 1", "The first beta of PostgreSQL 18 adds asynchronous I/O and uuidv7 support.")
+	require.True(t, ok)
+
+	assert.Equal(t, 1.0, a.Similarity(a))
+	assert.Greater(t, a.Similarity(b), 0.4)
+	assert.Less(t, a.Similarity(c), 0.2)
+	assert.Zero(t, a.Similarity(nil))
+
+	assert.Len(t, a.Bands(), Size/Rows)
+	assert.Equal(t, a.Bands(), a.Bands())
+	assert.Nil(t, Signature(nil).Bands())
+}
+
+func TestComputeNeedsEnoughWords(t *testing.T) {
+	_, ok := Compute("Go 1.26", "")
+	assert.False(t, ok)
+	_, ok = Compute("Go 1.26", "released today")
+	assert.True(t, ok)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,18 +13,101 @@ type NewsConnector interface {
 	GetNews(ctx context.Context) ([]RawNews, error)
 }
 
+// PushConnector - interface for sources that push news to us instead of being polled.
+// Ingest authenticates and validates a payload sent by the named source and passes the
+// new items to store; items count as ingested only once store succeeds.
+type PushConnector interface {
+	NewsConnector
+	Ingest(ctx context.Context, source string, payload []byte, header http.Header, store func(context.Context, []RawNews) (int, error)) (int, error)
+}
+
+// SourceConnector - interface for connectors polling several independent sources, such
+// as subreddits or feeds. ConnectorService fetches every source on its own, so a
+// failing source can be paused without holding back the others.
+type SourceConnector interface {
+	NewsConnector
+	// Sources returns the names of the configured sources
+	Sources() []string
+	// GetSourceNews fetches the news of the named source only
+	GetSourceNews(ctx context.Context, source string) ([]RawNews, error)
+}
+
+// CheckpointConnector - interface for source connectors that remember what they have
+// read of a source in a ChannelState. GetSourceBatch fetches the named source like
+// GetSourceNews but returns the updated State with the news instead of saving it;
+// ConnectorService saves it only once the news is stored, so news lost to a failed
+// store is fetched again on the next run. GetNews and GetSourceNews save it themselves.
+type CheckpointConnector interface {
+	SourceConnector
+	GetSourceBatch(ctx context.Context, source string) (NewsBatch, error)
+}
+
+// StreamingConnector - interface for connectors whose runs are large or long, such as a
+// first read of a big feed. StreamNews passes the news to emit in batches as they are
+// fetched instead of returning them at the end; emit stores a batch and then saves its
+// State checkpoint, so a run that stops halfway loses at most the batch in flight.
+// StreamNews stops and returns the error of a failed emit.
+type StreamingConnector interface {
+	NewsConnector
+	StreamNews(ctx context.Context, emit func(context.Context, NewsBatch) error) error
+}
+
+// WorkerPool - the worker pool shared by connector runs. A run holds one slot; a
+// connector that fetches concurrently within a run takes another slot for every
+// further concurrent fetch, so it stays within the shared limit.
+type WorkerPool interface {
+	Acquire(ctx context.Context) error
+	Release()
+}
+
+type workerPoolKey struct{}
+
+// WithWorkerPool returns a context carrying the worker pool of a run
+func WithWorkerPool(ctx context.Context, pool WorkerPool) context.Context {
+	return context.WithValue(ctx, workerPoolKey{}, pool)
+}
+
+// WorkerPoolFrom returns the worker pool of a run, or nil if the run has none
+func WorkerPoolFrom(ctx context.Context) WorkerPool {
+	pool, _ := ctx.Value(workerPoolKey{}).(WorkerPool)
+	return pool
+}
+
+// NewsBatch - news and the channel state that accounts for it, from a streamed run or a source
+type NewsBatch struct {
+	News []RawNews
+	// State is the checkpoint to save once News is stored; nil if the batch has none
+	State *ChannelState
+}
+
+// BackfillConnector - interface for connectors that can page backwards through the
+// history of their sources. BackfillPage returns the page of the named source that
+// follows cursor, "" for the newest page, with the news ordered newest first. It does
+// not read or advance the ChannelState of the source.
+type BackfillConnector interface {
+	SourceConnector
+	BackfillPage(ctx context.Context, source, cursor string) (BackfillPage, error)
+}
+
+// BackfillPage - one page of the history of a source
+type BackfillPage struct {
+	News []RawNews
+	// Next is the cursor of the following, older page; "" once the history is exhausted
+	Next string
+}
+
 // RawNews - structure for storing news in a standard format
 type RawNews struct {
-	SourceType  string
-	SourceID    string
-	SourceName  string
-	SourceURL   string
-	Title       string
-	Content     string
-	URL         string
-	PublishedAt time.Time
-	FetchedAt   time.Time
-	Metadata    map[string]interface{}
+	SourceType  string                 `json:"source_type"`
+	SourceID    string                 `json:"source_id"`
+	SourceName  string                 `json:"source_name"`
+	SourceURL   string                 `json:"source_url"`
+	Title       string                 `json:"title"`
+	Content     string                 `json:"content"`
+	URL         string                 `json:"url"`
+	PublishedAt time.Time              `json:"published_at"`
+	FetchedAt   time.Time              `json:"fetched_at"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ChannelState - source state structure
@@ -32,6 +116,36 @@ type ChannelState struct {
 	LastMessageID     string
 	LastUpdateTime    time.Time
 	ProcessedMessages int
+	// SeenIDs holds the most recent item IDs (or URLs) already emitted, for sources
+	// whose listings are not ordered by a monotonic ID
+	SeenIDs []string
+	// Validators holds the ETag and Last-Modified of URLs fetched for the channel,
+	// used to send conditional requests
+	Validators []HTTPValidators
+}
+
+// HTTPValidators are the cache validators a server returned for a URL
+type HTTPValidators struct {
+	URL          string `bson:"url"`
+	ETag         string `bson:"etag,omitempty"`
+	LastModified string `bson:"last_modified,omitempty"`
+}
+
+// MarkSeen appends ids to SeenIDs, keeping at most limit of the most recent entries
+func (s *ChannelState) MarkSeen(limit int, ids ...string) {
+	s.SeenIDs = append(s.SeenIDs, ids...)
+	if len(s.SeenIDs) > limit {
+		s.SeenIDs = append([]string(nil), s.SeenIDs[len(s.SeenIDs)-limit:]...)
+	}
+}
+
+// SeenSet returns SeenIDs as a set for fast lookups
+func (s *ChannelState) SeenSet() map[string]bool {
+	seen := make(map[string]bool, len(s.SeenIDs))
+	for _, id := range s.SeenIDs {
+		seen[id] = true
+	}
+	return seen
 }
 
 // ChannelStateRepository - interface for storing source states
@@ -40,8 +154,74 @@ type ChannelStateRepository interface {
 	UpdateChannelState(ctx context.Context, state *ChannelState) error
 }
 
+// Circuit breaker states of a source
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// SourceBreaker - circuit breaker of one source of a connector. An open breaker pauses
+// the source until RetryAt, when a single probe fetch decides whether it closes again.
+type SourceBreaker struct {
+	Connector string `json:"connector" bson:"connector"`
+	Source    string `json:"source" bson:"source"`
+	State     string `json:"state" bson:"state"`
+	// Failures counts the consecutive failed fetches
+	Failures int `json:"failures" bson:"failures"`
+	// Trips counts how often the breaker opened in a row; the cool-down doubles with each trip
+	Trips     int       `json:"trips" bson:"trips"`
+	LastError string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	OpenedAt  time.Time `json:"opened_at" bson:"opened_at,omitempty"`
+	RetryAt   time.Time `json:"retry_at" bson:"retry_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// BreakerRepository - interface for storing source circuit breakers
+type BreakerRepository interface {
+	ListBreakers(ctx context.Context) ([]SourceBreaker, error)
+	SaveBreaker(ctx context.Context, breaker *SourceBreaker) error
+	DeleteBreaker(ctx context.Context, connector, source string) error
+}
+
+// Backfill states
+const (
+	BackfillRunning = "running"
+	// BackfillStopped - the backfill was interrupted or reached its page limit and can be resumed
+	BackfillStopped = "stopped"
+	BackfillFailed  = "failed"
+	BackfillDone    = "done"
+)
+
+// Backfill - progress of filling in the history of one source between Since and Until.
+// It is stored apart from the ChannelState of the source, so live runs are not disturbed.
+type Backfill struct {
+	Connector string    `json:"connector" bson:"connector"`
+	Source    string    `json:"source" bson:"source"`
+	Since     time.Time `json:"since" bson:"since"`
+	Until     time.Time `json:"until" bson:"until"`
+	State     string    `json:"state" bson:"state"`
+	// Cursor is the next page to fetch; a stopped or failed backfill resumes from it
+	Cursor string `json:"cursor,omitempty" bson:"cursor,omitempty"`
+	Pages  int    `json:"pages" bson:"pages"`
+	Stored int    `json:"stored" bson:"stored"`
+	// Oldest is the publication time of the oldest news read so far
+	Oldest    time.Time `json:"oldest,omitempty" bson:"oldest,omitempty"`
+	LastError string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	StartedAt time.Time `json:"started_at" bson:"started_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// BackfillRepository - interface for storing backfill progress, one record per source
+type BackfillRepository interface {
+	// GetBackfill returns nil if the source was never backfilled
+	GetBackfill(ctx context.Context, connector, source string) (*Backfill, error)
+	SaveBackfill(ctx context.Context, backfill *Backfill) error
+}
+
 // NewsStorage - interface for news storage
 type NewsStorage interface {
+	// SaveRawNews saves the news not stored yet and returns the IDs of the saved items
 	SaveRawNews(ctx context.Context, news []RawNews) ([]primitive.ObjectID, error)
 }
 
@@ -63,4 +243,22 @@ type ProcessedNews struct {
 	URL         string             `json:"url" bson:"url"`
 	PublishedAt time.Time          `json:"published_at" bson:"published_at"`
 	ProcessedAt time.Time          `json:"processed_at" bson:"processed_at"`
+	// CanonicalURL is the URL of the linked page with tracking, AMP and redirects removed
+	CanonicalURL string `json:"canonical_url,omitempty" bson:"canonical_url,omitempty"`
+	// StoryID groups the items about the same story, from any source; it is the raw ID
+	// of the first item of the group
+	StoryID string `json:"story_id,omitempty" bson:"story_id,omitempty"`
+	// ContentHTML is the content as sanitized HTML and ContentText as plain text, whatever
+	// the format the source delivered it in
+	ContentHTML string `json:"content_html,omitempty" bson:"content_html,omitempty"`
+	ContentText string `json:"content_text,omitempty" bson:"content_text,omitempty"`
+	// LeadImage is the URL of the main image of the item
+	LeadImage string `json:"lead_image,omitempty" bson:"lead_image,omitempty"`
+	// WordCount and ReadingMinutes measure the plain text content
+	WordCount      int `json:"word_count,omitempty" bson:"word_count,omitempty"`
+	ReadingMinutes int `json:"reading_minutes,omitempty" bson:"reading_minutes,omitempty"`
+	// MinHash is the signature of the title and content, used to cluster near duplicates;
+	// MinHashBands are its band keys, used to look up similar items
+	MinHash      []uint32 `json:"-" bson:"minhash,omitempty"`
+	MinHashBands []string `json:"-" bson:"minhash_bands,omitempty"`
 }
\ No newline at end of file
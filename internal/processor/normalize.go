@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/sanitize"
+)
+
+// Metadata keys connectors may set to describe the content of an item
+const (
+	// MetadataContentFormat is the sanitize.Format of the content, for sources whose
+	// format cannot be told from their type or the content itself
+	MetadataContentFormat = "contentFormat"
+	// MetadataThumbnail is the preview image of an item, such as a video thumbnail
+	MetadataThumbnail = "thumbnail"
+)
+
+// wordsPerMinute is the reading speed reading times are estimated with
+const wordsPerMinute = 230
+
+// sourceFormats are the content formats of source types that do not deliver HTML
+var sourceFormats = map[string]sanitize.Format{
+	"reddit":   sanitize.FormatMarkdown,
+	"telegram": sanitize.FormatTelegram,
+}
+
+// NormalizeStage converts the content, whatever its format, to sanitized HTML and plain
+// text, and sets the lead image, word count and reading time of the item. The original
+// content is kept as is.
+func NormalizeStage(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+	doc := sanitize.Normalize(news.Content, contentFormat(raw, news.Content), news.URL)
+
+	news.ContentHTML = doc.HTML
+	news.ContentText = doc.Text
+	news.LeadImage = doc.LeadImage
+	if news.LeadImage == "" {
+		news.LeadImage = sanitize.ImageURL(metadataString(raw, MetadataThumbnail), news.URL)
+	}
+	news.WordCount = doc.WordCount
+	news.ReadingMinutes = (doc.WordCount + wordsPerMinute - 1) / wordsPerMinute
+	return nil
+}
+
+// contentFormat returns the format declared by the connector, else the format of the
+// source type, else the format the content looks like
+func contentFormat(raw *models.RawNews, content string) sanitize.Format {
+	switch format := sanitize.Format(metadataString(raw, MetadataContentFormat)); format {
+	case sanitize.FormatHTML, sanitize.FormatMarkdown, sanitize.FormatTelegram, sanitize.FormatText:
+		return format
+	}
+	if format, ok := sourceFormats[raw.SourceType]; ok {
+		return format
+	}
+	return sanitize.Detect(content)
+}
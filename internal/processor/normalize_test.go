@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeStage(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       models.RawNews
+		wantHTML  string
+		wantText  string
+		wantImage string
+	}{
+		{"rss html", models.RawNews{
+			SourceType: "rss", URL: "https://go.dev/blog/go1.26",
+			Content: `<div><img data-src="/images/gopher.png" src="data:,"><p onclick="x()">Go 1.26 is <b>out</b>.</p></div>`,
+		}, `<p><img src="https://go.dev/images/gopher.png"/></p>` + "\n<p>Go 1.26 is <strong>out</strong>.</p>", "Go 1.26 is out.", "https://go.dev/images/gopher.png"},
+		{"reddit markdown", models.RawNews{
+			SourceType: "reddit", URL: "https://www.reddit.com/r/golang/comments/1b2c3d4/go_126/",
+			Content: "Go 1.26 is **out** &amp; fast, see [notes](/r/golang/wiki)",
+		}, `<p>Go 1.26 is <strong>out</strong> &amp; fast, see <a href="https://www.reddit.com/r/golang/wiki" rel="nofollow noopener noreferrer">notes</a></p>`,
+			"Go 1.26 is out & fast, see notes", ""},
+		{"telegram markup", models.RawNews{
+			SourceType: "telegram", URL: "https://t.me/golang_news/1234",
+			Content: "<b>Go 1.26</b>\nis out",
+		}, "<p><strong>Go 1.26</strong><br/>is out</p>", "Go 1.26\nis out", ""},
+		{"plain text with thumbnail", models.RawNews{
+			SourceType: "youtube", URL: "https://www.youtube.com/watch?v=abc",
+			Content:  "What's new in Go 1.26 <3",
+			Metadata: map[string]interface{}{MetadataThumbnail: "https://i.ytimg.com/vi/abc/hqdefault.jpg"},
+		}, "<p>What&#39;s new in Go 1.26 &lt;3</p>", "What's new in Go 1.26 <3", "https://i.ytimg.com/vi/abc/hqdefault.jpg"},
+		{"declared format", models.RawNews{
+			SourceType: "webhook", URL: "https://example.com/1",
+			Content:  "# Release\n\n*Go* 1.26",
+			Metadata: map[string]interface{}{MetadataContentFormat: "markdown"},
+		}, "<h1>Release</h1>\n<p><em>Go</em> 1.26</p>", "Release\n\nGo 1.26", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := models.ProcessedNews{Content: tt.raw.Content, URL: tt.raw.URL}
+			require.NoError(t, NormalizeStage(context.Background(), &tt.raw, &news))
+			assert.Equal(t, tt.wantHTML, news.ContentHTML)
+			assert.Equal(t, tt.wantText, news.ContentText)
+			assert.Equal(t, tt.wantImage, news.LeadImage)
+			assert.Equal(t, tt.raw.Content, news.Content)
+		})
+	}
+}
+
+func TestNormalizeStageReadingTime(t *testing.T) {
+	raw := models.RawNews{SourceType: "rss", Content: "<p>" + strings.Repeat("word ", 500) + "</p>"}
+	news := models.ProcessedNews{Content: raw.Content}
+	require.NoError(t, NormalizeStage(context.Background(), &raw, &news))
+	assert.Equal(t, 500, news.WordCount)
+	assert.Equal(t, 3, news.ReadingMinutes)
+
+	raw, news = models.RawNews{SourceType: "hackernews"}, models.ProcessedNews{}
+	require.NoError(t, NormalizeStage(context.Background(), &raw, &news))
+	assert.Zero(t, news.WordCount)
+	assert.Zero(t, news.ReadingMinutes)
+	assert.Empty(t, news.ContentHTML)
+}
@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/dzianismalei/infoBro/internal/queue"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Queue is the consuming side of the news queue
+type Queue interface {
+	GetFromQueue(ctx context.Context, timeout time.Duration) (string, error)
+	AcknowledgeProcessed(ctx context.Context, newsID string) error
+	MarkAsFailed(ctx context.Context, newsID string) error
+}
+
+// Storage reads raw news and stores processed news
+type Storage interface {
+	GetRawNews(ctx context.Context, id primitive.ObjectID) (*models.RawNews, error)
+	SaveProcessedNews(ctx context.Context, news models.ProcessedNews) (primitive.ObjectID, error)
+}
+
+// Stage is one step of the processing pipeline. Stages run in order and refine
+// the processed item built from the raw one.
+type Stage interface {
+	Process(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error
+}
+
+// StageFunc adapts a function to the Stage interface
+type StageFunc func(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error
+
+// Process calls f
+func (f StageFunc) Process(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+	return f(ctx, raw, news)
+}
+
+// Processor takes raw news IDs off the queue, runs them through the pipeline stages
+// and saves the result to the processed news collection
+type Processor struct {
+	queue       Queue
+	storage     Storage
+	stages      []Stage
+	pollTimeout time.Duration
+}
+
+// New creates a processor running the given stages after the basic cleanup stage
+func New(q Queue, storage Storage, pollTimeout time.Duration, stages ...Stage) *Processor {
+	return &Processor{
+		queue:       q,
+		storage:     storage,
+		stages:      append([]Stage{StageFunc(CleanupStage)}, stages...),
+		pollTimeout: pollTimeout,
+	}
+}
+
+// Run processes queue items with the given number of workers until ctx is cancelled
+func (p *Processor) Run(ctx context.Context, workers int) error {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			p.work(ctx, worker)
+		}(i + 1)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// work is the loop of a single worker
+func (p *Processor) work(ctx context.Context, worker int) {
+	for ctx.Err() == nil {
+		id, err := p.queue.GetFromQueue(ctx, p.pollTimeout)
+		if errors.Is(err, queue.ErrEmpty) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Worker %d: failed to read from queue: %v", worker, err)
+			time.Sleep(p.pollTimeout)
+			continue
+		}
+
+		if err := p.ProcessOne(ctx, id); err != nil {
+			if ctx.Err() != nil {
+				// Interrupted by shutdown: the item stays in the processing list and is
+				// moved back to the queue when the worker starts again
+				log.Printf("Worker %d: stopped while processing %s", worker, id)
+				return
+			}
+			log.Printf("Worker %d: failed to process %s: %v", worker, id, err)
+			if err := p.queue.MarkAsFailed(ctx, id); err != nil {
+				log.Printf("Worker %d: failed to mark %s as failed: %v", worker, id, err)
+			}
+			continue
+		}
+
+		if err := p.queue.AcknowledgeProcessed(ctx, id); err != nil {
+			log.Printf("Worker %d: failed to acknowledge %s: %v", worker, id, err)
+		}
+	}
+}
+
+// ProcessOne runs a single raw news item through the pipeline and saves the result
+func (p *Processor) ProcessOne(ctx context.Context, id string) error {
+	rawID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid news ID %q: %w", id, err)
+	}
+
+	raw, err := p.storage.GetRawNews(ctx, rawID)
+	if err != nil {
+		return fmt.Errorf("failed to load raw news: %w", err)
+	}
+
+	news := models.ProcessedNews{
+		RawID:       rawID,
+		Title:       raw.Title,
+		Content:     raw.Content,
+		SourceType:  raw.SourceType,
+		SourceID:    raw.SourceID,
+		SourceName:  raw.SourceName,
+		SourceURL:   raw.SourceURL,
+		URL:         raw.URL,
+		PublishedAt: raw.PublishedAt,
+	}
+
+	for _, stage := range p.stages {
+		if err := stage.Process(ctx, raw, &news); err != nil {
+			return err
+		}
+	}
+
+	news.ProcessedAt = time.Now()
+	if _, err := p.storage.SaveProcessedNews(ctx, news); err != nil {
+		return fmt.Errorf("failed to save processed news: %w", err)
+	}
+	return nil
+}
+
+// CleanupStage trims whitespace, collapses runs of whitespace in the title and
+// falls back to the fetch time for items without a publication date
+func CleanupStage(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+	news.Title = strings.Join(strings.Fields(news.Title), " ")
+	news.Content = strings.TrimSpace(news.Content)
+	news.URL = strings.TrimSpace(news.URL)
+
+	if news.Title == "" {
+		return fmt.Errorf("news item %s/%s has no title", raw.SourceType, raw.SourceID)
+	}
+	if news.PublishedAt.IsZero() {
+		news.PublishedAt = raw.FetchedAt
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeStorage keeps raw and processed news in memory
+type fakeStorage struct {
+	raw       map[primitive.ObjectID]models.RawNews
+	processed []models.ProcessedNews
+}
+
+func (f *fakeStorage) GetRawNews(ctx context.Context, id primitive.ObjectID) (*models.RawNews, error) {
+	raw, ok := f.raw[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &raw, nil
+}
+
+func (f *fakeStorage) SaveProcessedNews(ctx context.Context, news models.ProcessedNews) (primitive.ObjectID, error) {
+	f.processed = append(f.processed, news)
+	return primitive.NewObjectID(), nil
+}
+
+// fakeQueue hands out its IDs once and then waits for ctx to be done
+type fakeQueue struct {
+	mu     sync.Mutex
+	ids    []string
+	acked  []string
+	failed []string
+}
+
+func (f *fakeQueue) GetFromQueue(ctx context.Context, timeout time.Duration) (string, error) {
+	f.mu.Lock()
+	if len(f.ids) > 0 {
+		id := f.ids[0]
+		f.ids = f.ids[1:]
+		f.mu.Unlock()
+		return id, nil
+	}
+	f.mu.Unlock()
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (f *fakeQueue) AcknowledgeProcessed(ctx context.Context, newsID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, newsID)
+	return nil
+}
+
+func (f *fakeQueue) MarkAsFailed(ctx context.Context, newsID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed = append(f.failed, newsID)
+	return nil
+}
+
+func TestProcessOne(t *testing.T) {
+	id := primitive.NewObjectID()
+	fetchedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	storage := &fakeStorage{raw: map[primitive.ObjectID]models.RawNews{
+		id: {
+			SourceType: "reddit",
+			SourceID:   "abc",
+			SourceName: "golang",
+			Title:      "  Go 1.26\n released  ",
+			Content:    "\n Release notes \n",
+			URL:        " https://go.dev/doc/go1.26 ",
+			FetchedAt:  fetchedAt,
+		},
+	}}
+
+	var seen []string
+	stage := StageFunc(func(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+		seen = append(seen, news.Title)
+		return nil
+	})
+
+	p := New(nil, storage, time.Second, stage)
+	require.NoError(t, p.ProcessOne(context.Background(), id.Hex()))
+
+	require.Len(t, storage.processed, 1)
+	news := storage.processed[0]
+	assert.Equal(t, id, news.RawID)
+	assert.Equal(t, "Go 1.26 released", news.Title)
+	assert.Equal(t, "Release notes", news.Content)
+	assert.Equal(t, "https://go.dev/doc/go1.26", news.URL)
+	assert.Equal(t, fetchedAt, news.PublishedAt)
+	assert.False(t, news.ProcessedAt.IsZero())
+	assert.Equal(t, []string{"Go 1.26 released"}, seen, "custom stages run after cleanup")
+}
+
+func TestProcessOneErrors(t *testing.T) {
+	id := primitive.NewObjectID()
+	storage := &fakeStorage{raw: map[primitive.ObjectID]models.RawNews{
+		id: {SourceType: "rss", SourceID: "1", Title: "   "},
+	}}
+	p := New(nil, storage, time.Second)
+
+	assert.Error(t, p.ProcessOne(context.Background(), "not-an-id"))
+	assert.Error(t, p.ProcessOne(context.Background(), primitive.NewObjectID().Hex()))
+	assert.EqualError(t, p.ProcessOne(context.Background(), id.Hex()), "news item rss/1 has no title")
+	assert.Empty(t, storage.processed)
+}
+
+func TestRunLeavesInterruptedItems(t *testing.T) {
+	ok, bad, interrupted := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	storage := &fakeStorage{raw: map[primitive.ObjectID]models.RawNews{
+		ok:          {SourceType: "rss", SourceID: "1", Title: "Go 1.26"},
+		bad:         {SourceType: "rss", SourceID: "2", Title: " "},
+		interrupted: {SourceType: "rss", SourceID: "3", Title: "Go 1.27"},
+	}}
+	q := &fakeQueue{ids: []string{ok.Hex(), bad.Hex(), interrupted.Hex()}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stage := StageFunc(func(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+		if raw.SourceID == "3" {
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	err := New(q, storage, time.Millisecond, stage).Run(ctx, 1)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, []string{ok.Hex()}, q.acked)
+	assert.Equal(t, []string{bad.Hex()}, q.failed, "items interrupted by shutdown are not failed")
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/canonical"
+	"github.com/dzianismalei/infoBro/internal/htmltext"
+	"github.com/dzianismalei/infoBro/internal/minhash"
+	"github.com/dzianismalei/infoBro/internal/models"
+)
+
+// Metadata keys connectors may set to point at the page an item is about
+const (
+	// MetadataCanonicalURL is the rel=canonical URL of a fetched page
+	MetadataCanonicalURL = "canonicalURL"
+	// MetadataLinkURL is the outbound link of an item whose URL is a discussion, such as a Reddit post
+	MetadataLinkURL = "linkURL"
+)
+
+// StoryIndex finds the story group of processed news
+type StoryIndex interface {
+	// FindStory returns the story ID of the given canonical URL, or an empty string
+	// if there is none
+	FindStory(ctx context.Context, canonicalURL string) (string, error)
+	// ClaimStory assigns storyID to a canonical URL unless another item claimed it
+	// first, atomically, and returns the story ID of the URL
+	ClaimStory(ctx context.Context, canonicalURL, storyID string) (string, error)
+	// SimilarStories returns up to limit processed news published between from and to
+	// that share at least one MinHash band with bands, with their story ID and signature
+	SimilarStories(ctx context.Context, bands []string, from, to time.Time, limit int) ([]models.ProcessedNews, error)
+}
+
+// StorySettings tunes the clustering of near duplicates
+type StorySettings struct {
+	// Threshold is the estimated Jaccard similarity of the words of two items above
+	// which they are the same story; zero disables clustering by similarity
+	Threshold float64
+	// Window is how far apart in publication time items of the same story may be
+	Window time.Duration
+	// MaxCandidates bounds the similar items compared with each new item
+	MaxCandidates int
+}
+
+// CanonicalURLStage sets the canonical URL of the item. The rel=canonical URL of the
+// page is preferred, then the outbound link, then the item URL.
+func CanonicalURLStage(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+	candidates := []string{
+		metadataString(raw, MetadataCanonicalURL),
+		canonical.FromHTML(raw.Content, news.URL),
+		metadataString(raw, MetadataLinkURL),
+		news.URL,
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if canonicalURL, err := canonical.URL(candidate); err == nil {
+			news.CanonicalURL = canonicalURL
+			return nil
+		}
+	}
+	return nil
+}
+
+// StoryStage links items into story groups: items sharing a canonical URL, and items
+// whose title and content are near duplicates within a time window
+type StoryStage struct {
+	index    StoryIndex
+	settings StorySettings
+}
+
+// NewStoryStage creates a stage looking up story groups in index. It must run after CanonicalURLStage.
+func NewStoryStage(index StoryIndex, settings StorySettings) *StoryStage {
+	return &StoryStage{index: index, settings: settings}
+}
+
+// Process joins the story of an earlier item with the same canonical URL, else the
+// story of the most similar item, else starts a new story
+func (s *StoryStage) Process(ctx context.Context, raw *models.RawNews, news *models.ProcessedNews) error {
+	news.MinHash, news.MinHashBands = nil, nil
+	text := news.ContentText
+	if text == "" {
+		text = htmltext.ToText(news.Content)
+	}
+	if signature, ok := minhash.Compute(news.Title, text); ok {
+		news.MinHash = signature
+		news.MinHashBands = signature.Bands()
+	}
+
+	if news.CanonicalURL != "" {
+		storyID, err := s.index.FindStory(ctx, news.CanonicalURL)
+		if err != nil {
+			return fmt.Errorf("failed to find story: %w", err)
+		}
+		if storyID != "" {
+			news.StoryID = storyID
+			return nil
+		}
+	}
+
+	storyID, err := s.similarStory(ctx, news)
+	if err != nil {
+		return fmt.Errorf("failed to find similar stories: %w", err)
+	}
+	if storyID == "" {
+		storyID = news.RawID.Hex()
+	}
+	if news.CanonicalURL != "" {
+		// Another worker may have claimed the URL since FindStory; its story wins
+		if storyID, err = s.index.ClaimStory(ctx, news.CanonicalURL, storyID); err != nil {
+			return fmt.Errorf("failed to claim story: %w", err)
+		}
+	}
+	news.StoryID = storyID
+	return nil
+}
+
+// similarStory returns the story of the most similar item above the threshold, if any
+func (s *StoryStage) similarStory(ctx context.Context, news *models.ProcessedNews) (string, error) {
+	if s.settings.Threshold <= 0 || news.MinHash == nil {
+		return "", nil
+	}
+
+	from, to := news.PublishedAt.Add(-s.settings.Window), news.PublishedAt.Add(s.settings.Window)
+	candidates, err := s.index.SimilarStories(ctx, news.MinHashBands, from, to, s.settings.MaxCandidates)
+	if err != nil {
+		return "", err
+	}
+
+	signature := minhash.Signature(news.MinHash)
+	var best string
+	bestSimilarity := s.settings.Threshold
+	for _, candidate := range candidates {
+		if candidate.RawID == news.RawID || candidate.StoryID == "" {
+			continue
+		}
+		if similarity := signature.Similarity(candidate.MinHash); similarity >= bestSimilarity {
+			best, bestSimilarity = candidate.StoryID, similarity
+		}
+	}
+	return best, nil
+}
+
+// metadataString returns a string metadata value of the raw item
+func metadataString(raw *models.RawNews, key string) string {
+	value, _ := raw.Metadata[key].(string)
+	return value
+}
@@ -0,0 +1,244 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeStoryIndex keeps the stories claimed per canonical URL and finds similar stories
+// among the items saved to a fakeStorage
+type fakeStoryIndex struct {
+	storage *fakeStorage
+	claims  map[string]string
+	// hidden claims are not found by FindStory, as if claimed by another worker since
+	hidden map[string]bool
+}
+
+func (f *fakeStoryIndex) FindStory(ctx context.Context, canonicalURL string) (string, error) {
+	if f.hidden[canonicalURL] {
+		return "", nil
+	}
+	return f.claims[canonicalURL], nil
+}
+
+func (f *fakeStoryIndex) ClaimStory(ctx context.Context, canonicalURL, storyID string) (string, error) {
+	if f.claims == nil {
+		f.claims = make(map[string]string)
+	}
+	if claimed, ok := f.claims[canonicalURL]; ok {
+		return claimed, nil
+	}
+	f.claims[canonicalURL] = storyID
+	return storyID, nil
+}
+
+func (f *fakeStoryIndex) SimilarStories(ctx context.Context, bands []string, from, to time.Time, limit int) ([]models.ProcessedNews, error) {
+	wanted := make(map[string]bool, len(bands))
+	for _, band := range bands {
+		wanted[band] = true
+	}
+	var similar []models.ProcessedNews
+	for _, news := range f.storage.processed {
+		if news.PublishedAt.Before(from) || news.PublishedAt.After(to) {
+			continue
+		}
+		for _, band := range news.MinHashBands {
+			if wanted[band] {
+				similar = append(similar, news)
+				break
+			}
+		}
+	}
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+// defaultStorySettings matches the clustering defaults of the app config
+var defaultStorySettings = StorySettings{Threshold: 0.3, Window: 48 * time.Hour, MaxCandidates: 200}
+
+func TestCanonicalURLStage(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  models.RawNews
+		want string
+	}{
+		{"item URL", models.RawNews{URL: "https://go.dev/blog/go1.26?utm_source=rss"}, "https://go.dev/blog/go1.26"},
+		{"outbound link", models.RawNews{
+			URL:      "https://www.reddit.com/r/golang/comments/1b2c3d4/go_126/",
+			Metadata: map[string]interface{}{MetadataLinkURL: "https://go.dev/blog/go1.26/"},
+		}, "https://go.dev/blog/go1.26"},
+		{"rel canonical in content", models.RawNews{
+			URL:     "https://mirror.example.com/posts/42",
+			Content: `<head><link rel="canonical" href="https://go.dev/blog/go1.26"></head><p>...</p>`,
+		}, "https://go.dev/blog/go1.26"},
+		{"canonical metadata", models.RawNews{
+			URL:      "https://example.com/a?page=1",
+			Metadata: map[string]interface{}{MetadataCanonicalURL: "https://example.com/a"},
+		}, "https://example.com/a"},
+		{"no usable URL", models.RawNews{URL: "tg://resolve?domain=golang"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := models.ProcessedNews{URL: tt.raw.URL}
+			require.NoError(t, CanonicalURLStage(context.Background(), &tt.raw, &news))
+			assert.Equal(t, tt.want, news.CanonicalURL)
+		})
+	}
+}
+
+func TestStoryStageGroupsByCanonicalURL(t *testing.T) {
+	published := time.Date(2026, 2, 11, 18, 0, 0, 0, time.UTC)
+	hn, reddit, rss, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	storage := &fakeStorage{raw: map[primitive.ObjectID]models.RawNews{
+		hn: {SourceType: "hackernews", SourceID: "1", Title: "Go 1.26 is released",
+			URL: "https://go.dev/blog/go1.26", PublishedAt: published},
+		reddit: {SourceType: "reddit", SourceID: "1b2c3d4", Title: "Go 1.26 released!",
+			URL:         "https://www.reddit.com/r/golang/comments/1b2c3d4/go_126_released/",
+			Metadata:    map[string]interface{}{MetadataLinkURL: "https://go.dev/blog/go1.26?utm_source=reddit"},
+			PublishedAt: published.Add(time.Hour)},
+		rss: {SourceType: "rss", SourceID: "go126", Title: "Go 1.26",
+			URL: "https://www.go.dev/blog/go1.26/#summary", PublishedAt: published.Add(2 * time.Hour)},
+		other: {SourceType: "rss", SourceID: "fuzz", Title: "Fuzzing in practice",
+			URL: "https://go.dev/blog/fuzz", PublishedAt: published},
+	}}
+	p := New(nil, storage, time.Second, StageFunc(CanonicalURLStage), NewStoryStage(&fakeStoryIndex{storage: storage}, StorySettings{}))
+
+	for _, id := range []primitive.ObjectID{hn, reddit, rss, other} {
+		require.NoError(t, p.ProcessOne(context.Background(), id.Hex()))
+	}
+
+	require.Len(t, storage.processed, 4)
+	for _, news := range storage.processed[:3] {
+		assert.Equal(t, "https://go.dev/blog/go1.26", news.CanonicalURL)
+		assert.Equal(t, hn.Hex(), news.StoryID, news.SourceType)
+	}
+	assert.Equal(t, other.Hex(), storage.processed[3].StoryID)
+}
+
+// labeledItem is an item of the labeled clustering fixture
+type labeledItem struct {
+	Story   string `json:"story"`
+	Hours   int    `json:"hours"`
+	Source  string `json:"source"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// clusterFixture processes the labeled fixture in publication order and returns the
+// expected and the assigned story of every item
+func clusterFixture(t *testing.T, settings StorySettings) (expected, assigned []string) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/stories.json")
+	require.NoError(t, err)
+	var items []labeledItem
+	require.NoError(t, json.Unmarshal(data, &items))
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Hours < items[j].Hours })
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	storage := &fakeStorage{raw: make(map[primitive.ObjectID]models.RawNews)}
+	ids := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		ids[i] = primitive.NewObjectID()
+		storage.raw[ids[i]] = models.RawNews{
+			SourceType:  item.Source,
+			SourceID:    strconv.Itoa(i),
+			Title:       item.Title,
+			Content:     item.Content,
+			URL:         fmt.Sprintf("https://%s.example.com/%d", item.Source, i),
+			PublishedAt: start.Add(time.Duration(item.Hours) * time.Hour),
+		}
+	}
+
+	p := New(nil, storage, time.Second, StageFunc(CanonicalURLStage), NewStoryStage(&fakeStoryIndex{storage: storage}, settings))
+	for i, id := range ids {
+		require.NoError(t, p.ProcessOne(context.Background(), id.Hex()))
+		expected = append(expected, items[i].Story)
+		assigned = append(assigned, storage.processed[i].StoryID)
+	}
+	return expected, assigned
+}
+
+// pairScores returns the pairwise precision and recall of assigned stories against expected ones
+func pairScores(expected, assigned []string) (precision, recall float64) {
+	var truePositives, predicted, actual int
+	for i := range expected {
+		for j := i + 1; j < len(expected); j++ {
+			same, grouped := expected[i] == expected[j], assigned[i] == assigned[j]
+			if same {
+				actual++
+			}
+			if grouped {
+				predicted++
+			}
+			if same && grouped {
+				truePositives++
+			}
+		}
+	}
+	precision, recall = 1, 1
+	if predicted > 0 {
+		precision = float64(truePositives) / float64(predicted)
+	}
+	if actual > 0 {
+		recall = float64(truePositives) / float64(actual)
+	}
+	return precision, recall
+}
+
+func TestStoryStageUsesClaimedStory(t *testing.T) {
+	// Another worker claimed the URL after this item looked it up
+	index := &fakeStoryIndex{
+		storage: &fakeStorage{},
+		claims:  map[string]string{"https://go.dev/blog/go1.26": "first"},
+		hidden:  map[string]bool{"https://go.dev/blog/go1.26": true},
+	}
+	stage := NewStoryStage(index, StorySettings{})
+
+	news := models.ProcessedNews{RawID: primitive.NewObjectID(), CanonicalURL: "https://go.dev/blog/go1.26", Title: "Go 1.26"}
+	require.NoError(t, stage.Process(context.Background(), &models.RawNews{}, &news))
+	assert.Equal(t, "first", news.StoryID)
+
+	news = models.ProcessedNews{RawID: primitive.NewObjectID(), CanonicalURL: "https://go.dev/blog/fuzz", Title: "Fuzzing"}
+	require.NoError(t, stage.Process(context.Background(), &models.RawNews{}, &news))
+	assert.Equal(t, news.RawID.Hex(), news.StoryID)
+	assert.Equal(t, news.RawID.Hex(), index.claims["https://go.dev/blog/fuzz"], "a new story claims its URL")
+}
+
+func TestStoryClusteringPrecision(t *testing.T) {
+	expected, assigned := clusterFixture(t, defaultStorySettings)
+	precision, recall := pairScores(expected, assigned)
+	t.Logf("precision %.2f, recall %.2f", precision, recall)
+	assert.GreaterOrEqual(t, precision, 0.95)
+	assert.GreaterOrEqual(t, recall, 0.9)
+}
+
+func TestStoryClusteringSettings(t *testing.T) {
+	// A threshold no pair reaches keeps every item in its own story
+	expected, assigned := clusterFixture(t, StorySettings{Threshold: 1, Window: 48 * time.Hour, MaxCandidates: 200})
+	precision, recall := pairScores(expected, assigned)
+	assert.Equal(t, 1.0, precision)
+	assert.Zero(t, recall)
+
+	// A short window splits write-ups published a day apart
+	expected, assigned = clusterFixture(t, StorySettings{Threshold: 0.3, Window: 2 * time.Hour, MaxCandidates: 200})
+	_, recall = pairScores(expected, assigned)
+	assert.Less(t, recall, 0.9)
+
+	// Without clustering only canonical URLs group items
+	expected, assigned = clusterFixture(t, StorySettings{})
+	_, recall = pairScores(expected, assigned)
+	assert.Zero(t, recall)
+}
@@ -2,7 +2,9 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,8 +18,8 @@ type RedisQueue struct {
 	failedKey string
 }
 
-// NewRedisQueue creates a new Redis queue
-func NewRedisQueue(address, password string, db int) (*RedisQueue, error) {
+// NewRedisQueue creates a new Redis queue using the given keys for the pending, processing and failed lists
+func NewRedisQueue(address, password string, db int, queueKey, processingKey, failedKey string) (*RedisQueue, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     address,
 		Password: password,
@@ -32,9 +34,9 @@ func NewRedisQueue(address, password string, db int) (*RedisQueue, error) {
 
 	return &RedisQueue{
 		client:        client,
-		queueKey:      "news:queue",
-		processingKey: "news:processing",
-		failedKey:     "news:failed",
+		queueKey:      queueKey,
+		processingKey: processingKey,
+		failedKey:     failedKey,
 	}, nil
 }
 
@@ -59,10 +61,17 @@ func (r *RedisQueue) AddToQueue(ctx context.Context, newsIDs []primitive.ObjectI
 	return r.client.RPush(ctx, r.queueKey, values...).Err()
 }
 
-// GetFromQueue retrieves a news ID from the queue and moves it to the processing queue
-func (r *RedisQueue) GetFromQueue(ctx context.Context) (string, error) {
+// ErrEmpty is returned by GetFromQueue when no item arrived before the timeout
+var ErrEmpty = errors.New("queue is empty")
+
+// GetFromQueue retrieves a news ID from the queue and moves it to the processing queue.
+// It waits up to timeout for an item and returns ErrEmpty if none arrives.
+func (r *RedisQueue) GetFromQueue(ctx context.Context, timeout time.Duration) (string, error) {
 	// Atomically move from queue to processing list (BLMOVE)
-	result, err := r.client.BLMove(ctx, r.queueKey, r.processingKey, "LEFT", "RIGHT", 0).Result()
+	result, err := r.client.BLMove(ctx, r.queueKey, r.processingKey, "LEFT", "RIGHT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrEmpty
+	}
 	if err != nil {
 		return "", err
 	}
@@ -119,4 +128,62 @@ func (r *RedisQueue) GetQueueStats(ctx context.Context) (map[string]int64, error
 		"processing": processingCount.Val(),
 		"failed":     failedCount.Val(),
 	}, nil
-}
\ No newline at end of file
+}
+
+// queueKeys maps the queue names used by the CLI to their Redis keys
+func (r *RedisQueue) queueKeys() map[string]string {
+	return map[string]string{
+		"queue":      r.queueKey,
+		"processing": r.processingKey,
+		"failed":     r.failedKey,
+	}
+}
+
+// resolveKey maps a queue name (queue, processing or failed) to its Redis key
+func (r *RedisQueue) resolveKey(name string) (string, error) {
+	key, ok := r.queueKeys()[name]
+	if !ok {
+		return "", fmt.Errorf("unknown queue %q, must be one of queue, processing, failed", name)
+	}
+	return key, nil
+}
+
+// Requeue moves every item of the named queue (processing or failed) back to the main queue
+// and returns how many items were moved
+func (r *RedisQueue) Requeue(ctx context.Context, from string) (int64, error) {
+	key, err := r.resolveKey(from)
+	if err != nil {
+		return 0, err
+	}
+	if key == r.queueKey {
+		return 0, fmt.Errorf("cannot requeue the main queue into itself")
+	}
+
+	var moved int64
+	for {
+		err := r.client.LMove(ctx, key, r.queueKey, "LEFT", "RIGHT").Err()
+		if errors.Is(err, redis.Nil) {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
+
+// Purge deletes every item of the named queue and returns how many items were removed
+func (r *RedisQueue) Purge(ctx context.Context, name string) (int64, error) {
+	key, err := r.resolveKey(name)
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := r.client.TxPipeline()
+	length := pipe.LLen(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return length.Val(), nil
+}
@@ -0,0 +1,508 @@
+package sanitize
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	listPattern      = regexp.MustCompile(`^( *)([-*+]|[0-9]{1,9}[.)])(?:[ \t]+|$)`)
+	fencePattern     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	separatorPattern = regexp.MustCompile(`^[ \t]*\|?(?:[ \t]*:?-+:?[ \t]*\|)*[ \t]*:?-+:?[ \t]*\|?[ \t]*$`)
+	autolinkPattern  = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+)>`)
+	redditPattern    = regexp.MustCompile(`^/?([ru])/([A-Za-z0-9_-]{2,})`)
+)
+
+// Markdown converts Reddit flavoured markdown to HTML: paragraphs, headings, lists, block
+// quotes, fenced and indented code, tables, rules, emphasis, strikethrough, superscript,
+// spoilers, links, images, bare URLs and r/ and u/ references. Raw HTML is escaped, as
+// Reddit does; the result still has to be sanitized.
+func Markdown(source string) string {
+	// The Reddit API escapes &, < and > in markdown bodies
+	source = html.UnescapeString(strings.ReplaceAll(source, "\r\n", "\n"))
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = expandIndent(line)
+	}
+
+	var b strings.Builder
+	renderBlocks(&b, lines, false)
+	return b.String()
+}
+
+// expandIndent replaces the tabs of the indentation of line with four spaces
+func expandIndent(line string) string {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(trimmed)]
+	if !strings.Contains(indent, "\t") {
+		return line
+	}
+	return strings.ReplaceAll(indent, "\t", "    ") + trimmed
+}
+
+// indentation returns the number of leading spaces of line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// startsBlock reports whether line starts a block other than a paragraph, and so ends
+// the paragraph before it
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) ||
+		fencePattern.MatchString(line) ||
+		strings.HasPrefix(trimmed, ">") && !strings.HasPrefix(trimmed, ">!") ||
+		listPattern.MatchString(line) && trimmed != ""
+}
+
+// renderBlocks renders block level markdown. Paragraphs of tight list items are
+// rendered without <p>.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlankLine(line):
+			i++
+		case fencePattern.MatchString(line):
+			i = fencedCode(b, lines, i)
+		case indentation(line) >= 4:
+			i = indentedCode(b, lines, i)
+		case headingPattern.MatchString(line):
+			match := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(match[1]))
+			b.WriteString("<h" + level + ">" + inline(match[2]) + "</h" + level + ">\n")
+			i++
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(strings.TrimSpace(line), ">") && !strings.HasPrefix(strings.TrimSpace(line), ">!"):
+			i = blockquote(b, lines, i)
+		case listPattern.MatchString(line):
+			i = list(b, lines, i)
+		case i+1 < len(lines) && strings.Contains(line, "|") && separatorPattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = table(b, lines, i)
+		default:
+			i = paragraph(b, lines, i, tight)
+		}
+	}
+}
+
+// fencedCode renders the code block opened by the fence at lines[i]
+func fencedCode(b *strings.Builder, lines []string, i int) int {
+	match := fencePattern.FindStringSubmatch(lines[i])
+	indent, fence, language := len(match[1]), match[2], match[3]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		line = line[min(indent, indentation(line)):]
+		code = append(code, line)
+	}
+	writeCode(b, code, language)
+	return i
+}
+
+// indentedCode renders the code block indented by four spaces starting at lines[i]
+func indentedCode(b *strings.Builder, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines) && (isBlankLine(lines[i]) || indentation(lines[i]) >= 4); i++ {
+		line := lines[i]
+		code = append(code, line[min(4, indentation(line)):])
+	}
+	for len(code) > 0 && isBlankLine(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	writeCode(b, code, "")
+	return i
+}
+
+func writeCode(b *strings.Builder, code []string, language string) {
+	b.WriteString("<pre><code")
+	if language != "" {
+		b.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// blockquote renders the quote starting at lines[i]
+func blockquote(b *strings.Builder, lines []string, i int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, ">!") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		quoted = append(quoted, strings.TrimPrefix(trimmed, " "))
+	}
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, quoted, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// list renders the list starting at lines[i]. Lines indented past the list marker
+// belong to the current item; a blank line between items makes the list loose.
+func list(b *strings.Builder, lines []string, i int) int {
+	first := listPattern.FindStringSubmatch(lines[i])
+	indent, ordered := len(first[1]), isOrdered(first[2])
+
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		match := listPattern.FindStringSubmatch(lines[i])
+		if match == nil || len(match[1]) > indent+1 || isOrdered(match[2]) != ordered {
+			break
+		}
+		contentIndent := len(match[0])
+		item := []string{lines[i][len(match[0]):]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlankLine(line) {
+				next := i + 1
+				for next < len(lines) && isBlankLine(lines[next]) {
+					next++
+				}
+				if next == len(lines) {
+					break
+				}
+				if indentation(lines[next]) <= indent+1 {
+					if sibling := listPattern.FindStringSubmatch(lines[next]); sibling != nil && isOrdered(sibling[2]) == ordered {
+						// The next item follows a blank line
+						loose = true
+						i = next
+					}
+					break
+				}
+				item = append(item, "")
+				continue
+			}
+			if indentation(line) > indent+1 {
+				item = append(item, line[min(contentIndent, indentation(line)):])
+				continue
+			}
+			if startsBlock(line) {
+				break
+			}
+			// A lazy continuation of the paragraph of the item
+			item = append(item, strings.TrimSpace(line))
+		}
+		items = append(items, item)
+		if i < len(lines) && isBlankLine(lines[i]) {
+			break
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if start, _ := strconv.Atoi(strings.TrimRight(first[2], ".)")); ordered && start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		renderBlocks(b, item, !loose && !hasBlankLine(item))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func isOrdered(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+func hasBlankLine(lines []string) bool {
+	for _, line := range lines {
+		if isBlankLine(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// table renders the pipe table whose header is lines[i]
+func table(b *strings.Builder, lines []string, i int) int {
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for _, cell := range tableCells(lines[i]) {
+		b.WriteString("<th>" + inline(cell) + "</th>")
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for i += 2; i < len(lines) && !isBlankLine(lines[i]) && strings.Contains(lines[i], "|"); i++ {
+		b.WriteString("<tr>")
+		for _, cell := range tableCells(lines[i]) {
+			b.WriteString("<td>" + inline(cell) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// tableCells splits a table row into cells
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// paragraph renders the paragraph starting at lines[i]. Lines ending with two spaces
+// or a backslash end with a line break.
+func paragraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text strings.Builder
+	separator := ""
+	for start := i; i < len(lines) && !isBlankLine(lines[i]) && (i == start || !startsBlock(lines[i])); i++ {
+		line := lines[i]
+		text.WriteString(separator + strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+		separator = " "
+		if strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\") {
+			separator = "\n"
+		}
+	}
+
+	rendered := strings.ReplaceAll(inline(text.String()), "\n", "<br>\n")
+	if tight {
+		b.WriteString(rendered + "\n")
+	} else {
+		b.WriteString("<p>" + rendered + "</p>\n")
+	}
+	return i
+}
+
+// inline renders the inline markdown of text
+func inline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if n := inlineElement(&b, text, i); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// inlineElement renders the inline element starting at text[i] and returns its length,
+// or 0 if there is none
+func inlineElement(b *strings.Builder, text string, i int) int {
+	rest := text[i:]
+	switch c := text[i]; {
+	case c == '\\' && len(rest) > 1 && strings.IndexByte("\\`*_{}[]()#+-.!|>~^<", rest[1]) >= 0:
+		b.WriteString(html.EscapeString(rest[1:2]))
+		return 2
+	case c == '`':
+		return codeSpan(b, rest)
+	case c == '!' && strings.HasPrefix(rest, "!["):
+		label, destination, title, n := parseLink(rest[1:])
+		if n == 0 {
+			return 0
+		}
+		b.WriteString(`<img src="` + html.EscapeString(destination) + `" alt="` + html.EscapeString(label) + `"`)
+		if title != "" {
+			b.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		b.WriteString(">")
+		return n + 1
+	case c == '[':
+		label, destination, title, n := parseLink(rest)
+		if n == 0 {
+			return 0
+		}
+		b.WriteString(`<a href="` + html.EscapeString(destination) + `"`)
+		if title != "" {
+			b.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		b.WriteString(">" + inline(label) + "</a>")
+		return n
+	case c == '<':
+		match := autolinkPattern.FindStringSubmatch(rest)
+		if match == nil {
+			return 0
+		}
+		writeLink(b, match[1], match[1])
+		return len(match[0])
+	case c == '>' && strings.HasPrefix(rest, ">!"):
+		// Spoilers are shown as plain text
+		if end := strings.Index(rest[2:], "!<"); end > 0 {
+			b.WriteString(inline(rest[2 : 2+end]))
+			return end + 4
+		}
+	case c == '^':
+		return superscript(b, rest)
+	case c == '*' || c == '_' || c == '~':
+		return emphasis(b, text, i)
+	case (c == 'h' || c == 'w') && (i == 0 || !isWordByte(text[i-1])):
+		match := urlPattern.FindStringIndex(rest)
+		if match == nil || match[0] != 0 {
+			return 0
+		}
+		end := trimURL(rest, 0, match[1])
+		writeLink(b, linkTarget(rest[:end]), rest[:end])
+		return end
+	case (c == 'r' || c == 'u' || c == '/') && (i == 0 || !isWordByte(text[i-1])):
+		match := redditPattern.FindStringSubmatch(rest)
+		if match == nil {
+			return 0
+		}
+		kind := match[1]
+		if kind == "u" {
+			kind = "user"
+		}
+		writeLink(b, "https://www.reddit.com/"+kind+"/"+match[2], match[0])
+		return len(match[0])
+	}
+	return 0
+}
+
+func writeLink(b *strings.Builder, href, label string) {
+	b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(label) + "</a>")
+}
+
+// codeSpan renders the code span at the start of text
+func codeSpan(b *strings.Builder, text string) int {
+	ticks := len(text) - len(strings.TrimLeft(text, "`"))
+	end := strings.Index(text[ticks:], text[:ticks])
+	if end < 0 {
+		b.WriteString(text[:ticks])
+		return ticks
+	}
+	code := strings.TrimSpace(text[ticks : ticks+end])
+	b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+	return 2*ticks + end
+}
+
+// parseLink parses a [label](destination "title") link at the start of text and
+// returns its length, or 0 if text does not start with a link
+func parseLink(text string) (label, destination, title string, n int) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(text) && closing < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= len(text) || text[closing+1] != '(' {
+		return "", "", "", 0
+	}
+
+	depth = 0
+	end := -1
+	for i := closing + 1; i < len(text) && end < 0; i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return "", "", "", 0
+	}
+
+	target := strings.TrimSpace(text[closing+2 : end])
+	if space := strings.IndexAny(target, " \t"); space >= 0 {
+		title = strings.Trim(strings.TrimSpace(target[space:]), `"'`)
+		target = target[:space]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return text[1:closing], target, title, end + 1
+}
+
+// superscript renders ^word or ^(words) at the start of text
+func superscript(b *strings.Builder, text string) int {
+	if strings.HasPrefix(text, "^(") {
+		if end := strings.IndexByte(text, ')'); end > 2 {
+			b.WriteString("<sup>" + inline(text[2:end]) + "</sup>")
+			return end + 1
+		}
+		return 0
+	}
+	end := 1
+	for end < len(text) && text[end] != ' ' && text[end] != '\n' && text[end] != '^' {
+		end++
+	}
+	if end == 1 {
+		return 0
+	}
+	b.WriteString("<sup>" + inline(text[1:end]) + "</sup>")
+	return end
+}
+
+// emphasis renders the emphasis, strong emphasis or strikethrough opened at text[i]
+func emphasis(b *strings.Builder, text string, i int) int {
+	rest := text[i:]
+	delimiter, tag := rest[:1], "em"
+	switch {
+	case strings.HasPrefix(rest, "~~"):
+		delimiter, tag = "~~", "del"
+	case rest[0] == '~':
+		return 0
+	case len(rest) > 1 && rest[1] == rest[0]:
+		delimiter, tag = rest[:2], "strong"
+	}
+	word := delimiter[0] == '_'
+	if word && i > 0 && isWordByte(text[i-1]) {
+		return 0
+	}
+	if len(rest) <= len(delimiter) || rest[len(delimiter)] == ' ' || rest[len(delimiter)] == '\n' {
+		return 0
+	}
+
+	for from := len(delimiter); ; {
+		end := strings.Index(rest[from:], delimiter)
+		if end < 0 {
+			return 0
+		}
+		end += from
+		from = end + 1
+
+		after := end + len(delimiter)
+		switch {
+		case end == len(delimiter), rest[end-1] == ' ', rest[end-1] == '\n':
+			continue
+		case len(delimiter) == 1 && (rest[end-1] == delimiter[0] || after < len(rest) && rest[after] == delimiter[0]):
+			continue
+		case word && after < len(rest) && isWordByte(rest[after]):
+			continue
+		}
+		b.WriteString("<" + tag + ">" + inline(rest[len(delimiter):end]) + "</" + tag + ">")
+		return after
+	}
+}
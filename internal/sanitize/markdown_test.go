@@ -0,0 +1,64 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"paragraphs", "First line\nsame paragraph\n\nSecond", "<p>First line same paragraph</p>\n<p>Second</p>\n"},
+		{"hard breaks", "One  \ntwo\\\nthree", "<p>One<br>\ntwo<br>\nthree</p>\n"},
+		{"headings", "# Go 1.26 #\n\n### Notes\n\n#hashtag", "<h1>Go 1.26</h1>\n<h3>Notes</h3>\n<p>#hashtag</p>\n"},
+		{"emphasis", "**bold** *em* __strong__ _em_ ~~gone~~ snake_case_name 2*3*4",
+			"<p><strong>bold</strong> <em>em</em> <strong>strong</strong> <em>em</em> <del>gone</del> snake_case_name 2<em>3</em>4</p>\n"},
+		{"nested emphasis", "*a **b** c*", "<p><em>a <strong>b</strong> c</em></p>\n"},
+		{"unclosed emphasis", "5 * 3 = 15 and a*", "<p>5 * 3 = 15 and a*</p>\n"},
+		{"code spans", "Use `go vet` and ``a ` b``", "<p>Use <code>go vet</code> and <code>a ` b</code></p>\n"},
+		{"escapes", `\*not em\* and \[not a link\]`, "<p>*not em* and [not a link]</p>\n"},
+		{"links", `[Go](https://go.dev "The Go site") [docs](</doc/go1.26>) [*em*](https://go.dev/(x))`,
+			`<p><a href="https://go.dev" title="The Go site">Go</a> <a href="/doc/go1.26">docs</a> <a href="https://go.dev/(x)"><em>em</em></a></p>` + "\n"},
+		{"images", "![Gopher](https://go.dev/gopher.png)", `<p><img src="https://go.dev/gopher.png" alt="Gopher"></p>` + "\n"},
+		{"bare URLs", "See https://go.dev/blog/go1.26, <https://pkg.go.dev> or www.golang.org.",
+			`<p>See <a href="https://go.dev/blog/go1.26">https://go.dev/blog/go1.26</a>, <a href="https://pkg.go.dev">https://pkg.go.dev</a> or <a href="https://www.golang.org">www.golang.org</a>.</p>` + "\n"},
+		{"reddit references", "Ask r/golang or /u/spez, not user/name",
+			`<p>Ask <a href="https://www.reddit.com/r/golang">r/golang</a> or <a href="https://www.reddit.com/user/spez">/u/spez</a>, not user/name</p>` + "\n"},
+		{"superscript and spoilers", "e = mc^2 and ^(two words) >!Rosebud!<", "<p>e = mc<sup>2</sup> and <sup>two words</sup> Rosebud</p>\n"},
+		{"escaped HTML", "&lt;script&gt;alert(1)&lt;/script&gt; <b>x</b> AT&amp;T", "<p>&lt;script&gt;alert(1)&lt;/script&gt; &lt;b&gt;x&lt;/b&gt; AT&amp;T</p>\n"},
+		{"tight list", "- one\n- two\n  - nested\n- three\ncontinued", "<ul>\n<li>one\n</li>\n<li>two\n<ul>\n<li>nested\n</li>\n</ul>\n</li>\n<li>three continued\n</li>\n</ul>\n"},
+		{"loose list", "1. one\n\n2. two", "<ol>\n<li><p>one</p>\n</li>\n<li><p>two</p>\n</li>\n</ol>\n"},
+		{"list start", "3) three\n4) four\n\nAfter", "<ol start=\"3\">\n<li>three\n</li>\n<li>four\n</li>\n</ol>\n<p>After</p>\n"},
+		{"lists after paragraphs", "Steps:\n* one\n* two", "<p>Steps:</p>\n<ul>\n<li>one\n</li>\n<li>two\n</li>\n</ul>\n"},
+		{"quotes", "> quoted\n> **text**\n>\n> more\n\nAfter", "<blockquote>\n<p>quoted <strong>text</strong></p>\n<p>more</p>\n</blockquote>\n<p>After</p>\n"},
+		{"fenced code", "```go\nif a < b {\n\treturn\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n    return\n}\n</code></pre>\n"},
+		{"unclosed fence", "~~~\ncode", "<pre><code>code\n</code></pre>\n"},
+		{"indented code", "Text\n\n    x := 1\n\n    y := 2\n\nAfter", "<p>Text</p>\n<pre><code>x := 1\n\ny := 2\n</code></pre>\n<p>After</p>\n"},
+		{"rules", "Above\n\n---\n\n* * *", "<p>Above</p>\n<hr>\n<hr>\n"},
+		{"tables", "| Version | Date |\n|:--|--:|\n| 1.26 | Feb |\n| **1.25** | Aug |\n\nAfter",
+			"<table>\n<thead>\n<tr><th>Version</th><th>Date</th></tr>\n</thead>\n<tbody>\n" +
+				"<tr><td>1.26</td><td>Feb</td></tr>\n<tr><td><strong>1.25</strong></td><td>Aug</td></tr>\n</tbody>\n</table>\n<p>After</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Markdown(tt.in))
+		})
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	doc := Normalize("Go 1.26 is out, see [the notes](/r/golang/wiki/releases) and "+
+		"[this](javascript:alert(1)).\n\n![chart](https://i.redd.it/chart.png)",
+		FormatMarkdown, "https://www.reddit.com/r/golang/comments/1b2c3d4/go_126/")
+
+	assert.Equal(t, `<p>Go 1.26 is out, see <a href="https://www.reddit.com/r/golang/wiki/releases" rel="nofollow noopener noreferrer">the notes</a> and this.</p>`+"\n"+
+		`<p><img src="https://i.redd.it/chart.png" alt="chart"/></p>`, doc.HTML)
+	assert.Equal(t, "Go 1.26 is out, see the notes and this.", doc.Text)
+	assert.Equal(t, "https://i.redd.it/chart.png", doc.LeadImage)
+	assert.Equal(t, 9, doc.WordCount)
+}
@@ -0,0 +1,504 @@
+// Package sanitize normalizes news content. Sources deliver HTML, markdown, Telegram
+// markup or plain text; Normalize converts any of them into one allowlisted subset of
+// HTML that is safe to render, and derives a plain text version from it.
+package sanitize
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dzianismalei/infoBro/internal/htmltext"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Format is the markup of content as delivered by a source
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	// FormatTelegram is the HTML subset of Telegram messages, where line breaks are plain newlines
+	FormatTelegram Format = "telegram"
+	FormatText     Format = "text"
+)
+
+// Document is normalized content
+type Document struct {
+	// HTML is the sanitized content, with inline content grouped into paragraphs
+	HTML string
+	// Text is the plain text of HTML
+	Text string
+	// LeadImage is the URL of the first image of the content
+	LeadImage string
+	// WordCount is the number of words of Text
+	WordCount int
+}
+
+// allowedElements are kept with the listed attributes; <a> and <img> are handled apart
+var allowedElements = map[atom.Atom][]string{
+	atom.A: {"title"}, atom.Img: {"alt", "title"},
+	atom.P: nil, atom.Br: nil, atom.Hr: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.Em: nil, atom.U: nil, atom.S: nil, atom.Del: nil, atom.Ins: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Mark: nil, atom.Small: nil, atom.Abbr: {"title"}, atom.Cite: nil, atom.Q: nil,
+	atom.Code: {"class"}, atom.Pre: nil, atom.Kbd: nil, atom.Samp: nil, atom.Blockquote: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Caption: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil,
+	atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+	atom.Figure: nil, atom.Figcaption: nil,
+}
+
+// renamedElements are presentational elements replaced by their semantic equivalent
+var renamedElements = map[atom.Atom]atom.Atom{
+	atom.B: atom.Strong, atom.I: atom.Em, atom.Strike: atom.S, atom.Tt: atom.Code,
+}
+
+// droppedElements are removed together with their content
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Head: true, atom.Title: true, atom.Noscript: true,
+	atom.Template: true, atom.Iframe: true, atom.Frame: true, atom.Object: true, atom.Embed: true,
+	atom.Applet: true, atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true,
+	atom.Textarea: true, atom.Video: true, atom.Audio: true, atom.Source: true, atom.Track: true,
+	atom.Canvas: true, atom.Svg: true, atom.Math: true, atom.Link: true, atom.Meta: true, atom.Base: true,
+}
+
+// containerElements are unwrapped, but their content stays apart from its neighbours
+var containerElements = map[atom.Atom]bool{
+	atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Main: true, atom.Aside: true, atom.Nav: true, atom.Center: true, atom.Address: true,
+	atom.Details: true, atom.Summary: true,
+}
+
+// blockElements are the allowed elements that cannot be part of a paragraph
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Pre: true, atom.Blockquote: true,
+	atom.Table: true, atom.Hr: true, atom.Figure: true,
+}
+
+// voidElements have no content
+var voidElements = map[atom.Atom]bool{atom.Br: true, atom.Hr: true, atom.Img: true}
+
+// keptEmptyElements are kept without content, so tables keep their shape
+var keptEmptyElements = map[atom.Atom]bool{atom.Td: true, atom.Th: true}
+
+// lazySources are the attributes lazy loading scripts read the real image URL from
+var lazySources = []string{"data-src", "data-lazy-src", "data-original", "data-lazy", "data-url"}
+
+// linkRel is set on every link, as the content comes from third parties
+const linkRel = "nofollow noopener noreferrer"
+
+var (
+	languageClass = regexp.MustCompile(`^language-[\w+#-]+$`)
+	tagPattern    = regexp.MustCompile(`</?([a-zA-Z][a-zA-Z0-9]*)[\s/>]`)
+	entityPattern = regexp.MustCompile(`&(?:[a-zA-Z]+|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+)
+
+// Detect guesses the format of content that does not declare one: HTML if it contains
+// HTML tags or entities, else plain text
+func Detect(content string) Format {
+	for _, match := range tagPattern.FindAllStringSubmatch(content, -1) {
+		if atom.Lookup([]byte(strings.ToLower(match[1]))) != 0 {
+			return FormatHTML
+		}
+	}
+	if entityPattern.MatchString(content) {
+		return FormatHTML
+	}
+	return FormatText
+}
+
+// Normalize converts content of the given format to sanitized HTML. Relative links and
+// images are resolved against base, the URL of the item; without an absolute base they
+// are dropped.
+func Normalize(content string, format Format, base string) Document {
+	var nodes []*html.Node
+	switch format {
+	case FormatMarkdown:
+		nodes = parse(Markdown(content))
+	case FormatTelegram:
+		nodes = breakLines(parse(content))
+	case FormatText:
+		nodes = breakLines([]*html.Node{{Type: html.TextNode, Data: content}})
+	default:
+		nodes = parse(content)
+	}
+
+	s := &sanitizer{base: baseURL(base)}
+	source := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, node := range nodes {
+		source.AppendChild(node)
+	}
+	root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	s.appendChildren(root, source)
+	groupParagraphs(root)
+
+	var b strings.Builder
+	for node := root.FirstChild; node != nil; node = node.NextSibling {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		html.Render(&b, node)
+	}
+
+	doc := Document{HTML: b.String(), LeadImage: firstImage(root)}
+	doc.Text = htmltext.ToText(doc.HTML)
+	doc.WordCount = countWords(doc.Text)
+	return doc
+}
+
+// ImageURL returns the absolute http(s) URL of an image, resolved against base, or an
+// empty string if raw is not one
+func ImageURL(raw, base string) string {
+	return resolve(baseURL(base), raw, false)
+}
+
+// parse parses an HTML fragment in the context of a body
+func parse(fragment string) []*html.Node {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return []*html.Node{{Type: html.TextNode, Data: fragment}}
+	}
+	return nodes
+}
+
+// sanitizer copies the allowed part of a node tree
+type sanitizer struct {
+	base *url.URL
+}
+
+// appendChildren appends the sanitized children of src to dst
+func (s *sanitizer) appendChildren(dst, src *html.Node) {
+	for child := src.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			dst.AppendChild(&html.Node{Type: html.TextNode, Data: child.Data})
+		case html.ElementNode:
+			s.appendElement(dst, child)
+		}
+	}
+}
+
+// appendElement appends the sanitized copy of n to dst, or its content if n is not allowed
+func (s *sanitizer) appendElement(dst, n *html.Node) {
+	if n.Namespace != "" || droppedElements[n.DataAtom] {
+		return
+	}
+	name := n.DataAtom
+	if renamed, ok := renamedElements[name]; ok {
+		name = renamed
+	}
+	attrs, ok := allowedElements[name]
+	if !ok {
+		if containerElements[name] {
+			dst.AppendChild(boundary())
+			s.appendChildren(dst, n)
+			dst.AppendChild(boundary())
+		} else {
+			s.appendChildren(dst, n)
+		}
+		return
+	}
+
+	el := newElement(name)
+	switch name {
+	case atom.A:
+		href := resolve(s.base, attribute(n, "href"), true)
+		if href == "" {
+			s.appendChildren(dst, n)
+			return
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: "href", Val: href}, html.Attribute{Key: "rel", Val: linkRel})
+	case atom.Img:
+		src := s.imageSource(n)
+		if src == "" || isPixel(n) {
+			return
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: "src", Val: src})
+	}
+	for _, key := range attrs {
+		if value, ok := allowedAttribute(key, attribute(n, key)); ok {
+			el.Attr = append(el.Attr, html.Attribute{Key: key, Val: value})
+		}
+	}
+
+	if !voidElements[name] {
+		s.appendChildren(el, n)
+		if name == atom.Blockquote {
+			groupParagraphs(el)
+		} else {
+			dropBoundaries(el)
+		}
+		if isEmpty(el) && !keptEmptyElements[name] {
+			return
+		}
+	}
+	dst.AppendChild(el)
+}
+
+// imageSource returns the URL of an image, preferring the real image of lazy loaded
+// images over the placeholder in src
+func (s *sanitizer) imageSource(n *html.Node) string {
+	candidates := make([]string, 0, len(lazySources)+3)
+	for _, key := range lazySources {
+		candidates = append(candidates, attribute(n, key))
+	}
+	candidates = append(candidates, largestCandidate(attribute(n, "data-srcset")), attribute(n, "src"), largestCandidate(attribute(n, "srcset")))
+	for _, candidate := range candidates {
+		if src := resolve(s.base, candidate, false); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// allowedAttribute validates the value of an allowed attribute
+func allowedAttribute(key, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "start", "colspan", "rowspan":
+		n, err := strconv.Atoi(value)
+		return strconv.Itoa(n), err == nil && n > 0 && n < 1000
+	case "class":
+		// Only the language of code blocks is kept, for syntax highlighting
+		return value, languageClass.MatchString(value)
+	default:
+		return value, value != ""
+	}
+}
+
+// isPixel reports whether an image is a tracking pixel
+func isPixel(n *html.Node) bool {
+	for _, key := range []string{"width", "height"} {
+		if value := strings.TrimSpace(attribute(n, key)); value == "0" || value == "1" || value == "1px" {
+			return true
+		}
+	}
+	return false
+}
+
+// largestCandidate returns the URL of the widest image of a srcset
+func largestCandidate(srcset string) string {
+	var best string
+	var bestSize float64
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		size := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			if parsed, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64); err == nil {
+				size = parsed
+			}
+		}
+		if best == "" || size > bestSize {
+			best, bestSize = fields[0], size
+		}
+	}
+	return best
+}
+
+// baseURL parses the URL relative references are resolved against; nil if it is not absolute
+func baseURL(base string) *url.URL {
+	u, err := url.Parse(strings.TrimSpace(base))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// resolve returns raw as an absolute http(s) URL, or a mailto URL for links, or an
+// empty string for any other scheme such as javascript: or data:
+func resolve(base *url.URL, raw string, link bool) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return ""
+		}
+	case "mailto":
+		if !link || u.Opaque == "" {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return u.String()
+}
+
+// attribute returns the value of an attribute of n
+func attribute(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func newElement(a atom.Atom) *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: a.String(), DataAtom: a}
+}
+
+// boundary marks where an unwrapped container ended, so its content is not merged
+// into the paragraph of its neighbours
+func boundary() *html.Node {
+	return &html.Node{Type: html.CommentNode, Data: "boundary"}
+}
+
+func isBoundary(n *html.Node) bool {
+	return n.Type == html.CommentNode
+}
+
+// isBlock reports whether n cannot be part of a paragraph
+func isBlock(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if blockElements[n.DataAtom] {
+		return true
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if isBlock(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBreak(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.DataAtom == atom.Br
+}
+
+func isBlank(n *html.Node) bool {
+	return n.Type == html.TextNode && strings.TrimSpace(n.Data) == ""
+}
+
+// isEmpty reports whether n has neither text nor images
+func isEmpty(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode && strings.TrimSpace(child.Data) != "":
+			return false
+		case child.Type == html.ElementNode && (voidElements[child.DataAtom] && !isBreak(child) || !isEmpty(child)):
+			return false
+		}
+	}
+	return true
+}
+
+// groupParagraphs wraps the runs of inline content of parent into paragraphs. Runs end
+// at block elements, at the end of unwrapped containers and at double line breaks.
+func groupParagraphs(parent *html.Node) {
+	children := detachChildren(parent)
+
+	var run []*html.Node
+	flush := func() {
+		for len(run) > 0 && (isBlank(run[0]) || isBreak(run[0])) {
+			run = run[1:]
+		}
+		for len(run) > 0 && (isBlank(run[len(run)-1]) || isBreak(run[len(run)-1])) {
+			run = run[:len(run)-1]
+		}
+		if len(run) > 0 {
+			p := newElement(atom.P)
+			for _, node := range run {
+				p.AppendChild(node)
+			}
+			parent.AppendChild(p)
+		}
+		run = nil
+	}
+
+	for _, child := range children {
+		switch {
+		case isBoundary(child):
+			flush()
+		case isBlock(child):
+			flush()
+			parent.AppendChild(child)
+		case isBreak(child) && lastBreak(run):
+			flush()
+		default:
+			run = append(run, child)
+		}
+	}
+	flush()
+}
+
+// lastBreak reports whether the last node of run, ignoring whitespace, is a line break
+func lastBreak(run []*html.Node) bool {
+	for i := len(run) - 1; i >= 0; i-- {
+		if !isBlank(run[i]) {
+			return isBreak(run[i])
+		}
+	}
+	return false
+}
+
+// dropBoundaries removes the container boundaries of parent, keeping a line break
+// between inline content they separated
+func dropBoundaries(parent *html.Node) {
+	for child := parent.FirstChild; child != nil; {
+		next := child.NextSibling
+		if isBoundary(child) {
+			prev := child.PrevSibling
+			if prev != nil && next != nil && !isBlock(prev) && !isBreak(prev) && !isBoundary(next) && !isBlock(next) {
+				parent.InsertBefore(newElement(atom.Br), child)
+			}
+			parent.RemoveChild(child)
+		}
+		child = next
+	}
+}
+
+func detachChildren(parent *html.Node) []*html.Node {
+	var children []*html.Node
+	for child := parent.FirstChild; child != nil; {
+		next := child.NextSibling
+		parent.RemoveChild(child)
+		children = append(children, child)
+		child = next
+	}
+	return children
+}
+
+// firstImage returns the source of the first image under n
+func firstImage(n *html.Node) string {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Img {
+			return attribute(child, "src")
+		}
+		if src := firstImage(child); src != "" {
+			return src
+		}
+	}
+	return ""
+}
+
+// countWords counts the words of text, not counting punctuation on its own
+func countWords(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,103 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const itemURL = "https://blog.example.com/posts/go-1.26/"
+
+func TestNormalizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"inline content is a paragraph", "Go <b>1.26</b> is <i>out</i>", "<p>Go <strong>1.26</strong> is <em>out</em></p>"},
+		{"scripts and styles", `<p style="color:red" onclick="steal()">Text</p><script>alert(1)</script><style>p{}</style>`, "<p>Text</p>"},
+		{"embeds", `<p>Watch</p><iframe src="https://www.youtube.com/embed/x"></iframe><form><input name="q"></form>`, "<p>Watch</p>"},
+		{"unsafe links", `<a href="javascript:alert(1)">one</a> <a href="data:text/html,x">two</a> <a href=" JAVA&#x09;SCRIPT:x">three</a>`, "<p>one two three</p>"},
+		{"relative links", `<a href="../go-1.25/">previous</a> <a href="//go.dev/doc">docs</a> <a href="#notes">notes</a>`,
+			`<p><a href="https://blog.example.com/posts/go-1.25/" rel="nofollow noopener noreferrer">previous</a> ` +
+				`<a href="https://go.dev/doc" rel="nofollow noopener noreferrer">docs</a> ` +
+				`<a href="https://blog.example.com/posts/go-1.26/#notes" rel="nofollow noopener noreferrer">notes</a></p>`},
+		{"mail links", `<a href="mailto:golang-dev@googlegroups.com" target="_blank">mail</a>`,
+			`<p><a href="mailto:golang-dev@googlegroups.com" rel="nofollow noopener noreferrer">mail</a></p>`},
+		{"containers", "<div>First</div><div>Second <span>part</span></div>", "<p>First</p>\n<p>Second part</p>"},
+		{"double line breaks", "One<br>two<br><br>Three<br/>", "<p>One<br/>two</p>\n<p>Three</p>"},
+		{"loose text between blocks", "Intro<p>Body</p>Outro", "<p>Intro</p>\n<p>Body</p>\n<p>Outro</p>"},
+		{"empty elements", "<p> </p><p><b></b>Text<a href=\"https://go.dev\"> </a></p>", "<p>Text</p>"},
+		{"code blocks", `<pre><code class="language-go hljs">x := 1</code></pre><pre><code class="language-go">y := 2</code></pre>`,
+			"<pre><code>x := 1</code></pre>\n<pre><code class=\"language-go\">y := 2</code></pre>"},
+		{"tables", `<table border="1"><tr><th colspan="2">Go</th></tr><tr><td></td><td rowspan="x">1.26</td></tr></table>`,
+			`<table><tbody><tr><th colspan="2">Go</th></tr><tr><td></td><td>1.26</td></tr></tbody></table>`},
+		{"lists in containers", "<ul><li><div>a</div><div>b</div></li></ul>", "<ul><li>a<br/>b</li></ul>"},
+		{"quotes", "<blockquote>Quoted<p>text</p></blockquote>", "<blockquote><p>Quoted</p><p>text</p></blockquote>"},
+		{"comments", "<p>Text<!-- hidden --></p>", "<p>Text</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.in, FormatHTML, itemURL).HTML)
+		})
+	}
+}
+
+func TestNormalizeImages(t *testing.T) {
+	doc := Normalize(`<div class="hero">`+
+		`<img src="https://tracker.example.com/p.gif" width="1" height="1">`+
+		`<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="/images/gopher.png" alt="Gopher" class="lazy">`+
+		`</div><p>Text</p><picture><source srcset="/a.webp"><img srcset="/small.jpg 320w, /large.jpg 1280w" alt=""></picture>`+
+		`<img src="javascript:alert(1)">`, FormatHTML, itemURL)
+
+	assert.Equal(t, `<p><img src="https://blog.example.com/images/gopher.png" alt="Gopher"/></p>`+"\n"+
+		"<p>Text</p>\n"+
+		`<p><img src="https://blog.example.com/large.jpg"/></p>`, doc.HTML)
+	assert.Equal(t, "https://blog.example.com/images/gopher.png", doc.LeadImage)
+
+	assert.Empty(t, Normalize(`<p><img src="/relative.png">Text</p>`, FormatHTML, "").LeadImage)
+	assert.Equal(t, "https://blog.example.com/thumb.jpg", ImageURL("/thumb.jpg", itemURL))
+	assert.Empty(t, ImageURL("data:image/png;base64,AAAA", itemURL))
+}
+
+func TestNormalizeTelegram(t *testing.T) {
+	doc := Normalize("<b>Go 1.26</b> is out!\n\nRelease notes: https://go.dev/doc/go1.26\n"+
+		"<tg-spoiler>Green Tea GC</tg-spoiler> by default <tg-emoji emoji-id=\"5368324170671202286\">🔥</tg-emoji>\n\n"+
+		"<pre><code class=\"language-go\">go install\ngo version</code></pre>"+
+		"<blockquote expandable>first\nsecond</blockquote>", FormatTelegram, "https://t.me/golang_news/1234")
+
+	assert.Equal(t, "<p><strong>Go 1.26</strong> is out!</p>\n"+
+		`<p>Release notes: <a href="https://go.dev/doc/go1.26" rel="nofollow noopener noreferrer">https://go.dev/doc/go1.26</a><br/>`+
+		"Green Tea GC by default 🔥</p>\n"+
+		"<pre><code class=\"language-go\">go install\ngo version</code></pre>\n"+
+		"<blockquote><p>first<br/>second</p></blockquote>", doc.HTML)
+	assert.Equal(t, "Go 1.26 is out!\n\nRelease notes: https://go.dev/doc/go1.26\nGreen Tea GC by default 🔥\n\ngo install go version\n\nfirst\nsecond", doc.Text)
+}
+
+func TestNormalizeText(t *testing.T) {
+	doc := Normalize("Go 1.26 <is> out & ready.\nSee www.go.dev/doc.\n\n"+
+		"Talk (https://www.youtube.com/watch?v=abc).", FormatText, "")
+
+	assert.Equal(t, "<p>Go 1.26 &lt;is&gt; out &amp; ready.<br/>"+
+		`See <a href="https://www.go.dev/doc" rel="nofollow noopener noreferrer">www.go.dev/doc</a>.</p>`+"\n"+
+		`<p>Talk (<a href="https://www.youtube.com/watch?v=abc" rel="nofollow noopener noreferrer">https://www.youtube.com/watch?v=abc</a>).</p>`, doc.HTML)
+	assert.Equal(t, "Go 1.26 <is> out & ready.\nSee www.go.dev/doc.\n\nTalk (https://www.youtube.com/watch?v=abc).", doc.Text)
+	assert.Equal(t, 9, doc.WordCount)
+}
+
+func TestNormalizeEmpty(t *testing.T) {
+	for _, format := range []Format{FormatHTML, FormatMarkdown, FormatTelegram, FormatText} {
+		assert.Equal(t, Document{}, Normalize("", format, itemURL), format)
+	}
+	assert.Equal(t, Document{}, Normalize("<script>alert(1)</script><p> </p>", FormatHTML, itemURL))
+}
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, FormatHTML, Detect("<p>Go 1.26</p>"))
+	assert.Equal(t, FormatHTML, Detect("Tom &amp; Jerry"))
+	assert.Equal(t, FormatHTML, Detect("Line<br/>break"))
+	assert.Equal(t, FormatText, Detect("if a < b && c > d"))
+	assert.Equal(t, FormatText, Detect("Generic <T> and <Foo> types"))
+	assert.Equal(t, FormatText, Detect("Plain text\nwith lines"))
+}
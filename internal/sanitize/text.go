@@ -0,0 +1,97 @@
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// urlPattern matches bare URLs in text
+var urlPattern = regexp.MustCompile(`(?:https?://|www\.)[^\s<>"]+`)
+
+// linkify converts plain text to text nodes, with links for bare URLs
+func linkify(text string) []*html.Node {
+	var nodes []*html.Node
+	last := 0
+	for _, match := range urlPattern.FindAllStringIndex(text, -1) {
+		start, end := match[0], trimURL(text, match[0], match[1])
+		if start > 0 && isWordByte(text[start-1]) {
+			continue
+		}
+		if start > last {
+			nodes = append(nodes, &html.Node{Type: html.TextNode, Data: text[last:start]})
+		}
+		a := newElement(atom.A)
+		a.Attr = []html.Attribute{{Key: "href", Val: linkTarget(text[start:end])}}
+		a.AppendChild(&html.Node{Type: html.TextNode, Data: text[start:end]})
+		nodes = append(nodes, a)
+		last = end
+	}
+	if last < len(text) {
+		nodes = append(nodes, &html.Node{Type: html.TextNode, Data: text[last:]})
+	}
+	return nodes
+}
+
+// trimURL returns the end of the URL matched between start and end, without trailing
+// punctuation and closing parentheses that belong to the sentence
+func trimURL(text string, start, end int) int {
+	for end > start {
+		last := text[end-1]
+		switch {
+		case strings.IndexByte(".,:;!?'*_~", last) >= 0:
+			end--
+		case last == ')' && strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")"):
+			end--
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+// linkTarget returns the link of a bare URL, which may lack its scheme
+func linkTarget(match string) string {
+	if strings.HasPrefix(match, "www.") {
+		return "https://" + match
+	}
+	return match
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '/' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// breakLines links the bare URLs of text outside links and code blocks and replaces
+// its newlines with line breaks, for formats where newlines are significant
+func breakLines(nodes []*html.Node) []*html.Node {
+	var result []*html.Node
+	for _, node := range nodes {
+		switch {
+		case node.Type == html.TextNode:
+			for _, part := range linkify(node.Data) {
+				if part.Type != html.TextNode {
+					result = append(result, part)
+					continue
+				}
+				for i, line := range strings.Split(strings.ReplaceAll(part.Data, "\r\n", "\n"), "\n") {
+					if i > 0 {
+						result = append(result, newElement(atom.Br))
+					}
+					if line != "" {
+						result = append(result, &html.Node{Type: html.TextNode, Data: line})
+					}
+				}
+			}
+			continue
+		case node.Type == html.ElementNode && node.DataAtom != atom.A && node.DataAtom != atom.Pre && node.DataAtom != atom.Code:
+			for _, child := range breakLines(detachChildren(node)) {
+				node.AppendChild(child)
+			}
+		}
+		result = append(result, node)
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetBackfill returns the backfill progress of a source, or nil if it was never backfilled
+func (m *MongoDB) GetBackfill(ctx context.Context, connector, source string) (*models.Backfill, error) {
+	collection := m.client.Database(m.database).Collection(m.backfillCollection)
+
+	var backfill models.Backfill
+	err := collection.FindOne(ctx, bson.M{"connector": connector, "source": source}).Decode(&backfill)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &backfill, nil
+}
+
+// SaveBackfill stores the backfill progress of a source, replacing the previous one
+func (m *MongoDB) SaveBackfill(ctx context.Context, backfill *models.Backfill) error {
+	collection := m.client.Database(m.database).Collection(m.backfillCollection)
+
+	filter := bson.M{"connector": backfill.Connector, "source": backfill.Source}
+	_, err := collection.ReplaceOne(ctx, filter, backfill, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListBreakers returns the circuit breakers of every source that failed recently
+func (m *MongoDB) ListBreakers(ctx context.Context) ([]models.SourceBreaker, error) {
+	collection := m.client.Database(m.database).Collection(m.breakerCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "connector", Value: 1}, {Key: "source", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var breakers []models.SourceBreaker
+	if err := cursor.All(ctx, &breakers); err != nil {
+		return nil, err
+	}
+	return breakers, nil
+}
+
+// SaveBreaker stores the circuit breaker of a source, replacing the previous one
+func (m *MongoDB) SaveBreaker(ctx context.Context, breaker *models.SourceBreaker) error {
+	collection := m.client.Database(m.database).Collection(m.breakerCollection)
+
+	filter := bson.M{"connector": breaker.Connector, "source": breaker.Source}
+	_, err := collection.ReplaceOne(ctx, filter, breaker, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteBreaker removes the circuit breaker of a source, which closes it
+func (m *MongoDB) DeleteBreaker(ctx context.Context, connector, source string) error {
+	collection := m.client.Database(m.database).Collection(m.breakerCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"connector": connector, "source": source})
+	return err
+}
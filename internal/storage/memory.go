@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStateRepository keeps channel states in memory. It is used for dry runs
+// and previews, where connectors must not advance their persisted state.
+type MemoryStateRepository struct {
+	mu     sync.Mutex
+	states map[string]models.ChannelState
+}
+
+// NewMemoryStateRepository creates an empty in-memory channel state repository
+func NewMemoryStateRepository() *MemoryStateRepository {
+	return &MemoryStateRepository{states: make(map[string]models.ChannelState)}
+}
+
+// GetChannelState retrieves a channel state, returning an empty state for unknown channels
+func (m *MemoryStateRepository) GetChannelState(ctx context.Context, channelID string) (*models.ChannelState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[channelID]
+	if !ok {
+		return &models.ChannelState{ChannelID: channelID}, nil
+	}
+	return &state, nil
+}
+
+// UpdateChannelState stores a copy of the channel state
+func (m *MemoryStateRepository) UpdateChannelState(ctx context.Context, state *models.ChannelState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *state
+	stored.SeenIDs = append([]string(nil), state.SeenIDs...)
+	stored.Validators = append([]models.HTTPValidators(nil), state.Validators...)
+	m.states[state.ChannelID] = stored
+	return nil
+}
+
+// MemoryBreakerRepository keeps source circuit breakers in memory
+type MemoryBreakerRepository struct {
+	mu       sync.Mutex
+	breakers map[[2]string]models.SourceBreaker
+}
+
+// NewMemoryBreakerRepository creates an empty in-memory breaker repository
+func NewMemoryBreakerRepository() *MemoryBreakerRepository {
+	return &MemoryBreakerRepository{breakers: make(map[[2]string]models.SourceBreaker)}
+}
+
+// ListBreakers returns every stored breaker ordered by connector and source
+func (m *MemoryBreakerRepository) ListBreakers(ctx context.Context) ([]models.SourceBreaker, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	breakers := make([]models.SourceBreaker, 0, len(m.breakers))
+	for _, breaker := range m.breakers {
+		breakers = append(breakers, breaker)
+	}
+	sort.Slice(breakers, func(i, j int) bool {
+		if breakers[i].Connector != breakers[j].Connector {
+			return breakers[i].Connector < breakers[j].Connector
+		}
+		return breakers[i].Source < breakers[j].Source
+	})
+	return breakers, nil
+}
+
+// SaveBreaker stores a copy of the breaker
+func (m *MemoryBreakerRepository) SaveBreaker(ctx context.Context, breaker *models.SourceBreaker) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.breakers[[2]string{breaker.Connector, breaker.Source}] = *breaker
+	return nil
+}
+
+// DeleteBreaker removes the breaker of a source
+func (m *MemoryBreakerRepository) DeleteBreaker(ctx context.Context, connector, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.breakers, [2]string{connector, source})
+	return nil
+}
+
+// MemoryBackfillRepository keeps backfill progress in memory
+type MemoryBackfillRepository struct {
+	mu        sync.Mutex
+	backfills map[[2]string]models.Backfill
+}
+
+// NewMemoryBackfillRepository creates an empty in-memory backfill repository
+func NewMemoryBackfillRepository() *MemoryBackfillRepository {
+	return &MemoryBackfillRepository{backfills: make(map[[2]string]models.Backfill)}
+}
+
+// GetBackfill returns a copy of the backfill of a source, or nil if there is none
+func (m *MemoryBackfillRepository) GetBackfill(ctx context.Context, connector, source string) (*models.Backfill, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backfill, ok := m.backfills[[2]string{connector, source}]
+	if !ok {
+		return nil, nil
+	}
+	return &backfill, nil
+}
+
+// SaveBackfill stores a copy of the backfill
+func (m *MemoryBackfillRepository) SaveBackfill(ctx context.Context, backfill *models.Backfill) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.backfills[[2]string{backfill.Connector, backfill.Source}] = *backfill
+	return nil
+}
+
+// MemoryNewsStorage keeps saved raw news in memory and drops queued IDs. It is used
+// for dry runs, where news must be fetched the regular way but not stored.
+type MemoryNewsStorage struct {
+	mu    sync.Mutex
+	news  []models.RawNews
+	saved map[[2]string]bool
+}
+
+// NewMemoryNewsStorage creates an empty in-memory news storage
+func NewMemoryNewsStorage() *MemoryNewsStorage {
+	return &MemoryNewsStorage{saved: make(map[[2]string]bool)}
+}
+
+// SaveRawNews keeps the news not saved yet, like MongoDB.SaveRawNews, and returns a
+// new ID for every item kept
+func (m *MemoryNewsStorage) SaveRawNews(ctx context.Context, news []models.RawNews) ([]primitive.ObjectID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []primitive.ObjectID
+	for _, item := range news {
+		key := [2]string{item.SourceType, item.SourceID}
+		if item.SourceID != "" && m.saved[key] {
+			continue
+		}
+		m.saved[key] = true
+		m.news = append(m.news, item)
+		ids = append(ids, primitive.NewObjectID())
+	}
+	return ids, nil
+}
+
+// AddToQueue does nothing, as nothing processes news saved in memory
+func (m *MemoryNewsStorage) AddToQueue(ctx context.Context, newsIDs []primitive.ObjectID) error {
+	return nil
+}
+
+// News returns the news saved so far in the order it was saved
+func (m *MemoryNewsStorage) News() []models.RawNews {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.RawNews(nil), m.news...)
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationsCollection records which schema migrations have been applied
+const migrationsCollection = "schema_migrations"
+
+// Migration is a numbered, idempotent change to the database schema or indexes
+type Migration struct {
+	Version     int
+	Description string
+	apply       func(ctx context.Context, m *MongoDB) error
+}
+
+// AppliedMigration describes a migration recorded in the schema_migrations collection
+type AppliedMigration struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// migrations lists every migration in version order. Append new ones at the end;
+// never renumber or edit one that has been released.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create news and channel state indexes",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			if err := m.createIndexes(ctx, m.rawCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "source_type", Value: 1}, {Key: "source_id", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "fetched_at", Value: -1}}},
+			); err != nil {
+				return err
+			}
+			if err := m.createIndexes(ctx, m.processedCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "raw_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "published_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "source_type", Value: 1}, {Key: "source_id", Value: 1}}},
+			); err != nil {
+				return err
+			}
+			return m.createIndexes(ctx, m.channelStateCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "channel_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			)
+		},
+	},
+	{
+		Version:     2,
+		Description: "create source breaker index",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			return m.createIndexes(ctx, m.breakerCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "connector", Value: 1}, {Key: "source", Value: 1}}, Options: options.Index().SetUnique(true)},
+			)
+		},
+	},
+	{
+		Version:     3,
+		Description: "create backfill index",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			return m.createIndexes(ctx, m.backfillCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "connector", Value: 1}, {Key: "source", Value: 1}}, Options: options.Index().SetUnique(true)},
+			)
+		},
+	},
+	{
+		Version:     4,
+		Description: "create story group indexes",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			return m.createIndexes(ctx, m.processedCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "canonical_url", Value: 1}, {Key: "published_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "story_id", Value: 1}}},
+			)
+		},
+	},
+	{
+		Version:     5,
+		Description: "create near duplicate lookup index",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			return m.createIndexes(ctx, m.processedCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "minhash_bands", Value: 1}, {Key: "published_at", Value: -1}}},
+			)
+		},
+	},
+	{
+		Version:     6,
+		Description: "make raw news unique per source type and ID",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			if err := m.removeDuplicateRawNews(ctx); err != nil {
+				return err
+			}
+			if err := m.dropIndex(ctx, m.rawCollection, "source_type_1_source_id_1"); err != nil {
+				return err
+			}
+			// Items without a source ID cannot be told apart and are left out
+			return m.createIndexes(ctx, m.rawCollection,
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "source_type", Value: 1}, {Key: "source_id", Value: 1}},
+					Options: options.Index().SetUnique(true).
+						SetPartialFilterExpression(bson.M{"source_id": bson.M{"$gt": ""}}),
+				},
+			)
+		},
+	},
+	{
+		Version:     7,
+		Description: "claim story IDs per canonical URL",
+		apply: func(ctx context.Context, m *MongoDB) error {
+			if err := m.createIndexes(ctx, storyURLsCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "canonical_url", Value: 1}}, Options: options.Index().SetUnique(true)},
+			); err != nil {
+				return err
+			}
+			// Existing URLs keep the story of their earliest item
+			cursor, err := m.client.Database(m.database).Collection(m.processedCollection).Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"canonical_url": bson.M{"$gt": ""}, "story_id": bson.M{"$exists": true}}}},
+				{{Key: "$sort", Value: bson.D{{Key: "published_at", Value: 1}}}},
+				{{Key: "$group", Value: bson.M{"_id": "$canonical_url", "story_id": bson.M{"$first": "$story_id"}}}},
+				{{Key: "$project", Value: bson.M{"_id": 0, "canonical_url": "$_id", "story_id": 1, "created_at": "$$NOW"}}},
+				{{Key: "$merge", Value: bson.M{"into": storyURLsCollection, "on": "canonical_url", "whenMatched": "keepExisting", "whenNotMatched": "insert"}}},
+			}, options.Aggregate().SetAllowDiskUse(true))
+			if err != nil {
+				return fmt.Errorf("failed to fill %s: %w", storyURLsCollection, err)
+			}
+			return cursor.Close(ctx)
+		},
+	},
+}
+
+// createIndexes creates the given indexes on a collection; existing identical indexes are left alone
+func (m *MongoDB) createIndexes(ctx context.Context, collection string, indexes ...mongo.IndexModel) error {
+	_, err := m.client.Database(m.database).Collection(collection).Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+	}
+	return nil
+}
+
+// indexNotFoundCode is the server error code of dropping an index that does not exist
+const indexNotFoundCode = 27
+
+// dropIndex drops the named index of a collection; a missing index is not an error
+func (m *MongoDB) dropIndex(ctx context.Context, collection, name string) error {
+	_, err := m.client.Database(m.database).Collection(collection).Indexes().DropOne(ctx, name)
+	var serverErr mongo.CommandError
+	if errors.As(err, &serverErr) && serverErr.Code == indexNotFoundCode {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drop index %s on %s: %w", name, collection, err)
+	}
+	return nil
+}
+
+// removeDuplicateRawNews keeps the first stored copy of raw news saved more than once
+// under the same source type and ID, so a unique index can be created on them
+func (m *MongoDB) removeDuplicateRawNews(ctx context.Context) error {
+	collection := m.client.Database(m.database).Collection(m.rawCollection)
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"source_id": bson.M{"$gt": ""}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"source_type": "$source_type", "source_id": "$source_id"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate raw news: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs []primitive.ObjectID `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}}); err != nil {
+			return fmt.Errorf("failed to remove duplicate raw news: %w", err)
+		}
+	}
+	return cursor.Err()
+}
+
+// Migrations returns every known migration in version order
+func Migrations() []Migration {
+	return migrations
+}
+
+// AppliedMigrations returns the migrations recorded as applied, in version order
+func (m *MongoDB) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	collection := m.client.Database(m.database).Collection(migrationsCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []AppliedMigration{}
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Migrate applies every migration that has not been applied yet and returns the ones it applied
+func (m *MongoDB) Migrate(ctx context.Context) ([]Migration, error) {
+	applied, err := m.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	collection := m.client.Database(m.database).Collection(migrationsCollection)
+	var ran []Migration
+	for _, migration := range migrations {
+		if done[migration.Version] {
+			continue
+		}
+
+		if err := migration.apply(ctx, m); err != nil {
+			return ran, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		record := AppliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if _, err := collection.InsertOne(ctx, record); err != nil {
+			return ran, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/dzianismalei/infoBro/internal/models"
@@ -19,11 +20,13 @@ type MongoDB struct {
 	rawCollection      string
 	processedCollection string
 	channelStateCollection string
+	breakerCollection   string
+	backfillCollection  string
 }
 
 // NewMongoDB creates a new MongoDB storage instance
-func NewMongoDB(uri, database, rawColl, processedColl, channelStateColl string) (*MongoDB, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func NewMongoDB(uri, database, rawColl, processedColl, channelStateColl, breakerColl, backfillColl string, connectTimeout time.Duration) (*MongoDB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
@@ -43,6 +46,8 @@ func NewMongoDB(uri, database, rawColl, processedColl, channelStateColl string)
 		rawCollection:         rawColl,
 		processedCollection:   processedColl,
 		channelStateCollection: channelStateColl,
+		breakerCollection:     breakerColl,
+		backfillCollection:    backfillColl,
 	}, nil
 }
 
@@ -51,7 +56,9 @@ func (m *MongoDB) Close(ctx context.Context) error {
 	return m.client.Disconnect(ctx)
 }
 
-// SaveRawNews saves raw news items to MongoDB and returns their ObjectIDs
+// SaveRawNews saves raw news items to MongoDB and returns the ObjectIDs of the items
+// that were inserted. Items whose source type and ID are already stored are skipped,
+// so overlapping runs and backfills do not queue the same news twice.
 func (m *MongoDB) SaveRawNews(ctx context.Context, news []models.RawNews) ([]primitive.ObjectID, error) {
 	if len(news) == 0 {
 		return []primitive.ObjectID{}, nil
@@ -59,11 +66,16 @@ func (m *MongoDB) SaveRawNews(ctx context.Context, news []models.RawNews) ([]pri
 
 	collection := m.client.Database(m.database).Collection(m.rawCollection)
 	
-	var documents []interface{}
-	for _, item := range news {
+	ids := make([]primitive.ObjectID, len(news))
+	writes := make([]mongo.WriteModel, len(news))
+	for i, item := range news {
+		ids[i] = primitive.NewObjectID()
 		doc := bson.M{
+			"_id":          ids[i],
 			"source_type":  item.SourceType,
 			"source_id":    item.SourceID,
+			"source_name":  item.SourceName,
+			"source_url":   item.SourceURL,
 			"title":        item.Title,
 			"content":      item.Content,
 			"url":          item.URL,
@@ -71,22 +83,94 @@ func (m *MongoDB) SaveRawNews(ctx context.Context, news []models.RawNews) ([]pri
 			"fetched_at":   item.FetchedAt,
 			"metadata":     item.Metadata,
 		}
-		documents = append(documents, doc)
+		if item.SourceID == "" {
+			// Without an ID the item cannot be matched against stored news
+			writes[i] = mongo.NewInsertOneModel().SetDocument(doc)
+			continue
+		}
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"source_type": item.SourceType, "source_id": item.SourceID}).
+			SetUpdate(bson.M{"$setOnInsert": doc}).
+			SetUpsert(true)
 	}
 
-	result, err := collection.InsertMany(ctx, documents)
+	// Unordered, so an item saved concurrently by another run only fails its own write
+	result, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	stored, err := duplicateWrites(err)
 	if err != nil {
 		return nil, err
 	}
 
-	var ids []primitive.ObjectID
-	for _, id := range result.InsertedIDs {
-		if oid, ok := id.(primitive.ObjectID); ok {
-			ids = append(ids, oid)
+	var inserted []primitive.ObjectID
+	for i, item := range news {
+		if stored[i] {
+			continue
+		}
+		if _, upserted := result.UpsertedIDs[int64(i)]; upserted || item.SourceID == "" {
+			inserted = append(inserted, ids[i])
 		}
 	}
 
-	return ids, nil
+	return inserted, nil
+}
+
+// duplicateWrites returns the indexes of the writes of a bulk write that failed
+// because the unique source index already holds the item, which means another run
+// stored it first. Any other error is returned as is.
+func duplicateWrites(err error) (map[int]bool, error) {
+	if err == nil {
+		return nil, nil
+	}
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return nil, err
+	}
+	duplicates := make(map[int]bool, len(bulkErr.WriteErrors))
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return nil, err
+		}
+		duplicates[writeErr.Index] = true
+	}
+	return duplicates, nil
+}
+
+// rawNewsDocument is the raw_news collection schema
+type rawNewsDocument struct {
+	ID          primitive.ObjectID     `bson:"_id"`
+	SourceType  string                 `bson:"source_type"`
+	SourceID    string                 `bson:"source_id"`
+	SourceName  string                 `bson:"source_name"`
+	SourceURL   string                 `bson:"source_url"`
+	Title       string                 `bson:"title"`
+	Content     string                 `bson:"content"`
+	URL         string                 `bson:"url"`
+	PublishedAt time.Time              `bson:"published_at"`
+	FetchedAt   time.Time              `bson:"fetched_at"`
+	Metadata    map[string]interface{} `bson:"metadata"`
+}
+
+// GetRawNews retrieves a raw news item by its ObjectID
+func (m *MongoDB) GetRawNews(ctx context.Context, id primitive.ObjectID) (*models.RawNews, error) {
+	collection := m.client.Database(m.database).Collection(m.rawCollection)
+
+	var doc rawNewsDocument
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return &models.RawNews{
+		SourceType:  doc.SourceType,
+		SourceID:    doc.SourceID,
+		SourceName:  doc.SourceName,
+		SourceURL:   doc.SourceURL,
+		Title:       doc.Title,
+		Content:     doc.Content,
+		URL:         doc.URL,
+		PublishedAt: doc.PublishedAt,
+		FetchedAt:   doc.FetchedAt,
+		Metadata:    doc.Metadata,
+	}, nil
 }
 
 // GetChannelState retrieves the state for a specific channel
@@ -101,6 +185,8 @@ func (m *MongoDB) GetChannelState(ctx context.Context, channelID string) (*model
 		LastMessageID     string             `bson:"last_message_id"`
 		LastUpdateTime    time.Time          `bson:"last_update_time"`
 		ProcessedMessages int                `bson:"processed_messages"`
+		SeenIDs           []string           `bson:"seen_ids"`
+		Validators        []models.HTTPValidators `bson:"validators"`
 	}
 	
 	err := collection.FindOne(ctx, filter).Decode(&result)
@@ -122,6 +208,8 @@ func (m *MongoDB) GetChannelState(ctx context.Context, channelID string) (*model
 		LastMessageID:     result.LastMessageID,
 		LastUpdateTime:    result.LastUpdateTime,
 		ProcessedMessages: result.ProcessedMessages,
+		SeenIDs:           result.SeenIDs,
+		Validators:        result.Validators,
 	}, nil
 }
 
@@ -135,6 +223,8 @@ func (m *MongoDB) UpdateChannelState(ctx context.Context, state *models.ChannelS
 			"last_message_id":     state.LastMessageID,
 			"last_update_time":    state.LastUpdateTime,
 			"processed_messages":  state.ProcessedMessages,
+			"seen_ids":            state.SeenIDs,
+			"validators":          state.Validators,
 		},
 	}
 	
@@ -143,14 +233,101 @@ func (m *MongoDB) UpdateChannelState(ctx context.Context, state *models.ChannelS
 	return err
 }
 
-// SaveProcessedNews saves a processed news item to MongoDB
+// SaveProcessedNews saves a processed news item to MongoDB.
+// Items are keyed by raw_id, so processing the same raw item again replaces the earlier result.
 func (m *MongoDB) SaveProcessedNews(ctx context.Context, news models.ProcessedNews) (primitive.ObjectID, error) {
 	collection := m.client.Database(m.database).Collection(m.processedCollection)
 	
-	result, err := collection.InsertOne(ctx, news)
+	news.ID = primitive.NilObjectID
+	opts := options.FindOneAndReplace().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"_id": 1})
+
+	var saved struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := collection.FindOneAndReplace(ctx, bson.M{"raw_id": news.RawID}, news, opts).Decode(&saved)
 	if err != nil {
 		return primitive.NilObjectID, err
 	}
 	
-	return result.InsertedID.(primitive.ObjectID), nil
-}
\ No newline at end of file
+	return saved.ID, nil
+}
+
+// ProcessedNewsFilter narrows down a processed news listing; zero fields are ignored
+type ProcessedNewsFilter struct {
+	SourceType string
+	SourceID   string
+	Query      string
+	FromDate   time.Time
+	ToDate     time.Time
+}
+
+// bson builds the MongoDB query for the filter
+func (f ProcessedNewsFilter) bson() bson.M {
+	query := bson.M{}
+	if f.SourceType != "" {
+		query["source_type"] = f.SourceType
+	}
+	if f.SourceID != "" {
+		query["source_id"] = f.SourceID
+	}
+	if f.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(f.Query), Options: "i"}
+		query["$or"] = bson.A{
+			bson.M{"title": pattern},
+			bson.M{"content": pattern},
+		}
+	}
+	published := bson.M{}
+	if !f.FromDate.IsZero() {
+		published["$gte"] = f.FromDate
+	}
+	if !f.ToDate.IsZero() {
+		published["$lte"] = f.ToDate
+	}
+	if len(published) > 0 {
+		query["published_at"] = published
+	}
+	return query
+}
+
+// ListProcessedNews returns one page of processed news, newest first, and the total number of matches
+func (m *MongoDB) ListProcessedNews(ctx context.Context, filter ProcessedNewsFilter, page, pageSize int) ([]models.ProcessedNews, int64, error) {
+	collection := m.client.Database(m.database).Collection(m.processedCollection)
+	query := filter.bson()
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	news := []models.ProcessedNews{}
+	if err := cursor.All(ctx, &news); err != nil {
+		return nil, 0, err
+	}
+
+	return news, total, nil
+}
+
+// GetProcessedNews retrieves a processed news item by its ObjectID
+func (m *MongoDB) GetProcessedNews(ctx context.Context, id primitive.ObjectID) (*models.ProcessedNews, error) {
+	collection := m.client.Database(m.database).Collection(m.processedCollection)
+
+	var news models.ProcessedNews
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&news); err != nil {
+		return nil, err
+	}
+	return &news, nil
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDuplicateWrites(t *testing.T) {
+	duplicate := func(index int) mongo.BulkWriteError {
+		return mongo.BulkWriteError{WriteError: mongo.WriteError{Index: index, Code: 11000, Message: "E11000 duplicate key error"}}
+	}
+
+	stored, err := duplicateWrites(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+
+	stored, err = duplicateWrites(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate(1), duplicate(3)}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 3: true}, stored)
+
+	other := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		duplicate(0),
+		{WriteError: mongo.WriteError{Index: 1, Code: 121, Message: "Document failed validation"}},
+	}}
+	_, err = duplicateWrites(other)
+	assert.Error(t, err)
+
+	_, err = duplicateWrites(errors.New("connection refused"))
+	assert.Error(t, err)
+}
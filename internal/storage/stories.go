@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dzianismalei/infoBro/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// storyURLsCollection maps every canonical URL to the story of the first item seen with it
+const storyURLsCollection = "story_urls"
+
+// FindStory returns the story ID claimed for a canonical URL, or an empty string if
+// there is none
+func (m *MongoDB) FindStory(ctx context.Context, canonicalURL string) (string, error) {
+	collection := m.client.Database(m.database).Collection(storyURLsCollection)
+
+	var found struct {
+		StoryID string `bson:"story_id"`
+	}
+	if err := collection.FindOne(ctx, bson.M{"canonical_url": canonicalURL}).Decode(&found); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		return "", err
+	}
+	return found.StoryID, nil
+}
+
+// ClaimStory assigns storyID to a canonical URL unless another story claimed it first,
+// and returns the story ID of the URL. The unique index on canonical_url makes the
+// claim atomic across processor workers.
+func (m *MongoDB) ClaimStory(ctx context.Context, canonicalURL, storyID string) (string, error) {
+	collection := m.client.Database(m.database).Collection(storyURLsCollection)
+
+	filter := bson.M{"canonical_url": canonicalURL}
+	update := bson.M{"$setOnInsert": bson.M{"story_id": storyID, "created_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	for attempt := 0; ; attempt++ {
+		var claimed struct {
+			StoryID string `bson:"story_id"`
+		}
+		err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&claimed)
+		if mongo.IsDuplicateKeyError(err) && attempt == 0 {
+			// A concurrent claim inserted the URL first; the retry returns its story
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return claimed.StoryID, nil
+	}
+}
+
+// SimilarStories returns up to limit processed news published between from and to that
+// share a MinHash band with bands, newest first, with only their story fields
+func (m *MongoDB) SimilarStories(ctx context.Context, bands []string, from, to time.Time, limit int) ([]models.ProcessedNews, error) {
+	if len(bands) == 0 {
+		return nil, nil
+	}
+	collection := m.client.Database(m.database).Collection(m.processedCollection)
+
+	filter := bson.M{
+		"minhash_bands": bson.M{"$in": bands},
+		"published_at":  bson.M{"$gte": from, "$lte": to},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: -1}}).
+		SetProjection(bson.M{"raw_id": 1, "story_id": 1, "minhash": 1, "published_at": 1})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var news []models.ProcessedNews
+	if err := cursor.All(ctx, &news); err != nil {
+		return nil, err
+	}
+	return news, nil
+}
+
+// storyKey groups processed news by story; items processed before story groups
+// existed are stories of their own
+var storyKey = bson.M{"$ifNull": bson.A{"$story_id", bson.M{"$toString": "$_id"}}}
+
+// ListStories returns one page of stories matching the filter, each represented by
+// its earliest matching item, ordered by their latest item, and the number of stories
+func (m *MongoDB) ListStories(ctx context.Context, filter ProcessedNewsFilter, page, pageSize int) ([]models.ProcessedNews, int64, error) {
+	collection := m.client.Database(m.database).Collection(m.processedCollection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter.bson()}},
+		{{Key: "$sort", Value: bson.D{{Key: "published_at", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    storyKey,
+			"item":   bson.M{"$first": "$$ROOT"},
+			"latest": bson.M{"$max": "$published_at"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "latest", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "count"}},
+			"items": bson.A{
+				bson.M{"$skip": int64((page - 1) * pageSize)},
+				bson.M{"$limit": int64(pageSize)},
+			},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var results []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Items []struct {
+			Item models.ProcessedNews `bson:"item"`
+		} `bson:"items"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+
+	news := []models.ProcessedNews{}
+	var total int64
+	if len(results) > 0 {
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Count
+		}
+		for _, story := range results[0].Items {
+			news = append(news, story.Item)
+		}
+	}
+	return news, total, nil
+}
+
+// StoryMembers returns every processed news of the given stories, oldest first, by story ID
+func (m *MongoDB) StoryMembers(ctx context.Context, storyIDs []string) (map[string][]models.ProcessedNews, error) {
+	members := make(map[string][]models.ProcessedNews, len(storyIDs))
+	if len(storyIDs) == 0 {
+		return members, nil
+	}
+	collection := m.client.Database(m.database).Collection(m.processedCollection)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "published_at", Value: 1}}).
+		SetProjection(bson.M{"content": 0, "content_html": 0, "content_text": 0, "minhash": 0, "minhash_bands": 0})
+	cursor, err := collection.Find(ctx, bson.M{"story_id": bson.M{"$in": storyIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var news []models.ProcessedNews
+	if err := cursor.All(ctx, &news); err != nil {
+		return nil, err
+	}
+	for _, item := range news {
+		members[item.StoryID] = append(members[item.StoryID], item)
+	}
+	return members, nil
+}
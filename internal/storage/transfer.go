@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxImportLine bounds the size of a single exported document
+const maxImportLine = 16 * 1024 * 1024
+
+// collectionNames maps the logical collection names used by export and import to the configured ones
+func (m *MongoDB) collectionNames() map[string]string {
+	return map[string]string{
+		"raw_news":       m.rawCollection,
+		"processed_news": m.processedCollection,
+		"channel_states": m.channelStateCollection,
+		"story_urls":     storyURLsCollection,
+	}
+}
+
+// TransferCollections returns the logical names of the collections that can be exported and imported
+func (m *MongoDB) TransferCollections() []string {
+	names := make([]string, 0, len(m.collectionNames()))
+	for name := range m.collectionNames() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveCollection maps a logical collection name to the configured one
+func (m *MongoDB) resolveCollection(name string) (string, error) {
+	resolved, ok := m.collectionNames()[name]
+	if !ok {
+		return "", fmt.Errorf("unknown collection %q, must be one of %v", name, m.TransferCollections())
+	}
+	return resolved, nil
+}
+
+// Export writes every document of a collection to w as canonical Extended JSON, one per line
+func (m *MongoDB) Export(ctx context.Context, name string, w io.Writer) (int, error) {
+	resolved, err := m.resolveCollection(name)
+	if err != nil {
+		return 0, err
+	}
+	collection := m.client.Database(m.database).Collection(resolved)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	writer := bufio.NewWriter(w)
+	count := 0
+	for cursor.Next(ctx) {
+		line, err := bson.MarshalExtJSON(cursor.Current, true, false)
+		if err != nil {
+			return count, fmt.Errorf("failed to encode document %d: %w", count+1, err)
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return count, err
+	}
+
+	return count, writer.Flush()
+}
+
+// Import reads Extended JSON documents, one per line, and upserts them into a collection by _id,
+// so importing the same export twice does not create duplicates
+func (m *MongoDB) Import(ctx context.Context, name string, r io.Reader) (int, error) {
+	resolved, err := m.resolveCollection(name)
+	if err != nil {
+		return 0, err
+	}
+	collection := m.client.Database(m.database).Collection(resolved)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+
+	count := 0
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(scanner.Bytes(), true, &doc); err != nil {
+			return count, fmt.Errorf("line %d: invalid document: %w", line, err)
+		}
+
+		var id interface{}
+		for _, element := range doc {
+			if element.Key == "_id" {
+				id = element.Value
+				break
+			}
+		}
+		if id == nil {
+			return count, fmt.Errorf("line %d: document has no _id", line)
+		}
+
+		opts := options.Replace().SetUpsert(true)
+		if _, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, doc, opts); err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+		count++
+	}
+
+	return count, scanner.Err()
+}
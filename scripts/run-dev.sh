@@ -27,9 +27,15 @@ fi
 
 # Start backend in background
 echo "Starting backend..."
-./bin/infobro --mongo-uri mongodb://localhost:27017 --redis-addr localhost:6379 &
+./bin/infobro migrate --mongo-uri mongodb://localhost:27017
+./bin/infobro serve --mongo-uri mongodb://localhost:27017 --redis-addr localhost:6379 &
 BACKEND_PID=$!
 
+# Start processor in background
+echo "Starting worker..."
+./bin/infobro worker --mongo-uri mongodb://localhost:27017 --redis-addr localhost:6379 &
+WORKER_PID=$!
+
 # Change to web directory
 cd web
 
@@ -48,6 +54,7 @@ FRONTEND_PID=$!
 function cleanup {
   echo "Shutting down..."
   kill $BACKEND_PID
+  kill $WORKER_PID
   kill $FRONTEND_PID
 }
 
@@ -74,13 +74,21 @@ const NewsDetailPage: React.FC = () => {
             <span className="text-gray-600">{newsItem.source_name}</span>
             <span className="mx-2 text-gray-500">•</span>
             <span className="text-gray-500">{formattedDate}</span>
+            {newsItem.reading_minutes ? (
+              <>
+                <span className="mx-2 text-gray-500">•</span>
+                <span className="text-gray-500">{newsItem.reading_minutes} min read</span>
+              </>
+            ) : null}
           </div>
 
           <h1 className="text-3xl font-bold text-gray-900 mb-6">{newsItem.title}</h1>
 
           <div className="prose max-w-none">
-            {newsItem.content ? (
-              <div dangerouslySetInnerHTML={{ __html: newsItem.content.replace(/\n/g, '<br />') }} />
+            {newsItem.content_html ? (
+              <div dangerouslySetInnerHTML={{ __html: newsItem.content_html }} />
+            ) : newsItem.content ? (
+              <p className="whitespace-pre-line">{newsItem.content_text || newsItem.content}</p>
             ) : (
               <p className="text-gray-600">No content available for this news item.</p>
             )}
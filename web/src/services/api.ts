@@ -14,6 +14,11 @@ export interface NewsItem {
   title: string;
   content?: string;
   content_preview?: string;
+  content_html?: string;
+  content_text?: string;
+  lead_image?: string;
+  word_count?: number;
+  reading_minutes?: number;
   source_type: string;
   source_id: string;
   source_name: string;